@@ -0,0 +1,35 @@
+package easierweb
+
+import (
+	"net/http"
+)
+
+// WrapHTTPHandler convert a http.Handler to a handle
+func WrapHTTPHandler(handler http.Handler) Handle {
+	return func(ctx *Context) {
+		handler.ServeHTTP(ctx.ResponseWriter, ctx.Request)
+	}
+}
+
+// WrapHTTPMiddleware convert a net/http middleware to a middleware handle
+// if the net/http middleware does not call the next handler, the subsequent handles are aborted
+// e.g. router.Use(easierweb.WrapHTTPMiddleware(handlers.CompressHandler))
+func WrapHTTPMiddleware(middleware func(http.Handler) http.Handler) Handle {
+	return func(ctx *Context) {
+		called := false
+		res, req := ctx.ResponseWriter, ctx.Request
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			// the middleware may replace the response writer and the request (e.g. add context values)
+			ctx.ResponseWriter = w
+			ctx.Request = r
+			ctx.Next()
+		})
+		middleware(next).ServeHTTP(res, req)
+		ctx.ResponseWriter = res
+		ctx.Request = req
+		if !called {
+			ctx.Abort()
+		}
+	}
+}
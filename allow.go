@@ -0,0 +1,71 @@
+package easierweb
+
+import (
+	"net/http"
+	"strings"
+)
+
+// serveHEAD serve the HEAD request by the GET handle if the HEAD handle is not registered
+func (r *Router) serveHEAD(res http.ResponseWriter, req *http.Request) bool {
+	if handle, _, _ := r.router.Lookup(MethodHEAD, req.URL.Path); handle != nil {
+		return false
+	}
+	handle, par, _ := r.router.Lookup(MethodGET, req.URL.Path)
+	if handle == nil {
+		return false
+	}
+	handle(res, req, par)
+	return true
+}
+
+// allowedMethods the methods of the path (including the automatic HEAD and OPTIONS), e.g. GET, HEAD, OPTIONS
+func (r *Router) allowedMethods(path string) []string {
+	var methods []string
+	options := false
+	for _, method := range methodNames {
+		if handle, _, _ := r.router.Lookup(method, path); handle != nil {
+			methods = append(methods, method)
+			options = options || method == MethodOPTIONS
+		} else if method == MethodHEAD && r.autoHEAD {
+			if handle, _, _ := r.router.Lookup(MethodGET, path); handle != nil {
+				methods = append(methods, method)
+			}
+		}
+	}
+	// the OPTIONS requests of the existing paths are answered automatically
+	if !options && len(methods) > 0 && r.router.HandleOPTIONS {
+		methods = append(methods, MethodOPTIONS)
+	}
+	return methods
+}
+
+// serveOPTIONS answer the OPTIONS request with the Allow header by the OptionsHandle (default 204), the router-level middlewares are executed
+func (r *Router) serveOPTIONS(res http.ResponseWriter, req *http.Request) {
+	// the header is set before the middlewares, they may abort the request (e.g. CORS preflight)
+	r.setAllow(res, req)
+	handle := r.optionsHandle
+	if handle == nil {
+		handle = func(ctx *Context) {
+			ctx.NoContent(http.StatusNoContent)
+		}
+	}
+	r.handle(nil, handle, res, req, nil)
+}
+
+// methodNotAllowed answer the request with the Allow header by the MethodNotAllowedHandle (default a 405 *Error passed to the ErrorHandle)
+func (r *Router) methodNotAllowed(res http.ResponseWriter, req *http.Request) {
+	r.setAllow(res, req)
+	handle := r.methodNotAllowedHandle
+	if handle == nil {
+		handle = func(ctx *Context) {
+			ctx.HandleError(NewError(http.StatusMethodNotAllowed, "method not allowed"))
+		}
+	}
+	r.handle(nil, handle, res, req, nil)
+}
+
+func (r *Router) setAllow(res http.ResponseWriter, req *http.Request) {
+	if methods := r.allowedMethods(req.URL.Path); len(methods) > 0 {
+		res.Header().Set("Allow", strings.Join(methods, ", "))
+	}
+}
@@ -0,0 +1,151 @@
+package easierweb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// AuditEvent the structured event of a request, delivered to the AuditHook after the request is completed
+type AuditEvent struct {
+	// start time of the request
+	Time time.Time
+	// the identity set by the authentication middleware (ctx.Principal()), empty if not authenticated
+	Principal string
+	// the route name (see Router.Name), or "METHOD route" if the route is not named
+	Action    string
+	Method    string
+	Route     string
+	Path      string
+	Status    int
+	Latency   time.Duration
+	ClientIP  string
+	RequestID string
+}
+
+// AuditHook receive the audit events, it is called in a single goroutine in the order of completion
+type AuditHook func(event AuditEvent)
+
+type AuditOptions struct {
+	// size of the event buffer, default 1024, the events are dropped (and logged) if the hook falls behind and the buffer is full
+	BufferSize int
+	// skip the request if it returns true, e.g. the health checks
+	Skip func(ctx *Context) bool
+}
+
+type auditor struct {
+	hook   AuditHook
+	events chan AuditEvent
+	done   chan struct{}
+	lock   sync.RWMutex
+	closed bool
+}
+
+// AuditHook deliver an audit event of each request to the hook asynchronously, e.g. write to the compliance log store
+// the pending events are delivered by Close (within the ShutdownTimeout)
+func (r *Router) AuditHook(hook AuditHook, opts ...AuditOptions) *Router {
+	opt := AuditOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.BufferSize <= 0 {
+		opt.BufferSize = 1024
+	}
+	a := &auditor{
+		hook:   hook,
+		events: make(chan AuditEvent, opt.BufferSize),
+		done:   make(chan struct{}),
+	}
+	go a.deliver(r)
+	r.auditors = append(r.auditors, a)
+	// the audit middleware is executed first, so that the status of the other middlewares (e.g. 401 of the authentication) is recorded
+	r.middlewares = append([]Handle{a.middleware(opt)}, r.middlewares...)
+	return r
+}
+
+func (a *auditor) middleware(opt AuditOptions) Handle {
+	return func(ctx *Context) {
+		if opt.Skip != nil && opt.Skip(ctx) {
+			ctx.Next()
+			return
+		}
+		start := time.Now()
+		defer func() {
+			err := recover()
+			status := ctx.ResponseStatus()
+			if err != nil {
+				status = http.StatusInternalServerError
+			} else if status == 0 {
+				status = ctx.Code
+			}
+			action := ctx.RouteName()
+			if action == "" {
+				route := ctx.Route
+				if route == "" {
+					route = ctx.Request.URL.Path
+				}
+				action = ctx.Request.Method + " " + route
+			}
+			a.push(ctx, AuditEvent{
+				Time:      start,
+				Principal: ctx.Principal(),
+				Action:    action,
+				Method:    ctx.Request.Method,
+				Route:     ctx.Route,
+				Path:      ctx.Request.URL.Path,
+				Status:    status,
+				Latency:   time.Since(start),
+				ClientIP:  ctx.ClientIP(),
+				RequestID: ctx.RequestID(),
+			})
+			if err != nil {
+				panic(err)
+			}
+		}()
+		ctx.Next()
+	}
+}
+
+func (a *auditor) push(ctx *Context, event AuditEvent) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	if a.closed {
+		return
+	}
+	select {
+	case a.events <- event:
+	default:
+		ctx.Logger.Warn(fmt.Sprintf("audit event is dropped, the buffer is full: %s", event.Action))
+	}
+}
+
+func (a *auditor) deliver(r *Router) {
+	defer close(a.done)
+	for event := range a.events {
+		func() {
+			defer func() {
+				err := recover()
+				if err != nil {
+					r.logger.Error(fmt.Sprintf("audit hook error: %s", err))
+				}
+			}()
+			a.hook(event)
+		}()
+	}
+}
+
+// close stop accepting the events, and wait for the pending events to be delivered
+func (a *auditor) close(ctx context.Context) {
+	a.lock.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.events)
+	}
+	a.lock.Unlock()
+	select {
+	case <-a.done:
+	case <-ctx.Done():
+	}
+}
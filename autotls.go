@@ -0,0 +1,55 @@
+package easierweb
+
+import (
+	"errors"
+	"golang.org/x/crypto/acme/autocert"
+	"net/http"
+)
+
+// AutoTLSOptions settings of RunAutoTLS (Let's Encrypt)
+type AutoTLSOptions struct {
+	// directory to cache the certificates, default "autocert-cache"
+	CacheDir string
+	// contact email of the ACME account, optional
+	Email string
+	// address of the HTTPS server, default ":443"
+	Addr string
+	// address of the HTTP server that answers the ACME http-01 challenges and redirects other requests to HTTPS, default ":80", "-" disables it
+	HTTPAddr string
+}
+
+// RunAutoTLS start the HTTPS server with the certificates obtained (and renewed) automatically from Let's Encrypt
+// only the domains are allowed to request the certificates
+func (r *Router) RunAutoTLS(domains ...string) error {
+	opt := r.autoTLSOptions
+	if opt.CacheDir == "" {
+		opt.CacheDir = "autocert-cache"
+	}
+	if opt.Addr == "" {
+		opt.Addr = ":443"
+	}
+	if opt.HTTPAddr == "" {
+		opt.HTTPAddr = ":80"
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Cache:      autocert.DirCache(opt.CacheDir),
+		Email:      opt.Email,
+	}
+	if opt.HTTPAddr != "-" {
+		// a nil fallback handler redirects the requests to HTTPS
+		redirect := r.newServer(opt.HTTPAddr)
+		redirect.Handler = m.HTTPHandler(nil)
+		r.addServer(redirect)
+		go func() {
+			err := redirect.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				r.logger.Error("autocert http server error: " + err.Error())
+			}
+		}()
+	}
+	server := r.newServer(opt.Addr)
+	server.TLSConfig = m.TLSConfig()
+	return r.ServeTLS(server, "", "")
+}
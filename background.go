@@ -0,0 +1,79 @@
+package easierweb
+
+import (
+	"context"
+	"github.com/julienschmidt/httprouter"
+	"maps"
+	"net/http"
+	"runtime/debug"
+)
+
+// Copy create a detached snapshot of the context, it is safe to use in goroutines after the handle returns (the context is pooled and reused)
+// the request data is copied, the request context is not canceled when the response is completed, and the response cannot be written
+func (c *Context) Copy() *Context {
+	cp := &Context{
+		Route:          c.Route,
+		Header:         maps.Clone(c.Header),
+		Path:           maps.Clone(c.Path),
+		Query:          maps.Clone(c.Query),
+		Form:           maps.Clone(c.Form),
+		Body:           append(Data(nil), c.Body...),
+		Code:           c.Code,
+		Result:         append(Data(nil), c.Result...),
+		Request:        c.Request.Clone(context.WithoutCancel(c.Request.Context())),
+		ResponseWriter: &discardResponseWriter{header: make(http.Header)},
+		Logger:         c.Logger,
+		router:         c.router,
+		routeName:      c.routeName,
+		claims:         maps.Clone(c.claims),
+		principal:      c.principal,
+		requestID:      c.requestID,
+		cspNonce:       c.cspNonce,
+		debug:          c.debug,
+		params:         append(httprouter.Params(nil), c.params...),
+		// the handles are not copied, so Next does nothing
+		index:   1,
+		aborted: true,
+		written: true,
+		closed:  true,
+	}
+	c.keysLock.RLock()
+	cp.keys = maps.Clone(c.keys)
+	c.keysLock.RUnlock()
+	return cp
+}
+
+// Go run the function in a goroutine with a copy of the context, the panic is recovered and handled by the recovery hook and the error handle (logged)
+func (r *Router) Go(ctx *Context, fn func(ctx *Context)) {
+	cp := ctx.Copy()
+	go func() {
+		defer func() {
+			sErr := recover()
+			if sErr != nil {
+				cp.stack = debug.Stack()
+				r.recovery(cp, sErr)
+			}
+		}()
+		fn(cp)
+	}()
+}
+
+// Go see Router.Go, e.g. ctx.Go(func(ctx *easierweb.Context) { sendEmail(ctx, ctx.Query.Get("to")) })
+func (c *Context) Go(fn func(ctx *Context)) {
+	c.router.Go(c, fn)
+}
+
+// discardResponseWriter the response writer of the copied context
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardResponseWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (w *discardResponseWriter) WriteHeader(int) {}
@@ -0,0 +1,451 @@
+package easierweb
+
+import (
+	"encoding"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaulter the request object of the easy handle sets its default values before binding
+type Defaulter interface {
+	Default()
+}
+
+// BeforeBinder the request object of the easy handle is called before binding, the error is returned as the response
+type BeforeBinder interface {
+	BeforeBind(ctx *Context) error
+}
+
+// AfterBinder the request object of the easy handle is called after binding and before validation,
+// e.g. normalize, trim and derive the fields, the error is returned as the response
+type AfterBinder interface {
+	AfterBind(ctx *Context) error
+}
+
+// bindField the struct field bound from the request parameters
+type bindField struct {
+	index []int
+	// query, param, header or form, empty if the field only has a default value
+	source     string
+	name       string
+	def        string
+	hasDef     bool
+	timeFormat string
+	// form struct, pointer to struct or slice of structs, bound by the nested names, e.g. address.city, items[0].name
+	nested bool
+}
+
+var (
+	bindSources   = []string{"query", "param", "header", "form"}
+	bindCache     sync.Map
+	durationType  = reflect.TypeOf(time.Duration(0))
+	unmarshalType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	fileType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	filesType     = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+// BindParams bind the struct fields tagged by query, param (path), header and form, e.g.
+//
+//	type Request struct {
+//		Id    int64     `param:"id"`
+//		Page  int       `query:"page" default:"1"`
+//		Tags  []string  `query:"tag"`
+//		Since time.Time `query:"since" time_format:"2006-01-02"`
+//		Token string    `header:"X-Token"`
+//	}
+//
+// the default tag is used when the parameter is absent (or the field is zero if it has no source tag)
+// supported types: string, bool, int*, uint*, float*, time.Time, time.Duration, encoding.TextUnmarshaler, pointers and slices of them
+// returns *Error with 400 if the conversion fails
+func (c *Context) BindParams(obj any) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("bind params: %T is not a non-nil pointer", obj)
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	fields := cachedBindFields(v.Type())
+	if len(fields) == 0 {
+		return nil
+	}
+	var query map[string][]string
+	for _, f := range fields {
+		var values []string
+		switch f.source {
+		case "query":
+			if query == nil {
+				query = c.Request.URL.Query()
+			}
+			values = query[f.name]
+		case "param":
+			if c.Path.Has(f.name) {
+				values = []string{c.Path.Get(f.name)}
+			}
+		case "header":
+			values = c.Request.Header.Values(f.name)
+		case "form":
+			fv := v.FieldByIndex(f.index)
+			if fv.Type() == fileType || fv.Type() == filesType {
+				setFileField(fv, c.formFiles()[f.name])
+				continue
+			}
+			if f.nested {
+				err := bindFormNested(fv, f.name, c.formValues(), c.formFiles())
+				if err != nil {
+					return NewError(http.StatusBadRequest, "invalid form parameter "+err.Error()).
+						WithCode("INVALID_PARAMETER").
+						WithDetails(map[string]any{"in": "form", "name": f.name})
+				}
+				continue
+			}
+			values = c.formValues()[f.name]
+		}
+		fv := v.FieldByIndex(f.index)
+		if len(values) == 0 {
+			if !f.hasDef || (f.source == "" && !fv.IsZero()) {
+				continue
+			}
+			values = []string{f.def}
+		}
+		err := setField(fv, values, f.timeFormat)
+		if err != nil {
+			in := f.source
+			if in == "" {
+				in = "default"
+			}
+			return NewError(http.StatusBadRequest, fmt.Sprintf("invalid %s parameter %s: %s", in, f.name, err)).
+				WithCode("INVALID_PARAMETER").
+				WithDetails(map[string]any{"in": in, "name": f.name})
+		}
+	}
+	return nil
+}
+
+// usesBindSource whether the struct has fields tagged by the source, e.g. query
+func usesBindSource(obj any, source string) bool {
+	t := reflect.TypeOf(obj)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return false
+	}
+	for _, f := range cachedBindFields(t) {
+		if f.source == source {
+			return true
+		}
+	}
+	return false
+}
+
+func cachedBindFields(t reflect.Type) []bindField {
+	if v, ok := bindCache.Load(t); ok {
+		return v.([]bindField)
+	}
+	fields := collectBindFields(t, nil)
+	bindCache.Store(t, fields)
+	return fields
+}
+
+func collectBindFields(t reflect.Type, index []int) []bindField {
+	var fields []bindField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+		f := bindField{index: fieldIndex, timeFormat: sf.Tag.Get("time_format")}
+		for _, source := range bindSources {
+			if name, ok := sf.Tag.Lookup(source); ok && name != "" && name != "-" {
+				f.source, f.name = source, name
+				break
+			}
+		}
+		f.def, f.hasDef = sf.Tag.Lookup("default")
+		f.nested = f.source == "form" && isFormStruct(sf.Type)
+		if f.source != "" || f.hasDef {
+			if f.name == "" {
+				f.name = sf.Name
+			}
+			fields = append(fields, f)
+			continue
+		}
+		// nested struct (not pointer)
+		if sf.Type.Kind() == reflect.Struct && sf.Type != timeType && !reflect.PointerTo(sf.Type).Implements(unmarshalType) {
+			fields = append(fields, collectBindFields(sf.Type, fieldIndex)...)
+		}
+	}
+	return fields
+}
+
+// formValues the values of the urlencoded or multipart form
+func (c *Context) formValues() map[string][]string {
+	if len(c.Request.PostForm) == 0 && c.Request.MultipartForm != nil {
+		return c.Request.MultipartForm.Value
+	}
+	return c.Request.PostForm
+}
+
+// formFiles the files of the multipart form
+func (c *Context) formFiles() map[string][]*multipart.FileHeader {
+	if c.Request.MultipartForm == nil {
+		return nil
+	}
+	return c.Request.MultipartForm.File
+}
+
+// isFormStruct whether the type is a struct (not time or text unmarshaler), a pointer to it, or a slice of them
+func isFormStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType && t != fileType.Elem() && !reflect.PointerTo(t).Implements(unmarshalType)
+}
+
+func setFileField(fv reflect.Value, files []*multipart.FileHeader) {
+	if len(files) == 0 {
+		return
+	}
+	if fv.Type() == fileType {
+		fv.Set(reflect.ValueOf(files[0]))
+		return
+	}
+	fv.Set(reflect.ValueOf(files))
+}
+
+// bindFormNested bind the struct by the nested form names, the sub fields are named by the form tags (or the field names)
+// e.g. address.city or address[city], items[0].name or items[0][name]
+func bindFormNested(fv reflect.Value, prefix string, values map[string][]string, files map[string][]*multipart.FileHeader) error {
+	switch fv.Kind() {
+	case reflect.Ptr:
+		if !hasFormPrefix(prefix, values, files) {
+			return nil
+		}
+		elem := reflect.New(fv.Type().Elem())
+		err := bindFormNested(elem.Elem(), prefix, values, files)
+		if err != nil {
+			return err
+		}
+		fv.Set(elem)
+		return nil
+	case reflect.Slice:
+		n := formSliceLen(prefix, values, files)
+		if n == 0 {
+			return nil
+		}
+		slice := reflect.MakeSlice(fv.Type(), n, n)
+		for i := 0; i < n; i++ {
+			err := bindFormNested(slice.Index(i), prefix+"["+strconv.Itoa(i)+"]", values, files)
+			if err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+	t := fv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := sf.Tag.Get("form")
+		if !sf.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		sub := fv.Field(i)
+		keys := []string{prefix + "." + name, prefix + "[" + name + "]"}
+		if sf.Type == fileType || sf.Type == filesType {
+			for _, key := range keys {
+				setFileField(sub, files[key])
+			}
+			continue
+		}
+		if isFormStruct(sf.Type) {
+			err := bindFormNested(sub, keys[0], values, files)
+			if err == nil {
+				err = bindFormNested(sub, keys[1], values, files)
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		for _, key := range keys {
+			v := values[key]
+			if len(v) == 0 {
+				continue
+			}
+			err := setField(sub, v, sf.Tag.Get("time_format"))
+			if err != nil {
+				return fmt.Errorf("%s: %s", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// hasFormPrefix whether any form value or file is named by the prefix
+func hasFormPrefix(prefix string, values map[string][]string, files map[string][]*multipart.FileHeader) bool {
+	for k := range values {
+		if strings.HasPrefix(k, prefix+".") || strings.HasPrefix(k, prefix+"[") {
+			return true
+		}
+	}
+	for k := range files {
+		if strings.HasPrefix(k, prefix+".") || strings.HasPrefix(k, prefix+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// formSliceLen the length of the indexed form names, e.g. items[2].name -> 3
+func formSliceLen(prefix string, values map[string][]string, files map[string][]*multipart.FileHeader) int {
+	n := 0
+	check := func(k string) {
+		rest, ok := strings.CutPrefix(k, prefix+"[")
+		if !ok {
+			return
+		}
+		index, _, ok := strings.Cut(rest, "]")
+		if !ok {
+			return
+		}
+		i, err := strconv.Atoi(index)
+		// the index is limited to avoid allocating a huge slice
+		if err == nil && i >= 0 && i < 1000 && i+1 > n {
+			n = i + 1
+		}
+	}
+	for k := range values {
+		check(k)
+	}
+	for k := range files {
+		check(k)
+	}
+	return n
+}
+
+func setField(fv reflect.Value, values []string, timeFormat string) error {
+	if fv.Kind() == reflect.Ptr {
+		elem := reflect.New(fv.Type().Elem())
+		err := setField(elem.Elem(), values, timeFormat)
+		if err != nil {
+			return err
+		}
+		fv.Set(elem)
+		return nil
+	}
+	if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 && fv.Type() != timeType {
+		// a single value is split by comma, e.g. ?tag=a,b or ?tag=a&tag=b
+		if len(values) == 1 && strings.Contains(values[0], ",") {
+			values = strings.Split(values[0], ",")
+		}
+		slice := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, s := range values {
+			err := setValue(slice.Index(i), strings.TrimSpace(s), timeFormat)
+			if err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+	return setValue(fv, values[0], timeFormat)
+}
+
+func setValue(fv reflect.Value, s string, timeFormat string) error {
+	if fv.Kind() == reflect.Ptr {
+		elem := reflect.New(fv.Type().Elem())
+		err := setValue(elem.Elem(), s, timeFormat)
+		if err != nil {
+			return err
+		}
+		fv.Set(elem)
+		return nil
+	}
+	switch fv.Type() {
+	case timeType:
+		t, err := parseTime(s, timeFormat)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	if fv.CanAddr() && fv.Addr().Type().Implements(unmarshalType) {
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		if s == "" {
+			fv.SetBool(false)
+			return nil
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
+
+// parseTime parse the time by the format, or RFC3339, date (2006-01-02) and unix seconds
+func parseTime(s, format string) (time.Time, error) {
+	if format != "" {
+		return time.Parse(format, s)
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(n, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as time", s)
+}
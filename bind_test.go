@@ -0,0 +1,89 @@
+package easierweb
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// binding hooks test
+
+type bindTestRequest struct {
+	Name  string `json:"name"`
+	Page  int    `json:"page"`
+	Trace string `json:"-"`
+}
+
+func (b *bindTestRequest) Default() {
+	b.Page = 1
+	b.Trace += "default,"
+}
+
+func (b *bindTestRequest) BeforeBind(ctx *Context) error {
+	b.Trace += "before,"
+	if ctx.Request.Header.Get("X-Reject") != "" {
+		return NewError(http.StatusForbidden, "rejected")
+	}
+	return nil
+}
+
+func (b *bindTestRequest) AfterBind(ctx *Context) error {
+	b.Trace += "after"
+	if b.Name == "" {
+		return NewError(http.StatusBadRequest, "name is required")
+	}
+	b.Name = strings.ToUpper(b.Name)
+	return nil
+}
+
+type bindTestResult struct {
+	Result string `json:"result"`
+}
+
+func TestBindingHooks(t *testing.T) {
+
+	fmt.Println("\n[TestBindingHooks] start")
+
+	router := New(RouterOptions{CloseConsolePrint: true})
+	router.EasyPOST("/value", func(ctx *Context, req bindTestRequest) (*bindTestResult, error) {
+		return &bindTestResult{fmt.Sprintf("%s %d %s", req.Name, req.Page, req.Trace)}, nil
+	})
+	router.EasyPOST("/pointer", func(ctx *Context, req *bindTestRequest) (*bindTestResult, error) {
+		return &bindTestResult{fmt.Sprintf("%s %d %s", req.Name, req.Page, req.Trace)}, nil
+	})
+
+	cases := []struct {
+		path   string
+		body   string
+		reject bool
+		code   int
+		expect string
+	}{
+		{"/value", `{"name":"a"}`, false, http.StatusOK, `{"result":"A 1 default,before,after"}`},
+		{"/pointer", `{"name":"a"}`, false, http.StatusOK, `{"result":"A 1 default,before,after"}`},
+		{"/pointer", `{"name":"a","page":3}`, false, http.StatusOK, `{"result":"A 3 default,before,after"}`},
+		{"/value", `{"name":"a"}`, true, http.StatusForbidden, ""},
+		{"/pointer", `{"name":"a"}`, true, http.StatusForbidden, ""},
+		{"/value", `{}`, false, http.StatusBadRequest, ""},
+		{"/pointer", `{}`, false, http.StatusBadRequest, ""},
+		// the pointer parameter is not nil without the body
+		{"/pointer", ``, false, http.StatusBadRequest, ""},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodPost, c.path, strings.NewReader(c.body))
+		req.Header.Set("Content-Type", MediaTypeJSON)
+		if c.reject {
+			req.Header.Set("X-Reject", "1")
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		fmt.Println("[TestBindingHooks] result ->", c.path, c.body, rec.Code, rec.Body.String())
+		if rec.Code != c.code || (c.expect != "" && strings.TrimSpace(rec.Body.String()) != c.expect) {
+			t.Fatal("binding hooks do not match")
+		}
+	}
+
+	fmt.Println("\n[TestBindingHooks] end")
+}
@@ -0,0 +1,58 @@
+package easierweb
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// BodyReader get the reader of the request body
+// for the routes registered with StreamBody, the body is streamed from the connection (not buffered), otherwise it reads the buffered ctx.Body
+func (c *Context) BodyReader() io.ReadCloser {
+	return c.Request.Body
+}
+
+// StreamBody the body of the last registered route is not read before the handles, it is read by ctx.BodyReader() (or ctx.Request.MultipartReader())
+// e.g. router.PUT("/files/:name", upload).StreamBody().BodyLimit(1 << 30)
+func (r *Router) StreamBody() *Router {
+	for _, rt := range r.lastRoutes {
+		rt.streamBody = true
+	}
+	return r
+}
+
+// BodyLimit set the maximum size in bytes of the request body of the last registered route (overrides RouterOptions.MaxBodySize), -1 means no limit
+func (r *Router) BodyLimit(size int64) *Router {
+	for _, rt := range r.lastRoutes {
+		rt.bodyLimit = size
+	}
+	return r
+}
+
+// StreamBody see Router.StreamBody
+func (g *Group) StreamBody() *Group {
+	g.router.StreamBody()
+	return g
+}
+
+// BodyLimit see Router.BodyLimit
+func (g *Group) BodyLimit(size int64) *Group {
+	g.router.BodyLimit(size)
+	return g
+}
+
+func bodyTooLarge(limit int64) *Error {
+	return NewError(http.StatusRequestEntityTooLarge, fmt.Sprintf("request body is too large, at most %d bytes", limit)).
+		WithCode("BODY_TOO_LARGE").
+		WithDetails(map[string]any{"limit": limit})
+}
+
+// checkBodySize convert the error of http.MaxBytesReader to 413
+func checkBodySize(err error, limit int64) error {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return bodyTooLarge(limit)
+	}
+	return err
+}
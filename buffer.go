@@ -0,0 +1,118 @@
+package easierweb
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+type ResponseBufferOptions struct {
+	// buffer the responses of all routes, otherwise only the requests that call ctx.BufferResponse (or ctx.OnCommit) are buffered
+	Enabled bool
+	// maximum size in bytes of the buffered body, the larger responses are streamed (without the hooks), default 1MB
+	MaxSize int
+	// called before the buffered responses are written, after the hooks of ctx.OnCommit
+	Hooks []ResponseHook
+}
+
+// BufferedResponse the buffered response, it can be modified until it is committed (written to the client)
+type BufferedResponse struct {
+	// zero if nothing is written
+	Status int
+	// the response headers, the same as ctx.ResponseWriter.Header()
+	Header http.Header
+	Body   []byte
+}
+
+// ResponseHook post-process the buffered response before it is written, e.g. rewrite the headers, compute the ETag, or transform the body
+type ResponseHook func(ctx *Context, res *BufferedResponse)
+
+// BufferResponse buffer the response of the request, it is written to the client after the middlewares and the handle are completed,
+// so the middlewares (after ctx.Next) and the ResponseHandle can modify it by ctx.BufferedResponse(), the Content-Length is set when it is written
+// the response is streamed if it is larger than ResponseBufferOptions.MaxSize or it is flushed, the websocket and server-sent events are not buffered
+func (c *Context) BufferResponse() {
+	if c.writer.buffer != nil || c.Written() || c.websocket || c.Flusher != nil {
+		return
+	}
+	c.writer.maxSize = c.router.responseBuffer.MaxSize
+	c.writer.buffer = &BufferedResponse{Header: c.writer.ResponseWriter.Header()}
+}
+
+// BufferedResponse get the buffered response, nil if the response is not buffered, or it is streamed
+func (c *Context) BufferedResponse() *BufferedResponse {
+	return c.writer.buffer
+}
+
+// OnCommit add a hook that is called before the buffered response is written (it enables ctx.BufferResponse),
+// the hooks are called in order, they are not called if the response is streamed or it cannot be buffered (e.g. already written)
+func (c *Context) OnCommit(hook ResponseHook) {
+	c.BufferResponse()
+	c.writer.hooks = append(c.writer.hooks, hook)
+}
+
+// discardBuffer drop the buffered response, e.g. the partial response of a panic is replaced by the error response
+func (c *Context) discardBuffer() {
+	if c.writer.buffer == nil {
+		return
+	}
+	c.writer.buffer.Status = 0
+	c.writer.buffer.Body = c.writer.buffer.Body[:0]
+	c.writer.status.Store(0)
+	c.written = false
+}
+
+// commitResponse call the hooks and write the buffered response
+func (r *Router) commitResponse(ctx *Context) {
+	w := &ctx.writer
+	res := w.buffer
+	if res == nil {
+		return
+	}
+	w.buffer = nil
+	for _, hooks := range [][]ResponseHook{w.hooks, r.responseBuffer.Hooks} {
+		for _, hook := range hooks {
+			func() {
+				defer func() {
+					err := recover()
+					if err != nil {
+						ctx.Logger.Error(fmt.Sprintf("response hook error: %s", err))
+					}
+				}()
+				hook(ctx, res)
+			}()
+		}
+	}
+	if res.Status == 0 {
+		if len(res.Body) == 0 {
+			return
+		}
+		res.Status = http.StatusOK
+	}
+	noBody := res.Status < http.StatusOK || res.Status == http.StatusNoContent || res.Status == http.StatusNotModified
+	if !noBody && ctx.Request.Method != http.MethodHead && res.Header.Get("Content-Length") == "" {
+		res.Header.Set("Content-Length", strconv.Itoa(len(res.Body)))
+	}
+	w.status.Store(int32(res.Status))
+	w.ResponseWriter.WriteHeader(res.Status)
+	if noBody || len(res.Body) == 0 {
+		return
+	}
+	n, err := w.ResponseWriter.Write(res.Body)
+	w.size += int64(n)
+	if err != nil {
+		ctx.Logger.Debug("write buffered response error: " + err.Error())
+	}
+}
+
+// stream write the buffered response without the hooks, the later writes are not buffered
+func (w *responseWriter) stream() error {
+	res := w.buffer
+	w.buffer = nil
+	w.ResponseWriter.WriteHeader(res.Status)
+	if len(res.Body) == 0 {
+		return nil
+	}
+	n, err := w.ResponseWriter.Write(res.Body)
+	w.size += int64(n)
+	return err
+}
@@ -0,0 +1,125 @@
+package easierweb
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// CertReloader serve the certificate of the files, and reload it when the files are changed without restarting the server,
+// e.g. the short-lived certificates rotated by cert-manager, it is used by RunTLS if ServerOptions.CertReloadInterval is set, or
+// tlsConfig.GetCertificate = reloader.GetCertificate
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+	logger   *slog.Logger
+	lock     sync.RWMutex
+	cert     *tls.Certificate
+	certMod  time.Time
+	keyMod   time.Time
+	checked  time.Time
+}
+
+// NewCertReloader load the certificate, the files are checked for changes at most once per interval (default 1 minute) during the TLS handshakes
+func NewCertReloader(certFile string, keyFile string, interval time.Duration) (*CertReloader, error) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	c := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: interval,
+		logger:   slog.Default(),
+	}
+	err := c.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload load the certificate from the files, the current certificate is kept if it fails (e.g. the key file is not written yet)
+func (c *CertReloader) Reload() error {
+	certInfo, err := os.Stat(c.certFile)
+	if err != nil {
+		return err
+	}
+	keyInfo, err := os.Stat(c.keyFile)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.cert = &cert
+	c.certMod = certInfo.ModTime()
+	c.keyMod = keyInfo.ModTime()
+	c.checked = time.Now()
+	return nil
+}
+
+// GetCertificate the tls.Config.GetCertificate, it reloads the certificate if the files are changed since the last load
+func (c *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.lock.RLock()
+	cert, due := c.cert, time.Since(c.checked) >= c.interval
+	c.lock.RUnlock()
+	if due && c.changed() {
+		err := c.Reload()
+		if err != nil {
+			c.logger.Warn("reload certificate error: "+err.Error(), slog.String("cert_file", c.certFile))
+		} else {
+			c.lock.RLock()
+			cert = c.cert
+			c.lock.RUnlock()
+			c.logger.Info("certificate is reloaded", slog.String("cert_file", c.certFile))
+		}
+	}
+	return cert, nil
+}
+
+// changed whether the modification time of the files is changed, the next check is after the interval
+func (c *CertReloader) changed() bool {
+	c.lock.Lock()
+	c.checked = time.Now()
+	certMod, keyMod := c.certMod, c.keyMod
+	c.lock.Unlock()
+	certInfo, err := os.Stat(c.certFile)
+	if err != nil {
+		return false
+	}
+	keyInfo, err := os.Stat(c.keyFile)
+	if err != nil {
+		return false
+	}
+	return !certInfo.ModTime().Equal(certMod) || !keyInfo.ModTime().Equal(keyMod)
+}
+
+// tlsConfig apply the client certificate settings, and serve the certificate of the files by a CertReloader if ServerOptions.CertReloadInterval is set,
+// in which case the returned files are empty (the certificate is in the tls.Config)
+func (r *Router) tlsConfig(config *tls.Config, certFile string, keyFile string) (*tls.Config, string, string, error) {
+	config, err := r.clientAuthTLSConfig(config)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if r.serverOptions.CertReloadInterval <= 0 || certFile == "" {
+		return config, certFile, keyFile, nil
+	}
+	reloader, err := NewCertReloader(certFile, keyFile, r.serverOptions.CertReloadInterval)
+	if err != nil {
+		return nil, "", "", err
+	}
+	reloader.logger = r.logger
+	if config == nil {
+		config = &tls.Config{}
+	} else {
+		config = config.Clone()
+	}
+	config.GetCertificate = reloader.GetCertificate
+	return config, "", "", nil
+}
@@ -0,0 +1,96 @@
+package easierweb
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// ParseIPPrefixes parse the IPs and CIDRs, e.g. "10.0.0.0/8", "192.168.1.10", "::1"
+func ParseIPPrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if strings.Contains(v, "/") {
+			prefix, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, err
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// ContainsIP whether the ip is in any of the prefixes
+func ContainsIP(prefixes []netip.Prefix, ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTLS whether the request is sent over HTTPS, it is the TLS connection,
+// or the X-Forwarded-Proto header (https) if the request comes from the RouterOptions.TrustedProxies
+func (c *Context) IsTLS() bool {
+	if c.Request.TLS != nil {
+		return true
+	}
+	ip, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		ip = c.Request.RemoteAddr
+	}
+	return c.fromTrustedProxy(ip) && strings.EqualFold(strings.TrimSpace(c.Request.Header.Get("X-Forwarded-Proto")), "https")
+}
+
+// ClientIP get the ip of the client, it is the host of the remote address,
+// or the nearest untrusted ip of the X-Forwarded-For header (X-Real-IP if it is absent) if the request comes from the RouterOptions.TrustedProxies
+func (c *Context) ClientIP() string {
+	ip, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		ip = c.Request.RemoteAddr
+	}
+	if !c.fromTrustedProxy(ip) {
+		return ip
+	}
+	forwarded := c.Request.Header.Values("X-Forwarded-For")
+	if len(forwarded) == 0 {
+		realIP := strings.TrimSpace(c.Request.Header.Get("X-Real-IP"))
+		if _, err := netip.ParseAddr(realIP); err == nil {
+			return realIP
+		}
+		return ip
+	}
+	// walk from the right, the entries on the left can be forged by the client
+	entries := strings.Split(strings.Join(forwarded, ","), ",")
+	for i := len(entries) - 1; i >= 0; i-- {
+		v := strings.TrimSpace(entries[i])
+		if _, err := netip.ParseAddr(v); err != nil {
+			break
+		}
+		ip = v
+		if !ContainsIP(c.router.trustedProxies, v) {
+			break
+		}
+	}
+	return ip
+}
+
+// fromTrustedProxy whether the remote ip is in the RouterOptions.TrustedProxies, the forwarded headers of the others can be forged
+func (c *Context) fromTrustedProxy(ip string) bool {
+	return c.router != nil && len(c.router.trustedProxies) > 0 && ContainsIP(c.router.trustedProxies, ip)
+}
@@ -0,0 +1,22 @@
+package easierweb
+
+import (
+	"encoding/json"
+)
+
+// Codec marshal and unmarshal the data, e.g. jsoniter.ConfigCompatibleWithStandardLibrary, sonic.ConfigStd, or a go-json adapter
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// stdJSONCodec the encoding/json codec
+type stdJSONCodec struct{}
+
+func (stdJSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdJSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,316 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"github.com/dpwgc/easierweb/middlewares"
+	"gopkg.in/yaml.v3"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix the prefix of the environment variables that override the config, e.g. EASIERWEB_ADDR, EASIERWEB_CORS_ALLOW_ORIGINS
+const EnvPrefix = "EASIERWEB_"
+
+// Config the settings of the router that can be tuned without recompiling, loaded from a YAML or JSON file and the environment variables
+type Config struct {
+	// listen address, default ":8080"
+	Addr     string `yaml:"addr" json:"addr" env:"ADDR"`
+	RootPath string `yaml:"root_path" json:"root_path" env:"ROOT_PATH"`
+	// debug, info, warn or error, default info
+	LogLevel string `yaml:"log_level" json:"log_level" env:"LOG_LEVEL"`
+	// text or json, default text
+	LogFormat         string          `yaml:"log_format" json:"log_format" env:"LOG_FORMAT"`
+	TLS               TLSConfig       `yaml:"tls" json:"tls" env:"TLS"`
+	ReadTimeout       Duration        `yaml:"read_timeout" json:"read_timeout" env:"READ_TIMEOUT"`
+	ReadHeaderTimeout Duration        `yaml:"read_header_timeout" json:"read_header_timeout" env:"READ_HEADER_TIMEOUT"`
+	WriteTimeout      Duration        `yaml:"write_timeout" json:"write_timeout" env:"WRITE_TIMEOUT"`
+	IdleTimeout       Duration        `yaml:"idle_timeout" json:"idle_timeout" env:"IDLE_TIMEOUT"`
+	ShutdownTimeout   Duration        `yaml:"shutdown_timeout" json:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	MaxBodySize       int64           `yaml:"max_body_size" json:"max_body_size" env:"MAX_BODY_SIZE"`
+	CORS              CORSConfig      `yaml:"cors" json:"cors" env:"CORS"`
+	RateLimit         RateLimitConfig `yaml:"rate_limit" json:"rate_limit" env:"RATE_LIMIT"`
+	// the static mounts, they cannot be set by the environment variables
+	Static []StaticConfig `yaml:"static" json:"static" env:"-"`
+}
+
+// TLSConfig the server is started by RunTLS if the certificate and key files are set
+type TLSConfig struct {
+	CertFile     string `yaml:"cert_file" json:"cert_file" env:"CERT_FILE"`
+	KeyFile      string `yaml:"key_file" json:"key_file" env:"KEY_FILE"`
+	ClientCAFile string `yaml:"client_ca_file" json:"client_ca_file" env:"CLIENT_CA_FILE"`
+}
+
+// CORSConfig the middlewares.CORS settings, the empty lists use the defaults of the middleware
+type CORSConfig struct {
+	Enabled          bool     `yaml:"enabled" json:"enabled" env:"ENABLED"`
+	AllowOrigins     []string `yaml:"allow_origins" json:"allow_origins" env:"ALLOW_ORIGINS"`
+	AllowMethods     []string `yaml:"allow_methods" json:"allow_methods" env:"ALLOW_METHODS"`
+	AllowHeaders     []string `yaml:"allow_headers" json:"allow_headers" env:"ALLOW_HEADERS"`
+	ExposeHeaders    []string `yaml:"expose_headers" json:"expose_headers" env:"EXPOSE_HEADERS"`
+	AllowCredentials bool     `yaml:"allow_credentials" json:"allow_credentials" env:"ALLOW_CREDENTIALS"`
+	MaxAge           Duration `yaml:"max_age" json:"max_age" env:"MAX_AGE"`
+}
+
+// RateLimitConfig the middlewares.RateLimit settings, the requests are limited per client IP
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled" env:"ENABLED"`
+	// requests per second
+	Rate  float64 `yaml:"rate" json:"rate" env:"RATE"`
+	Burst int     `yaml:"burst" json:"burst" env:"BURST"`
+}
+
+// StaticConfig serve the files of the directory under the path, e.g. {path: /assets, dir: ./public}, "/*filepath" is appended to the path if it has no catch-all parameter
+type StaticConfig struct {
+	Path string `yaml:"path" json:"path"`
+	Dir  string `yaml:"dir" json:"dir"`
+}
+
+// Duration the time.Duration that is written as a string in the config, e.g. "30s", "1m30s"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Load read the config file (.json is decoded as JSON, others as YAML), then override it by the environment variables,
+// the file is skipped if the path is empty, e.g. the config is only set by the environment variables in the container
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			err = json.Unmarshal(data, cfg)
+		} else {
+			err = yaml.Unmarshal(data, cfg)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse config %s: %w", path, err)
+		}
+	}
+	err := applyEnv(reflect.ValueOf(cfg).Elem(), EnvPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// NewFromConfig create a router from the config file path (see Load) or a Config (*Config), the options are used as the base of the router options, e.g.
+// router, cfg, err := config.NewFromConfig("config.yaml")
+// ...
+// err = cfg.Run(router)
+func NewFromConfig(source any, opts ...easierweb.RouterOptions) (*easierweb.Router, *Config, error) {
+	var cfg *Config
+	switch s := source.(type) {
+	case string:
+		loaded, err := Load(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg = loaded
+	case Config:
+		cfg = &s
+	case *Config:
+		cfg = s
+	default:
+		return nil, nil, fmt.Errorf("unsupported config source %T", source)
+	}
+	router, err := cfg.NewRouter(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return router, cfg, nil
+}
+
+// NewRouter create a router with the settings, the CORS and rate limit middlewares and the static mounts
+func (c *Config) NewRouter(opts ...easierweb.RouterOptions) (*easierweb.Router, error) {
+	opt := easierweb.RouterOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if c.RootPath != "" {
+		opt.RootPath = c.RootPath
+	}
+	if opt.Logger == nil || c.LogLevel != "" || c.LogFormat != "" {
+		logger, err := c.logger()
+		if err != nil {
+			return nil, err
+		}
+		opt.Logger = logger
+	}
+	if c.ReadTimeout > 0 {
+		opt.Server.ReadTimeout = time.Duration(c.ReadTimeout)
+	}
+	if c.ReadHeaderTimeout > 0 {
+		opt.Server.ReadHeaderTimeout = time.Duration(c.ReadHeaderTimeout)
+	}
+	if c.WriteTimeout > 0 {
+		opt.Server.WriteTimeout = time.Duration(c.WriteTimeout)
+	}
+	if c.IdleTimeout > 0 {
+		opt.Server.IdleTimeout = time.Duration(c.IdleTimeout)
+	}
+	if c.ShutdownTimeout > 0 {
+		opt.ShutdownTimeout = time.Duration(c.ShutdownTimeout)
+	}
+	if c.MaxBodySize > 0 {
+		opt.MaxBodySize = c.MaxBodySize
+	}
+	if c.TLS.ClientCAFile != "" {
+		opt.Server.ClientCAFile = c.TLS.ClientCAFile
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return nil, fmt.Errorf("both tls cert_file and key_file must be set")
+	}
+	if c.CORS.Enabled && c.CORS.AllowCredentials && (len(c.CORS.AllowOrigins) == 0 || slices.Contains(c.CORS.AllowOrigins, "*")) {
+		return nil, fmt.Errorf("cors allow_credentials requires the allow_origins (not *)")
+	}
+	router := easierweb.New(opt)
+	if c.CORS.Enabled {
+		router.Use(middlewares.CORS(middlewares.CORSOptions{
+			AllowOrigins:     c.CORS.AllowOrigins,
+			AllowMethods:     c.CORS.AllowMethods,
+			AllowHeaders:     c.CORS.AllowHeaders,
+			ExposeHeaders:    c.CORS.ExposeHeaders,
+			AllowCredentials: c.CORS.AllowCredentials,
+			MaxAge:           time.Duration(c.CORS.MaxAge),
+		}))
+	}
+	if c.RateLimit.Enabled {
+		if c.RateLimit.Rate <= 0 {
+			return nil, fmt.Errorf("rate_limit rate must be positive")
+		}
+		router.Use(middlewares.RateLimit(middlewares.RateLimitOptions{
+			Rate:  c.RateLimit.Rate,
+			Burst: c.RateLimit.Burst,
+		}))
+	}
+	for _, s := range c.Static {
+		if s.Path == "" || s.Dir == "" {
+			return nil, fmt.Errorf("static path and dir must be set")
+		}
+		path := s.Path
+		if !strings.Contains(path, "*") {
+			path = strings.TrimSuffix(path, "/") + "/*filepath"
+		}
+		router.Static(path, s.Dir)
+	}
+	return router, nil
+}
+
+// Run start the router on the Addr, by RunTLS if the TLS certificate and key files are set
+func (c *Config) Run(router *easierweb.Router) error {
+	addr := c.Addr
+	if addr == "" {
+		addr = ":8080"
+	}
+	if c.TLS.CertFile != "" {
+		return router.RunTLS(addr, c.TLS.CertFile, c.TLS.KeyFile, nil)
+	}
+	return router.Run(addr)
+}
+
+func (c *Config) logger() (*slog.Logger, error) {
+	var level slog.Level
+	if c.LogLevel != "" {
+		err := level.UnmarshalText([]byte(c.LogLevel))
+		if err != nil {
+			return nil, fmt.Errorf("invalid log_level %q", c.LogLevel)
+		}
+	}
+	handlerOptions := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(c.LogFormat) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, handlerOptions)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, handlerOptions)), nil
+	}
+	return nil, fmt.Errorf("invalid log_format %q", c.LogFormat)
+}
+
+// applyEnv set the fields by the environment variables named by the prefix and the env tags, the nested structs join the names with "_",
+// the lists are separated by ",", e.g. EASIERWEB_CORS_ALLOW_ORIGINS=https://a.com,https://b.com
+func applyEnv(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("env")
+		if name == "" || name == "-" {
+			continue
+		}
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct && field.Type() != reflect.TypeOf(Duration(0)) {
+			err := applyEnv(field, prefix+name+"_")
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		value, ok := os.LookupEnv(prefix + name)
+		if !ok {
+			continue
+		}
+		err := setField(field, value)
+		if err != nil {
+			return fmt.Errorf("invalid environment variable %s: %w", prefix+name, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(Duration(0)) {
+		return field.Addr().Interface().(*Duration).UnmarshalText([]byte(value))
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package easierweb
+
+import (
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// named types of the path parameter constraints, e.g. /users/:id|int
+var constraintTypes = map[string]string{
+	"int":   `-?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"alpha": `[a-zA-Z]+`,
+	"alnum": `[a-zA-Z0-9]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// compileConstraint compile the named type or the regexp, it must match the whole parameter value
+func compileConstraint(pattern string) *regexp.Regexp {
+	if v, ok := constraintTypes[pattern]; ok {
+		pattern = v
+	}
+	return regexp.MustCompile(`^(?:` + pattern + `)$`)
+}
+
+// parseConstraints strip the inline constraints from the path, e.g. /users/:id|^[0-9]+$ -> /users/:id
+// the constraint of the catch-all parameter is the rest of the path, so it can contain /, e.g. /files/*filepath|/[a-z]+\.txt
+// the named parameters never contain /, it panics if their constraints do (the rest of the regexp would be the static segments)
+func parseConstraints(path string) (string, map[string]*regexp.Regexp) {
+	if !strings.Contains(path, "|") {
+		return path, nil
+	}
+	constraints := make(map[string]*regexp.Regexp)
+	full := path
+	// the catch-all parameter is the last segment
+	if i := strings.Index(path, "/*"); i >= 0 {
+		if name, pattern, ok := strings.Cut(path[i+2:], "|"); ok {
+			constraints[name] = compileConstraint(pattern)
+			path = path[:i+2] + name
+		}
+	}
+	segments := strings.Split(path, "/")
+	last := ""
+	for i, s := range segments {
+		if !strings.HasPrefix(s, ":") {
+			// the static segment after a constraint is the rest of its regexp, e.g. /:date|\d{4}/\d{2}
+			if last != "" && strings.ContainsAny(s, `\^$[](){}|`) {
+				panic(fmt.Errorf("the constraint of the path parameter %s cannot contain '/': %s", last, full))
+			}
+			continue
+		}
+		name, pattern, ok := strings.Cut(s, "|")
+		if !ok {
+			continue
+		}
+		constraints[name[1:]] = compileConstraint(pattern)
+		segments[i] = name
+		last = name
+	}
+	return strings.Join(segments, "/"), constraints
+}
+
+// Where set the constraint of the path parameter of the last registered route (all methods if registered by Any)
+// the pattern is a named type (int, uint, alpha, alnum, uuid) or a regexp that must match the whole value
+// the request is not found (404) if the constraint fails, e.g. router.GET("/users/:id", getUser).Where("id", `\d+`)
+func (r *Router) Where(name, pattern string) *Router {
+	re := compileConstraint(pattern)
+	for _, rt := range r.lastRoutes {
+		if rt.constraints == nil {
+			rt.constraints = make(map[string]*regexp.Regexp)
+		}
+		rt.constraints[name] = re
+	}
+	return r
+}
+
+// matchConstraints check the path parameters, the not found handle is executed if any of them fails
+func (r *Router) matchConstraints(rt *route, res http.ResponseWriter, req *http.Request, par httprouter.Params) bool {
+	for name, re := range rt.constraints {
+		if !re.MatchString(par.ByName(name)) {
+			r.notFound(res, req)
+			return false
+		}
+	}
+	return true
+}
@@ -1,22 +1,33 @@
 package easierweb
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"github.com/julienschmidt/httprouter"
-	"golang.org/x/net/websocket"
 	"gopkg.in/yaml.v3"
 	"io"
+	"io/fs"
 	"log/slog"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+var _ context.Context = (*Context)(nil)
+
 type Context struct {
 	Route          string
 	Header         Params
@@ -28,13 +39,30 @@ type Context struct {
 	Result         Data
 	Request        *http.Request
 	ResponseWriter http.ResponseWriter
-	WebsocketConn  *websocket.Conn
+	WebsocketConn  *WSConn
 	Flusher        http.Flusher
 	Logger         *slog.Logger
+	router         *Router
+	routeName      string
 	index          int
+	aborted        bool
 	handles        []Handle
-	written        bool
-	closed         bool
+	claims         map[string]any
+	principal      string
+	requestID      string
+	cspNonce       string
+	stack          []byte
+	session        *Session
+	noCache        bool
+	// 0 unknown, 1 disabled, 2 enabled, see IsDebug
+	debug     int8
+	keys      map[string]any
+	params    httprouter.Params
+	writer    responseWriter
+	keysLock  sync.RWMutex
+	written   bool
+	closed    bool
+	websocket bool
 }
 
 func (c *Context) Next() {
@@ -47,6 +75,44 @@ func (c *Context) Next() {
 
 func (c *Context) Abort() {
 	c.index = len(c.handles) + 1
+	c.aborted = true
+}
+
+// AbortWithStatus write the status code (without body) and stop the remaining handles
+func (c *Context) AbortWithStatus(code int) {
+	c.NoContent(code)
+	c.Abort()
+}
+
+// IsAborted whether Abort is called, the remaining handles are stopped
+func (c *Context) IsAborted() bool {
+	return c.aborted
+}
+
+// Deadline, Done, Err and Value implement context.Context, they are wired to the request context
+// so the Context can be passed to the functions that accept a context.Context, e.g. db.QueryContext(ctx, query)
+
+func (c *Context) Deadline() (time.Time, bool) {
+	return c.Request.Context().Deadline()
+}
+
+// Done closed when the client disconnects, the server is shutting down or the deadline is exceeded (e.g. middlewares.Timeout)
+func (c *Context) Done() <-chan struct{} {
+	return c.Request.Context().Done()
+}
+
+func (c *Context) Err() error {
+	return c.Request.Context().Err()
+}
+
+// Value get the value stored by Set if the key is a string, otherwise the value of the request context
+func (c *Context) Value(key any) any {
+	if k, ok := key.(string); ok {
+		if value, exists := c.Get(k); exists {
+			return value
+		}
+	}
+	return c.Request.Context().Value(key)
 }
 
 // POST Form File
@@ -89,7 +155,7 @@ func (c *Context) BindHeader(obj any) error {
 // POST Body Bind
 
 func (c *Context) BindJSON(obj any) error {
-	return c.Body.ParseJSON(obj)
+	return c.router.jsonCodec.Unmarshal(c.Body, obj)
 }
 
 func (c *Context) BindYAML(obj any) error {
@@ -100,22 +166,124 @@ func (c *Context) BindXML(obj any) error {
 	return c.Body.ParseXML(obj)
 }
 
+func (c *Context) BindMsgPack(obj any) error {
+	return c.Body.ParseMsgPack(obj)
+}
+
+// BindBody bind the body data according to the Content-Type header (json by default, or protobuf for RouterOptions.ProtobufPaths)
+func (c *Context) BindBody(obj any) error {
+	contentType := c.Request.Header.Get("Content-Type")
+	if contentType == "" && c.router.protobufDefault(c.Request.URL.Path) {
+		return unmarshalProtobuf(c.Body, obj)
+	}
+	return c.router.decoder(contentType)(c.Body, obj)
+}
+
 // Result Write
 
 func (c *Context) WriteJSON(code int, obj any) {
-	if c.written {
+	if c.skipWrite() {
 		return
 	}
-	marshal, err := json.Marshal(obj)
+	marshal, err := c.router.jsonCodec.Marshal(obj)
 	if err != nil {
 		panic(err)
 	}
 	c.AddContentType("application/json; charset=utf-8")
-	c.Write(code, marshal)
+	c.Write(code, c.debugJSON(marshal))
+}
+
+// WriteJSONP write the JSON wrapped in the callback (application/javascript), e.g. ctx.WriteJSONP(200, ctx.Query.Get("callback"), obj)
+// for the legacy browser integrations that cannot use CORS, the JSON is written if the callback is empty,
+// and 400 if it is not a valid function name (letters, digits, _ and $, separated by dots, e.g. jQuery123.cb)
+func (c *Context) WriteJSONP(code int, callback string, obj any) {
+	if callback == "" {
+		c.WriteJSON(code, obj)
+		return
+	}
+	if c.skipWrite() {
+		return
+	}
+	if !validCallback(callback) {
+		c.WriteError(NewError(http.StatusBadRequest, "invalid jsonp callback"))
+		return
+	}
+	marshal, err := c.router.jsonCodec.Marshal(obj)
+	if err != nil {
+		panic(err)
+	}
+	// U+2028 and U+2029 are valid in JSON but not in the javascript strings of the old browsers (encoding/json escapes them, other codecs may not)
+	marshal = bytes.ReplaceAll(marshal, []byte("\u2028"), []byte("\\u2028"))
+	marshal = bytes.ReplaceAll(marshal, []byte("\u2029"), []byte("\\u2029"))
+	c.AddContentType("application/javascript; charset=utf-8")
+	// the response is not sniffed as another type, and the comment prevents the content sniffing attacks (e.g. Rosetta Flash)
+	c.SetHeader("X-Content-Type-Options", "nosniff")
+	c.Write(code, []byte("/**/ "+callback+"("+string(c.debugJSON(marshal))+");"))
+}
+
+// validCallback the dotted javascript identifiers, at most 128 characters
+func validCallback(callback string) bool {
+	if len(callback) > 128 {
+		return false
+	}
+	for _, part := range strings.Split(callback, ".") {
+		if part == "" {
+			return false
+		}
+		for i, ch := range part {
+			if !(ch == '_' || ch == '$' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (i > 0 && ch >= '0' && ch <= '9')) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// WriteError write the error as the problem details (application/problem+json)
+// *Error uses its status code, other errors are written as 500 without the message
+func (c *Context) WriteError(err error) {
+	if c.skipWrite() {
+		return
+	}
+	var e *Error
+	if !errors.As(err, &e) {
+		e = NewError(http.StatusInternalServerError, "")
+	}
+	problem := e.Problem(c.Request.URL.Path)
+	if c.IsDebug() {
+		if e.Err != nil {
+			problem.Error = e.Err.Error()
+		} else if e != err {
+			problem.Error = err.Error()
+		}
+		problem.Stack = stackLines(c.stack)
+	}
+	marshal, mErr := c.router.jsonCodec.Marshal(problem)
+	if mErr != nil {
+		panic(mErr)
+	}
+	c.SetContentType(MediaTypeProblemJSON)
+	c.Write(problem.Status, c.debugJSON(marshal))
+}
+
+// AbortWithError write the error (see WriteError) and stop the remaining handles
+func (c *Context) AbortWithError(err error) {
+	c.WriteError(err)
+	c.Abort()
+}
+
+// HandleError pass the error to the RouterOptions.ErrorHandle (WriteError if it is nil), e.g. the authentication middlewares
+// write the 401 *Error in the same format as the handles
+func (c *Context) HandleError(err error) {
+	if c.router.errorHandle == nil {
+		c.WriteError(err)
+		return
+	}
+	c.router.errorBottomUp(c, err)
 }
 
 func (c *Context) WriteYAML(code int, obj any) {
-	if c.written {
+	if c.skipWrite() {
 		return
 	}
 	marshal, err := yaml.Marshal(obj)
@@ -127,7 +295,7 @@ func (c *Context) WriteYAML(code int, obj any) {
 }
 
 func (c *Context) WriteXML(code int, obj any) {
-	if c.written {
+	if c.skipWrite() {
 		return
 	}
 	marshal, err := xml.Marshal(obj)
@@ -139,14 +307,14 @@ func (c *Context) WriteXML(code int, obj any) {
 }
 
 func (c *Context) Redirect(code int, url string) {
-	if c.written {
+	if c.skipWrite() {
 		return
 	}
 	http.Redirect(c.ResponseWriter, c.Request, url, code)
 }
 
 func (c *Context) WriteLocalFile(fileName, localFilePath string) {
-	if c.written {
+	if c.skipWrite() {
 		return
 	}
 	fileBytes, err := os.ReadFile(localFilePath)
@@ -157,7 +325,7 @@ func (c *Context) WriteLocalFile(fileName, localFilePath string) {
 }
 
 func (c *Context) WriteFile(fileName string, fileBytes []byte) {
-	if c.written {
+	if c.skipWrite() {
 		return
 	}
 	if len(fileName) > 0 {
@@ -169,8 +337,93 @@ func (c *Context) WriteFile(fileName string, fileBytes []byte) {
 	c.Write(http.StatusOK, fileBytes)
 }
 
+// File write the local file, the Range, If-Modified-Since and Content-Type are handled by http.ServeContent
+func (c *Context) File(filePath string) {
+	c.serveFile(filePath, "")
+}
+
+// Attachment write the local file as an attachment, the browser downloads it with the file name
+func (c *Context) Attachment(filePath, fileName string) {
+	if fileName == "" {
+		fileName = filepath.Base(filePath)
+	}
+	c.serveFile(filePath, mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+}
+
+// Stream copy the reader to the response (200), the reader is closed if it is an io.Closer
+func (c *Context) Stream(contentType string, reader io.Reader) {
+	c.DataFromReader(http.StatusOK, -1, contentType, reader, nil)
+}
+
+// DataFromReader copy the reader to the response, contentLength < 0 means unknown (chunked)
+func (c *Context) DataFromReader(code int, contentLength int64, contentType string, reader io.Reader, headers map[string]string) {
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if c.skipWrite() {
+		return
+	}
+	for k, v := range headers {
+		c.SetHeader(k, v)
+	}
+	if contentType != "" {
+		c.SetContentType(contentType)
+	}
+	if contentLength >= 0 {
+		c.SetHeader("Content-Length", strconv.FormatInt(contentLength, 10))
+	}
+	c.ResponseWriter.WriteHeader(code)
+	c.Code = code
+	c.written = true
+	_, err := io.Copy(c.ResponseWriter, reader)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (c *Context) serveFile(filePath, contentDisposition string) {
+	if c.skipWrite() {
+		return
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			c.WriteString(http.StatusNotFound, "file not found")
+			return
+		}
+		panic(err)
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		panic(err)
+	}
+	if stat.IsDir() {
+		c.WriteString(http.StatusNotFound, "file not found")
+		return
+	}
+	if contentDisposition != "" {
+		c.SetContentDisposition(contentDisposition)
+	}
+	sw := &statusWriter{ResponseWriter: c.ResponseWriter, code: http.StatusOK}
+	http.ServeContent(sw, c.Request, stat.Name(), stat.ModTime(), f)
+	c.Code = sw.code
+	c.written = true
+}
+
+// statusWriter record the status code written by the http.Handler
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (s *statusWriter) WriteHeader(code int) {
+	s.code = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
 func (c *Context) WriteHTML(code int, html string) {
-	if c.written {
+	if c.skipWrite() {
 		return
 	}
 	c.AddContentType("text/html; charset=utf-8")
@@ -178,7 +431,7 @@ func (c *Context) WriteHTML(code int, html string) {
 }
 
 func (c *Context) WriteString(code int, text string) {
-	if c.written {
+	if c.skipWrite() {
 		return
 	}
 	c.AddContentType("text/plain; charset=utf-8")
@@ -190,13 +443,16 @@ func (c *Context) NoContent(code int) {
 }
 
 func (c *Context) Write(code int, data []byte) {
-	if c.written {
+	if c.skipWrite() {
 		return
 	}
 	c.ResponseWriter.WriteHeader(code)
-	_, err := c.ResponseWriter.Write(data)
-	if err != nil {
-		panic(err)
+	// the responses without body (e.g. 204, 304) are not written
+	if len(data) > 0 {
+		_, err := c.ResponseWriter.Write(data)
+		if err != nil {
+			panic(err)
+		}
 	}
 	c.Code = code
 	c.Result = data
@@ -230,39 +486,39 @@ func (c *Context) AddHeader(key, value string) {
 // WS Receive
 
 func (c *Context) ReceiveJSON(obj any) error {
-	return websocket.JSON.Receive(c.WebsocketConn, obj)
+	buf, err := c.Receive()
+	if err != nil {
+		return err
+	}
+	return c.router.jsonCodec.Unmarshal(buf, obj)
 }
 
 func (c *Context) ReceiveYAML(obj any) error {
-	var buf string
-	err := websocket.Message.Receive(c.WebsocketConn, &buf)
+	buf, err := c.Receive()
 	if err != nil {
 		return err
 	}
-	return yaml.Unmarshal([]byte(buf), obj)
+	return yaml.Unmarshal(buf, obj)
 }
 
 func (c *Context) ReceiveXML(obj any) error {
-	var buf string
-	err := websocket.Message.Receive(c.WebsocketConn, &buf)
+	buf, err := c.Receive()
 	if err != nil {
 		return err
 	}
-	return xml.Unmarshal([]byte(buf), obj)
+	return xml.Unmarshal(buf, obj)
 }
 
 func (c *Context) ReceiveString() (string, error) {
-	var buf string
-	err := websocket.Message.Receive(c.WebsocketConn, &buf)
+	buf, err := c.Receive()
 	if err != nil {
 		return "", err
 	}
-	return buf, nil
+	return string(buf), nil
 }
 
 func (c *Context) Receive() ([]byte, error) {
-	var buf []byte
-	err := websocket.Message.Receive(c.WebsocketConn, &buf)
+	_, buf, err := c.WebsocketConn.ReadMessage()
 	if err != nil {
 		return nil, err
 	}
@@ -272,7 +528,7 @@ func (c *Context) Receive() ([]byte, error) {
 // WS Send
 
 func (c *Context) SendJSON(obj any) error {
-	marshal, err := json.Marshal(obj)
+	marshal, err := c.router.jsonCodec.Marshal(obj)
 	if err != nil {
 		return err
 	}
@@ -299,12 +555,19 @@ func (c *Context) SendString(text string) error {
 	return c.Send([]byte(text))
 }
 
+// Send send a text message
 func (c *Context) Send(msg []byte) error {
-	_, err := c.WebsocketConn.Write(msg)
-	if err != nil {
-		return err
-	}
-	return nil
+	return c.WebsocketConn.WriteMessage(TextMessage, msg)
+}
+
+func (c *Context) SendBinary(msg []byte) error {
+	return c.WebsocketConn.WriteMessage(BinaryMessage, msg)
+}
+
+// IsWebsocket whether the request is the handshake of a websocket route, the middlewares are executed before the upgrade,
+// the connection (ctx.WebsocketConn) is available after ctx.Next()
+func (c *Context) IsWebsocket() bool {
+	return c.websocket || c.WebsocketConn != nil
 }
 
 // WS Close
@@ -321,10 +584,28 @@ func (c *Context) Close() error {
 	return nil
 }
 
+// CloseWithCode send a close frame with the code and reason, then close the connection
+func (c *Context) CloseWithCode(code int, reason string) error {
+	if c.closed {
+		return nil
+	}
+	err := c.WebsocketConn.CloseWithCode(code, reason)
+	if err != nil {
+		return err
+	}
+	c.closed = true
+	return nil
+}
+
 // SSE Push
 
+// Push write the raw message and flush it, returns an error if the client has disconnected
 func (c *Context) Push(msg string) error {
-	_, err := fmt.Fprintf(c.ResponseWriter, msg)
+	err := c.Request.Context().Err()
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(c.ResponseWriter, msg)
 	if err != nil {
 		return err
 	}
@@ -332,6 +613,23 @@ func (c *Context) Push(msg string) error {
 	return nil
 }
 
+// PushEvent push a message in the event stream format, multi-line data is split into multiple data fields
+func (c *Context) PushEvent(event, data string) error {
+	var msg strings.Builder
+	if event != "" {
+		msg.WriteString("event: ")
+		msg.WriteString(event)
+		msg.WriteString("\n")
+	}
+	for _, line := range strings.Split(data, "\n") {
+		msg.WriteString("data: ")
+		msg.WriteString(line)
+		msg.WriteString("\n")
+	}
+	msg.WriteString("\n")
+	return c.Push(msg.String())
+}
+
 // Other request parameters
 
 func (c *Context) GetCookie(name string) (*http.Cookie, error) {
@@ -366,9 +664,135 @@ func (c *Context) Proto() string {
 	return c.Request.Proto
 }
 
+// Keys
+
+// Set store the request-scoped value, e.g. the tenant resolved by the middleware, it is safe for concurrent use
+func (c *Context) Set(key string, value any) {
+	c.keysLock.Lock()
+	defer c.keysLock.Unlock()
+	if c.keys == nil {
+		c.keys = make(map[string]any)
+	}
+	c.keys[key] = value
+}
+
+// Get get the value stored by Set
+func (c *Context) Get(key string) (any, bool) {
+	c.keysLock.RLock()
+	defer c.keysLock.RUnlock()
+	value, ok := c.keys[key]
+	return value, ok
+}
+
+// MustGet get the value stored by Set, panics if it does not exist
+func (c *Context) MustGet(key string) any {
+	value, ok := c.Get(key)
+	if !ok {
+		panic(fmt.Errorf("key %s does not exist", key))
+	}
+	return value
+}
+
+// Auth
+
+// Claims get the claims set by the authentication middleware (e.g. middlewares.JWT), returns nil if not authenticated
+func (c *Context) Claims() map[string]any {
+	return c.claims
+}
+
+func (c *Context) SetClaims(claims map[string]any) {
+	c.claims = claims
+}
+
+// Principal get the identity set by the authentication middleware, e.g. the username of middlewares.BasicAuth, returns "" if not authenticated
+func (c *Context) Principal() string {
+	return c.principal
+}
+
+func (c *Context) SetPrincipal(principal string) {
+	c.principal = principal
+}
+
+// ClientCert get the client certificate verified by the TLS server (see ServerOptions.ClientCAFile), returns nil if there is none
+func (c *Context) ClientCert() *x509.Certificate {
+	if c.Request == nil || c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 || len(c.Request.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return c.Request.TLS.VerifiedChains[0][0]
+}
+
+// Stack get the stack trace of the recovered panic, it can be used in the error handle, returns nil if no panic occurred
+func (c *Context) Stack() []byte {
+	return c.stack
+}
+
+// CSP Nonce
+
+// CSPNonce get the random nonce of the request (generated on the first call), it is used by the inline scripts and styles
+// that are allowed by the Content-Security-Policy, e.g. middlewares.Secure with "script-src 'nonce-{nonce}'",
+// and <script nonce="{{ .Nonce }}"> in the template
+func (c *Context) CSPNonce() string {
+	if c.cspNonce == "" {
+		b := make([]byte, 16)
+		_, _ = rand.Read(b)
+		c.cspNonce = base64.StdEncoding.EncodeToString(b)
+	}
+	return c.cspNonce
+}
+
+// Request ID
+
+type requestIDKey struct{}
+
+// RequestID get the request id set by the middleware (e.g. middlewares.RequestID)
+func (c *Context) RequestID() string {
+	return c.requestID
+}
+
+// SetRequestID set the request id, it is also stored in the request context, which can be read by RequestIDFromContext
+func (c *Context) SetRequestID(id string) {
+	c.requestID = id
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey{}, id))
+}
+
+// RequestIDFromContext get the request id from the request context, e.g. RequestIDFromContext(ctx.Request.Context())
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Path Parameters
+
+// Param get the path parameter from the matched params directly, without the map lookup of ctx.Path
+func (c *Context) Param(name string) string {
+	for _, p := range c.params {
+		if p.Key == name {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// ParamInt get the path parameter as an int, e.g. /users/:id
+func (c *Context) ParamInt(name string) (int, error) {
+	return strconv.Atoi(c.Param(name))
+}
+
+// Cache
+
+// NoCache mark the response as not cacheable, the cache middleware (e.g. middlewares.Cache) will not store it
+func (c *Context) NoCache() {
+	c.noCache = true
+}
+
+// IsNoCache whether the response is marked as not cacheable
+func (c *Context) IsNoCache() bool {
+	return c.noCache
+}
+
 // Set
 
-func setContext(ctx *Context, router *Router, route string, res http.ResponseWriter, req *http.Request, par httprouter.Params, ws *websocket.Conn, middlewares ...Handle) error {
+func setContext(ctx *Context, router *Router, rt *route, res http.ResponseWriter, req *http.Request, par httprouter.Params, middlewares ...Handle) error {
 
 	defer func() {
 		err := recover()
@@ -377,64 +801,93 @@ func setContext(ctx *Context, router *Router, route string, res http.ResponseWri
 		}
 	}()
 
-	handles := append([]Handle(nil), router.middlewares...)
-	handles = append(handles, middlewares...)
-	ctx.Route = route
+	// reuse the slices and maps of the pooled context
+	ctx.handles = append(ctx.handles[:0], router.middlewares...)
+	ctx.handles = append(ctx.handles, middlewares...)
+	ctx.Route = ""
+	ctx.routeName = ""
+	if rt != nil {
+		ctx.Route = rt.path
+		ctx.routeName = rt.name
+	}
 	ctx.index = 0
-	ctx.handles = handles
-	ctx.Header = nil
-	ctx.Path = nil
-	ctx.Query = nil
-	ctx.Form = nil
+	ctx.aborted = false
+	ctx.Header = resetParams(ctx.Header)
+	ctx.Path = resetParams(ctx.Path)
+	ctx.Query = resetParams(ctx.Query)
+	ctx.Form = resetParams(ctx.Form)
 	ctx.Body = nil
 	ctx.Request = req
-	ctx.ResponseWriter = res
-	ctx.WebsocketConn = ws
+	ctx.writer.reset(res)
+	ctx.ResponseWriter = &ctx.writer
+	ctx.WebsocketConn = nil
 	ctx.Flusher = nil
 	ctx.Logger = router.logger
+	ctx.router = router
 	ctx.Code = 0
 	ctx.Result = nil
+	ctx.claims = nil
+	ctx.principal = ""
+	ctx.requestID = ""
+	ctx.cspNonce = ""
+	ctx.stack = nil
+	ctx.session = nil
+	ctx.noCache = false
+	ctx.debug = 0
+	ctx.keysLock.Lock()
+	clear(ctx.keys)
+	ctx.keysLock.Unlock()
 	ctx.written = false
 	ctx.closed = false
+	ctx.websocket = rt != nil && rt.kind == routeKindWS
 
-	if strings.Contains(strings.ToLower(req.Header.Get("Content-Type")), "multipart/form-data") ||
-		strings.Contains(strings.ToLower(req.Header.Get("content-type")), "multipart/form-data") {
+	limit := router.maxBodySize
+	if rt != nil && rt.bodyLimit != 0 {
+		limit = rt.bodyLimit
+	}
+	if limit > 0 {
+		if req.ContentLength > limit {
+			return bodyTooLarge(limit)
+		}
+		req.Body = http.MaxBytesReader(res, req.Body, limit)
+	}
+
+	if rt != nil && rt.streamBody {
+		// the body is read by ctx.BodyReader()
+	} else if strings.Contains(strings.ToLower(req.Header.Get("Content-Type")), MediaTypeMultipart) ||
+		strings.Contains(strings.ToLower(req.Header.Get("content-type")), MediaTypeMultipart) {
 		err := req.ParseMultipartForm(router.multipartFormMaxMemory)
 		if err != nil {
-			return err
+			return checkBodySize(err, limit)
 		}
-	} else if strings.Contains(strings.ToLower(req.Header.Get("Content-Type")), "application/x-www-form-urlencoded") ||
-		strings.Contains(strings.ToLower(req.Header.Get("content-type")), "application/x-www-form-urlencoded") {
+	} else if strings.Contains(strings.ToLower(req.Header.Get("Content-Type")), MediaTypeForm) ||
+		strings.Contains(strings.ToLower(req.Header.Get("content-type")), MediaTypeForm) {
 		err := req.ParseForm()
 		if err != nil {
-			return err
+			return checkBodySize(err, limit)
 		}
 	} else {
 		bodyBytes, err := io.ReadAll(req.Body)
 		if err != nil {
-			return err
+			return checkBodySize(err, limit)
 		}
 		ctx.Body = bodyBytes
+		// the body can still be read by the http.Handler (e.g. mounted handler, reverse proxy)
+		req.Body = io.NopCloser(bytes.NewReader(bodyBytes))
 	}
 
-	if len(req.Header) > 0 {
-		ctx.Header = make(map[string]string, len(req.Header))
-		for k, v := range req.Header {
-			if len(v) > 0 {
-				ctx.Header[k] = v[0]
-			}
+	for k, v := range req.Header {
+		if len(v) > 0 {
+			ctx.Header[k] = v[0]
 		}
 	}
 
-	if len(par) > 0 {
-		ctx.Path = make(map[string]string, len(par))
-		for _, v := range par {
-			ctx.Path[v.Key] = v.Value
-		}
+	ctx.params = par
+	for _, v := range par {
+		ctx.Path[v.Key] = v.Value
 	}
 
-	if len(req.URL.Query()) > 0 {
-		ctx.Query = make(map[string]string, len(req.URL.Query()))
+	if req.URL.RawQuery != "" {
 		for k, v := range req.URL.Query() {
 			if len(v) > 0 {
 				ctx.Query[k] = v[0]
@@ -442,14 +895,20 @@ func setContext(ctx *Context, router *Router, route string, res http.ResponseWri
 		}
 	}
 
-	if len(req.PostForm) > 0 {
-		ctx.Form = make(map[string]string, len(req.PostForm))
-		for k, v := range req.PostForm {
-			if len(v) > 0 {
-				ctx.Form[k] = v[0]
-			}
+	for k, v := range req.PostForm {
+		if len(v) > 0 {
+			ctx.Form[k] = v[0]
 		}
 	}
 
 	return nil
 }
+
+// resetParams clear the params for reuse, the map is only allocated once per pooled context
+func resetParams(p Params) Params {
+	if p == nil {
+		return make(Params)
+	}
+	clear(p)
+	return p
+}
@@ -0,0 +1,56 @@
+package easierweb
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// abort test
+
+func TestAbort(t *testing.T) {
+
+	fmt.Println("\n[TestAbort] start")
+
+	var trace []string
+	middleware := func(name string, abort bool) Handle {
+		return func(ctx *Context) {
+			if abort {
+				ctx.AbortWithStatus(http.StatusForbidden)
+				trace = append(trace, fmt.Sprintf("%s:%v", name, ctx.IsAborted()))
+				return
+			}
+			ctx.Next()
+			trace = append(trace, fmt.Sprintf("%s:%v", name, ctx.IsAborted()))
+		}
+	}
+	handle := func(ctx *Context) {
+		trace = append(trace, "H")
+		ctx.WriteString(http.StatusOK, "ok")
+	}
+	router := New()
+	router.GET("/next", handle, middleware("A", false), middleware("B", false))
+	router.GET("/abort", handle, middleware("A", false), middleware("B", true))
+
+	cases := []struct {
+		path   string
+		code   int
+		expect string
+	}{
+		// nested Next, the chain completes without Abort
+		{"/next", http.StatusOK, "[H B:false A:false]"},
+		{"/abort", http.StatusForbidden, "[B:true A:true]"},
+	}
+	for _, c := range cases {
+		trace = nil
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.path, nil))
+		fmt.Println("[TestAbort] result ->", c.path, rec.Code, trace)
+		if rec.Code != c.code || fmt.Sprint(trace) != c.expect {
+			t.Fatal("aborted state does not match")
+		}
+	}
+
+	fmt.Println("\n[TestAbort] end")
+}
@@ -0,0 +1,136 @@
+package easierweb
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// ControllerRoutes the controller declares the routes of its methods, instead of the naming convention
+// e.g. map[string]string{"Search": "GET /search", "Export": "POST /:id/export", "Helper": "-"}
+// "-" means the method is not registered
+type ControllerRoutes interface {
+	Routes() map[string]string
+}
+
+// controllerMethods the method name prefixes, Any registers all methods
+var controllerMethods = []struct {
+	prefix string
+	method string
+}{
+	{"Get", MethodGET},
+	{"Head", MethodHEAD},
+	{"Options", MethodOPTIONS},
+	{"Post", MethodPOST},
+	{"Put", MethodPUT},
+	{"Patch", MethodPATCH},
+	{"Delete", MethodDELETE},
+	{"Any", ""},
+}
+
+// EasyController register the exported methods of the controller as the easy handles, the methods are named by the convention:
+// Get -> GET /users, Post -> POST /users, GetByID -> GET /users/:id, DeleteByID -> DELETE /users/:id,
+// GetProfile -> GET /users/profile, GetOrdersByID -> GET /users/orders/:id, PutUserOrders -> PUT /users/user-orders
+// the methods without the http method prefix are not registered, and the controller can declare the routes by ControllerRoutes
+// the static paths cannot be registered along with the wildcard paths of the same level, e.g. GetProfile and GetByID (httprouter)
+// e.g. router.EasyController("/users", &UserController{})
+func (r *Router) EasyController(path string, controller any, middlewares ...Handle) *Router {
+	v := reflect.ValueOf(controller)
+	t := v.Type()
+	c, hasRoutes := controller.(ControllerRoutes)
+	var declared map[string]string
+	if hasRoutes {
+		declared = c.Routes()
+	}
+	path = strings.TrimSuffix(path, "/")
+	var routes []*route
+	for i := 0; i < t.NumMethod(); i++ {
+		name := t.Method(i).Name
+		if hasRoutes && name == "Routes" {
+			continue
+		}
+		var methods []string
+		var subPath string
+		if d, ok := declared[name]; ok {
+			if d == "-" {
+				continue
+			}
+			method, p, found := strings.Cut(strings.TrimSpace(d), " ")
+			if !found {
+				panic(fmt.Errorf("invalid route '%s' of the controller method %s, e.g. 'GET /path'", d, name))
+			}
+			methods = []string{strings.ToUpper(method)}
+			subPath = strings.TrimSuffix(strings.TrimSpace(p), "/")
+		} else {
+			method, p, ok := controllerRoute(name)
+			if !ok {
+				continue
+			}
+			methods = []string{method}
+			if method == "" {
+				methods = methodNames
+			}
+			subPath = p
+		}
+		easyHandle := v.Method(i).Interface()
+		plugins := &routePlugins{}
+		handle := r.easyHandle(easyHandle, plugins)
+		r.lastRoutes = nil
+		for _, method := range methods {
+			rt := r.api(method, path+subPath, handle, easyHandle, middlewares)
+			rt.plugins = plugins
+			routes = append(routes, rt)
+		}
+	}
+	// the later settings (e.g. Doc, RequestPlugins) apply to all routes of the controller
+	r.lastRoutes = routes
+	return r
+}
+
+// controllerRoute the http method (empty means all) and the sub path of the method name, e.g. GetOrdersByID -> GET /orders/:id
+func controllerRoute(name string) (string, string, bool) {
+	for _, m := range controllerMethods {
+		rest, ok := strings.CutPrefix(name, m.prefix)
+		if !ok || (rest != "" && !unicode.IsUpper(rune(rest[0]))) {
+			continue
+		}
+		var param string
+		if i := strings.LastIndex(rest, "By"); i >= 0 && len(rest) > i+2 && unicode.IsUpper(rune(rest[i+2])) {
+			rest, param = rest[:i], rest[i+2:]
+		}
+		var subPath string
+		if rest != "" {
+			subPath = "/" + kebabCase(rest)
+		}
+		if param != "" {
+			subPath += "/:" + lowerCamelCase(param)
+		}
+		return m.method, subPath, true
+	}
+	return "", "", false
+}
+
+// kebabCase e.g. UserOrders -> user-orders, HTMLPage -> html-page
+func kebabCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, c := range runes {
+		if unicode.IsUpper(c) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('-')
+			}
+			c = unicode.ToLower(c)
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// lowerCamelCase e.g. ID -> id, UserID -> userID
+func lowerCamelCase(s string) string {
+	if strings.ToUpper(s) == s {
+		return strings.ToLower(s)
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
@@ -0,0 +1,156 @@
+package easierweb
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidCookie  = errors.New("invalid cookie")
+	ErrCookieSecrets  = errors.New("cookie secrets are empty")
+	cookieSignContext = []byte("easierweb-cookie-sign")
+	cookieCryptoSalt  = []byte("easierweb-cookie-encrypt")
+)
+
+// Cookie get the cookie value by name
+func (c *Context) Cookie(name string) (string, error) {
+	cookie, err := c.Request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return cookie.Value, nil
+}
+
+// SetCookie set the cookie with the defaults: Path "/", SameSite Lax, Secure if the request is TLS
+func (c *Context) SetCookie(cookie *http.Cookie) {
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	// zero means not set, http.SameSiteDefaultMode is kept
+	if cookie.SameSite == 0 {
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	if c.Request.TLS != nil {
+		cookie.Secure = true
+	}
+	http.SetCookie(c.ResponseWriter, cookie)
+}
+
+// DeleteCookie expire the cookie in the client
+func (c *Context) DeleteCookie(name string) {
+	c.SetCookie(&http.Cookie{Name: name, Value: "", MaxAge: -1, Expires: time.Unix(0, 0)})
+}
+
+// SetSignedCookie set the cookie signed (HMAC-SHA256) by the first key of RouterOptions.CookieSecrets, the value is readable by the client but cannot be modified
+func (c *Context) SetSignedCookie(cookie *http.Cookie) error {
+	secrets := c.router.cookieSecrets
+	if len(secrets) == 0 {
+		return ErrCookieSecrets
+	}
+	value := base64.RawURLEncoding.EncodeToString([]byte(cookie.Value))
+	cookie.Value = value + "." + base64.RawURLEncoding.EncodeToString(signCookie(secrets[0], cookie.Name, value))
+	c.SetCookie(cookie)
+	return nil
+}
+
+// SignedCookie get the value of the signed cookie, all keys of RouterOptions.CookieSecrets are tried (key rotation)
+func (c *Context) SignedCookie(name string) (string, error) {
+	raw, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	value, sig, ok := strings.Cut(raw, ".")
+	if !ok {
+		return "", ErrInvalidCookie
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	for _, secret := range c.router.cookieSecrets {
+		if hmac.Equal(mac, signCookie(secret, name, value)) {
+			data, err := base64.RawURLEncoding.DecodeString(value)
+			if err != nil {
+				return "", ErrInvalidCookie
+			}
+			return string(data), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+// SetEncryptedCookie set the cookie encrypted (AES-GCM) by the first key of RouterOptions.CookieSecrets, the value cannot be read or modified by the client
+func (c *Context) SetEncryptedCookie(cookie *http.Cookie) error {
+	secrets := c.router.cookieSecrets
+	if len(secrets) == 0 {
+		return ErrCookieSecrets
+	}
+	aead, err := cookieAEAD(secrets[0])
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return err
+	}
+	// the name is authenticated, so the value cannot be moved to another cookie
+	sealed := aead.Seal(nonce, nonce, []byte(cookie.Value), []byte(cookie.Name))
+	cookie.Value = base64.RawURLEncoding.EncodeToString(sealed)
+	c.SetCookie(cookie)
+	return nil
+}
+
+// EncryptedCookie get the value of the encrypted cookie, all keys of RouterOptions.CookieSecrets are tried (key rotation)
+func (c *Context) EncryptedCookie(name string) (string, error) {
+	raw, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	for _, secret := range c.router.cookieSecrets {
+		aead, err := cookieAEAD(secret)
+		if err != nil {
+			return "", err
+		}
+		if len(sealed) < aead.NonceSize() {
+			return "", ErrInvalidCookie
+		}
+		data, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(name))
+		if err == nil {
+			return string(data), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+func signCookie(secret []byte, name, value string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(cookieSignContext)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// cookieAEAD derive the aes-256 key from the secret, so that the secrets of any length can be used
+func cookieAEAD(secret []byte) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(cookieCryptoSalt)
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
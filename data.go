@@ -3,6 +3,7 @@ package easierweb
 import (
 	"encoding/json"
 	"encoding/xml"
+	"github.com/vmihailenco/msgpack/v5"
 	"gopkg.in/yaml.v3"
 )
 
@@ -20,6 +21,10 @@ func (d *Data) ParseXML(obj any) error {
 	return xml.Unmarshal(*d, obj)
 }
 
+func (d *Data) ParseMsgPack(obj any) error {
+	return msgpack.Unmarshal(*d, obj)
+}
+
 func (d *Data) SaveJSON(obj any) error {
 	marshal, err := json.Marshal(obj)
 	if err != nil {
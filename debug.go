@@ -0,0 +1,64 @@
+package easierweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+type DebugOptions struct {
+	// enable the debug output of all requests, e.g. in the development environment
+	Enabled bool
+	// the query parameter that enables the debug output of a request, e.g. "debug" (?debug=1), it requires Authorize
+	Query string
+	// whether the request is allowed to enable the debug output by the query parameter, e.g. the principal is an admin
+	Authorize func(ctx *Context) bool
+}
+
+// IsDebug whether the debug output is enabled for the request (see RouterOptions.Debug), the JSON responses are indented,
+// and the error responses include the underlying error and the stack trace
+func (c *Context) IsDebug() bool {
+	if c.debug == 0 {
+		c.debug = 1
+		if c.router.debug.Enabled || c.debugQuery() {
+			c.debug = 2
+		}
+	}
+	return c.debug == 2
+}
+
+func (c *Context) debugQuery() bool {
+	opt := c.router.debug
+	if opt.Query == "" || opt.Authorize == nil || !c.Request.URL.Query().Has(opt.Query) {
+		return false
+	}
+	value := c.Request.URL.Query().Get(opt.Query)
+	if value == "0" || value == "false" {
+		return false
+	}
+	return opt.Authorize(c)
+}
+
+// debugJSON indent the JSON if the debug output is enabled
+func (c *Context) debugJSON(data []byte) []byte {
+	if !c.IsDebug() {
+		return data
+	}
+	var buf bytes.Buffer
+	if json.Indent(&buf, data, "", "  ") != nil {
+		return data
+	}
+	return buf.Bytes()
+}
+
+// stackLines the lines of the stack trace, for the JSON output
+func stackLines(stack []byte) []string {
+	if len(stack) == 0 {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSpace(string(stack)), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return lines
+}
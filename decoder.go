@@ -0,0 +1,74 @@
+package easierweb
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+	"mime"
+	"strings"
+)
+
+// Decoder unmarshal the request body into obj
+type Decoder func(data []byte, obj any) error
+
+const (
+	MediaTypeJSON        = "application/json"
+	MediaTypeXML         = "application/xml"
+	MediaTypeTextXML     = "text/xml"
+	MediaTypeYAML        = "application/yaml"
+	MediaTypeXYAML       = "application/x-yaml"
+	MediaTypeTextYAML    = "text/yaml"
+	MediaTypeMsgPack     = "application/msgpack"
+	MediaTypeXMsgPack    = "application/x-msgpack"
+	MediaTypeVndMsgPack  = "application/vnd.msgpack"
+	MediaTypeForm        = "application/x-www-form-urlencoded"
+	MediaTypeMultipart   = "multipart/form-data"
+	MediaTypeEventStream = "text/event-stream"
+	MediaTypeProblemJSON = "application/problem+json"
+	MediaTypeProtobuf    = "application/protobuf"
+	MediaTypeXProtobuf   = "application/x-protobuf"
+)
+
+func defaultDecoders() map[string]Decoder {
+	return map[string]Decoder{
+		MediaTypeJSON:       json.Unmarshal,
+		MediaTypeXML:        xml.Unmarshal,
+		MediaTypeTextXML:    xml.Unmarshal,
+		MediaTypeYAML:       yaml.Unmarshal,
+		MediaTypeXYAML:      yaml.Unmarshal,
+		MediaTypeTextYAML:   yaml.Unmarshal,
+		MediaTypeMsgPack:    msgpack.Unmarshal,
+		MediaTypeXMsgPack:   msgpack.Unmarshal,
+		MediaTypeVndMsgPack: msgpack.Unmarshal,
+		MediaTypeProtobuf:   unmarshalProtobuf,
+		MediaTypeXProtobuf:  unmarshalProtobuf,
+	}
+}
+
+// SetDecoder register a request body decoder for the media type (e.g. application/json)
+func (r *Router) SetDecoder(mediaType string, decoder Decoder) *Router {
+	r.decoders[strings.ToLower(mediaType)] = decoder
+	return r
+}
+
+// decoder get the decoder by the Content-Type header, if not found, use the json codec
+func (r *Router) decoder(contentType string) Decoder {
+	if contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err == nil {
+			decoder, ok := r.decoders[strings.ToLower(mediaType)]
+			if ok {
+				return decoder
+			}
+			// structured syntax suffix, e.g. application/problem+json
+			if i := strings.LastIndex(mediaType, "+"); i >= 0 {
+				decoder, ok = r.decoders["application/"+strings.ToLower(mediaType[i+1:])]
+				if ok {
+					return decoder
+				}
+			}
+		}
+	}
+	return r.jsonCodec.Unmarshal
+}
@@ -1,40 +1,53 @@
 package easierweb
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
-	"runtime/debug"
 )
 
 // default function
 
 func defaultRequestHandle() RequestHandle {
 	return func(ctx *Context, reqObj any) error {
+		// the fields tagged by query/form are bound by BindParams instead of mapstructure
 		if len(ctx.Form) > 0 {
-			err := ctx.BindForm(reqObj)
-			if err != nil {
-				return err
+			if !usesBindSource(reqObj, "form") {
+				err := ctx.BindForm(reqObj)
+				if err != nil {
+					return err
+				}
 			}
 		} else if len(ctx.Body) > 0 {
-			err := ctx.BindJSON(reqObj)
+			err := ctx.BindBody(reqObj)
 			if err != nil {
 				return err
 			}
 		}
-		if len(ctx.Query) > 0 {
+		if len(ctx.Query) > 0 && !usesBindSource(reqObj, "query") {
 			err := ctx.BindQuery(reqObj)
 			if err != nil {
 				return err
 			}
 		}
-		return nil
+		return ctx.BindParams(reqObj)
 	}
 }
 
 func defaultResponseHandle() ResponseHandle {
 	return func(ctx *Context, result any, err error) {
 		if err != nil {
+			var validationErrors ValidationErrors
+			if errors.As(err, &validationErrors) {
+				ctx.WriteJSON(http.StatusBadRequest, validationErrors)
+				return
+			}
+			var e *Error
+			if errors.As(err, &e) {
+				ctx.WriteError(e)
+				return
+			}
 			if result != nil {
 				ctx.WriteJSON(http.StatusBadRequest, result)
 				return
@@ -45,13 +58,27 @@ func defaultResponseHandle() ResponseHandle {
 			ctx.NoContent(http.StatusNoContent)
 			return
 		}
-		ctx.WriteJSON(http.StatusOK, result)
+		if IsProtoMessage(result) && ctx.WantsProtobuf() {
+			ctx.WriteProtobuf(http.StatusOK, result)
+			return
+		}
+		ctx.SetPageLinks(result)
+		ctx.WriteJSON(http.StatusOK, ctx.SelectFields(result))
 	}
 }
 
 func defaultErrorHandle() ErrorHandle {
 	return func(ctx *Context, err any) {
-		ctx.Logger.Error(fmt.Sprintf("%s\n%s", err, string(debug.Stack())), slog.String("method", ctx.Request.Method), slog.String("route", ctx.Route))
+		if e, ok := err.(*Error); ok {
+			ctx.WriteError(e)
+			return
+		}
+		ctx.Logger.Error(fmt.Sprintf("%s\n%s", err, string(ctx.Stack())), slog.String("method", ctx.Request.Method), slog.String("route", ctx.Route),
+			slog.Any("headers", ctx.Redactor().Headers(ctx.Request.Header)))
+		if ctx.IsDebug() {
+			ctx.WriteJSON(http.StatusInternalServerError, map[string]any{"msg": fmt.Sprint(err), "stack": stackLines(ctx.Stack())})
+			return
+		}
 		ctx.WriteString(http.StatusInternalServerError, fmt.Sprintf("{\"msg\":\"%s\"}", err))
 	}
 }
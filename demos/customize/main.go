@@ -7,6 +7,7 @@ import (
 	"github.com/dpwgc/easierweb/plugins"
 	"log/slog"
 	"net/http"
+	"time"
 )
 
 // you can customize error, request, and response handle functions
@@ -25,6 +26,18 @@ func main() {
 		MultipartFormMaxMemory: 4096,
 		// whether to turn off console output
 		CloseConsolePrint: false,
+		// close the router gracefully when receiving SIGINT/SIGTERM
+		ShutdownSignals: true,
+		// maximum time to wait for in-flight requests when closing
+		ShutdownTimeout: 10 * time.Second,
+		// customize the response of unmatched routes (404)
+		NotFoundHandle: func(ctx *easierweb.Context) {
+			ctx.WriteJSON(http.StatusNotFound, map[string]string{"msg": "not found"})
+		},
+		// customize the response when the path matches but the method does not (405)
+		MethodNotAllowedHandle: func(ctx *easierweb.Context) {
+			ctx.WriteJSON(http.StatusMethodNotAllowed, map[string]string{"msg": "method not allowed"})
+		},
 	})
 
 	// use framework plugins
@@ -0,0 +1,139 @@
+package easierweb
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"log/slog"
+	"reflect"
+)
+
+var (
+	bytesType  = reflect.TypeOf([]byte(nil))
+	stringType = reflect.TypeOf("")
+	errType    = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// EasyWS the websocket handle of the messages, it is called for each message until the connection is closed
+// e.g. router.EasyWS("/chat", func(ctx *easierweb.Context, msg ChatMessage) (*ChatReply, error) { ... })
+// the parameters are *Context, context.Context and the message (json by the JSONCodec, []byte and string are raw),
+// the message is validated by the validator, and the result is sent as json ([]byte as binary, string as text, nil is not sent)
+// the error closes the connection: *websocket.CloseError with its code, *Error with 4000 + status (e.g. 4401),
+// the decoding and validation errors with 1007 (invalid payload), the others with 1011 (internal server error)
+func (r *Router) EasyWS(path string, easyHandle any, middlewares ...Handle) *Router {
+	return r.WS(path, r.easyWSHandle(easyHandle), middlewares...)
+}
+
+func (r *Router) easyWSHandle(easyHandle any) Handle {
+	funcType := reflect.TypeOf(easyHandle)
+	if funcType.Kind() != reflect.Func || funcType.NumOut() > 2 {
+		panic(errors.New("websocket handle does not match"))
+	}
+	msgType := easyRequestType(funcType)
+	if msgType == nil || funcType.NumIn() > 2 {
+		panic(errors.New("websocket handle input parameters does not match"))
+	}
+	fn := reflect.ValueOf(easyHandle)
+	return func(ctx *Context) {
+		for {
+			_, data, err := ctx.WebsocketConn.ReadMessage()
+			if err != nil {
+				if !IsCloseError(err, CloseNormalClosure, CloseGoingAway, websocket.CloseNoStatusReceived) {
+					ctx.Logger.Debug("websocket read error: "+err.Error(), slog.String("route", ctx.Route))
+				}
+				return
+			}
+			paramValues := make([]reflect.Value, funcType.NumIn())
+			for i := range paramValues {
+				if isContextParam(funcType.In(i)) {
+					paramValues[i] = reflect.ValueOf(ctx)
+					continue
+				}
+				msg := reflect.New(msgType)
+				err = r.decodeWSMessage(data, msg)
+				if err == nil && r.validator != nil && msgType != bytesType && msgType != stringType {
+					err = r.validator.Validate(msg.Interface())
+				}
+				if err != nil {
+					_ = ctx.WebsocketConn.CloseWithCode(CloseInvalidPayload, "invalid message")
+					return
+				}
+				paramValues[i] = msg.Elem()
+			}
+			var result reflect.Value
+			for j, v := range fn.Call(paramValues) {
+				if funcType.Out(j) != errType {
+					result = v
+				} else if !v.IsNil() {
+					err = v.Interface().(error)
+				}
+			}
+			if err != nil {
+				r.closeWSWithError(ctx, err)
+				return
+			}
+			err = r.sendWSResult(ctx, result)
+			if err != nil {
+				ctx.Logger.Debug("websocket write error: "+err.Error(), slog.String("route", ctx.Route))
+				return
+			}
+		}
+	}
+}
+
+func (r *Router) decodeWSMessage(data []byte, msg reflect.Value) error {
+	switch msg.Elem().Type() {
+	case bytesType:
+		msg.Elem().SetBytes(data)
+		return nil
+	case stringType:
+		msg.Elem().SetString(string(data))
+		return nil
+	}
+	return r.jsonCodec.Unmarshal(data, msg.Interface())
+}
+
+func (r *Router) sendWSResult(ctx *Context, result reflect.Value) error {
+	if !result.IsValid() {
+		return nil
+	}
+	switch result.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		if result.IsNil() {
+			return nil
+		}
+	}
+	switch v := result.Interface().(type) {
+	case []byte:
+		return ctx.SendBinary(v)
+	case string:
+		return ctx.SendString(v)
+	}
+	return ctx.SendJSON(result.Interface())
+}
+
+// closeWSWithError map the error of the websocket handle to the close frame
+func (r *Router) closeWSWithError(ctx *Context, err error) {
+	var closeErr *websocket.CloseError
+	var e *Error
+	var validationErrors ValidationErrors
+	switch {
+	case errors.As(err, &closeErr):
+		_ = ctx.WebsocketConn.CloseWithCode(closeErr.Code, closeReason(closeErr.Text))
+	case errors.As(err, &e):
+		_ = ctx.WebsocketConn.CloseWithCode(4000+e.Status, closeReason(e.Message))
+	case errors.As(err, &validationErrors):
+		_ = ctx.WebsocketConn.CloseWithCode(CloseInvalidPayload, "invalid message")
+	default:
+		ctx.Logger.Error(fmt.Sprintf("websocket handle error: %s", err), slog.String("route", ctx.Route))
+		_ = ctx.WebsocketConn.CloseWithCode(CloseInternalServerError, "internal server error")
+	}
+}
+
+// closeReason the reason of the close frame is limited to 123 bytes
+func closeReason(reason string) string {
+	if len(reason) > 123 {
+		return reason[:123]
+	}
+	return reason
+}
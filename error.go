@@ -0,0 +1,81 @@
+package easierweb
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Error the error with the http status code, the default response handle renders it as the RFC 7807 problem details
+// e.g. return nil, easierweb.NewError(http.StatusNotFound, "user not found").WithCode("USER_NOT_FOUND")
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Details any
+	// the underlying error, it is not exposed to the client
+	Err error
+}
+
+// Problem the RFC 7807 problem details (application/problem+json)
+type Problem struct {
+	Type     string `json:"type" xml:"Type" yaml:"type"`
+	Title    string `json:"title" xml:"Title" yaml:"title"`
+	Status   int    `json:"status" xml:"Status" yaml:"status"`
+	Detail   string `json:"detail,omitempty" xml:"Detail,omitempty" yaml:"detail,omitempty"`
+	Instance string `json:"instance,omitempty" xml:"Instance,omitempty" yaml:"instance,omitempty"`
+	Code     string `json:"code,omitempty" xml:"Code,omitempty" yaml:"code,omitempty"`
+	Details  any    `json:"details,omitempty" xml:"Details,omitempty" yaml:"details,omitempty"`
+	// the underlying error and the stack trace, only in the debug output (see RouterOptions.Debug)
+	Error string   `json:"error,omitempty" xml:"Error,omitempty" yaml:"error,omitempty"`
+	Stack []string `json:"stack,omitempty" xml:"Stack,omitempty" yaml:"stack,omitempty"`
+}
+
+func NewError(status int, message string) *Error {
+	return &Error{Status: status, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithCode set the business error code
+func (e *Error) WithCode(code string) *Error {
+	e.Code = code
+	return e
+}
+
+// WithDetails set the details, e.g. the invalid fields
+func (e *Error) WithDetails(details any) *Error {
+	e.Details = details
+	return e
+}
+
+// Wrap set the underlying error
+func (e *Error) Wrap(err error) *Error {
+	e.Err = err
+	return e
+}
+
+// Problem convert to the problem details, the instance is the request path
+func (e *Error) Problem(instance string) Problem {
+	status := e.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	return Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   e.Message,
+		Instance: instance,
+		Code:     e.Code,
+		Details:  e.Details,
+	}
+}
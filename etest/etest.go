@@ -0,0 +1,195 @@
+package etest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// Client send the requests to the router (or any http.Handler) in memory, the middlewares and the easy handle binding are executed as usual
+// the cookies are kept between the requests, e.g. the session cookie
+type Client struct {
+	handler http.Handler
+	header  http.Header
+	jar     http.CookieJar
+	t       testing.TB
+}
+
+// Response the recorded response, the expectations are checked in order, and the first failure is returned by Err and BindJSON
+type Response struct {
+	Code   int
+	Header http.Header
+	Body   []byte
+	err    error
+	t      testing.TB
+}
+
+var baseURL, _ = url.Parse("http://example.com")
+
+// NewTestClient create a client of the handler, e.g. etest.NewTestClient(router).WithT(t)
+func NewTestClient(handler http.Handler) *Client {
+	jar, _ := cookiejar.New(nil)
+	return &Client{
+		handler: handler,
+		header:  make(http.Header),
+		jar:     jar,
+	}
+}
+
+// WithT report the failed expectations to the test (t.Errorf), instead of only returning them
+func (c *Client) WithT(t testing.TB) *Client {
+	c.t = t
+	return c
+}
+
+// SetHeader set the header of all requests, e.g. Authorization
+func (c *Client) SetHeader(key, value string) *Client {
+	c.header.Set(key, value)
+	return c
+}
+
+func (c *Client) GET(path string) *Response {
+	return c.Do(http.MethodGet, path, nil)
+}
+
+func (c *Client) HEAD(path string) *Response {
+	return c.Do(http.MethodHead, path, nil)
+}
+
+func (c *Client) OPTIONS(path string) *Response {
+	return c.Do(http.MethodOptions, path, nil)
+}
+
+func (c *Client) DELETE(path string) *Response {
+	return c.Do(http.MethodDelete, path, nil)
+}
+
+func (c *Client) POST(path string, body any) *Response {
+	return c.Do(http.MethodPost, path, body)
+}
+
+func (c *Client) PUT(path string, body any) *Response {
+	return c.Do(http.MethodPut, path, body)
+}
+
+func (c *Client) PATCH(path string, body any) *Response {
+	return c.Do(http.MethodPatch, path, body)
+}
+
+// Do send the request, the body can be nil, []byte, string, io.Reader, url.Values (form), or any other value encoded as json
+// the headers are the key-value pairs, e.g. c.Do("POST", "/users", user, "X-Request-ID", "1")
+func (c *Client) Do(method, path string, body any, headers ...string) *Response {
+	reader, contentType, err := encodeBody(body)
+	if err != nil {
+		return c.response(nil, err)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	for _, cookie := range c.jar.Cookies(baseURL) {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	c.handler.ServeHTTP(rec, req)
+	res := rec.Result()
+	c.jar.SetCookies(baseURL, res.Cookies())
+	return c.response(rec, nil)
+}
+
+func (c *Client) response(rec *httptest.ResponseRecorder, err error) *Response {
+	res := &Response{t: c.t}
+	if rec != nil {
+		res.Code = rec.Code
+		res.Header = rec.Header()
+		res.Body = rec.Body.Bytes()
+	}
+	res.fail(err)
+	return res
+}
+
+func encodeBody(body any) (io.Reader, string, error) {
+	switch v := body.(type) {
+	case nil:
+		return nil, "", nil
+	case []byte:
+		return bytes.NewReader(v), "", nil
+	case string:
+		return strings.NewReader(v), "", nil
+	case io.Reader:
+		return v, "", nil
+	case url.Values:
+		return strings.NewReader(v.Encode()), "application/x-www-form-urlencoded", nil
+	}
+	marshal, err := json.Marshal(body)
+	if err != nil {
+		return nil, "", err
+	}
+	return bytes.NewReader(marshal), "application/json", nil
+}
+
+func (r *Response) fail(err error) {
+	if err == nil || r.err != nil {
+		return
+	}
+	r.err = err
+	if r.t != nil {
+		r.t.Helper()
+		r.t.Errorf("%s", err)
+	}
+}
+
+func (r *Response) ExpectStatus(code int) *Response {
+	if r.err == nil && r.Code != code {
+		r.fail(fmt.Errorf("expected status %d, got %d: %s", code, r.Code, r.Body))
+	}
+	return r
+}
+
+func (r *Response) ExpectHeader(key, value string) *Response {
+	if r.err == nil && r.Header.Get(key) != value {
+		r.fail(fmt.Errorf("expected header %s %q, got %q", key, value, r.Header.Get(key)))
+	}
+	return r
+}
+
+func (r *Response) ExpectBodyContains(text string) *Response {
+	if r.err == nil && !bytes.Contains(r.Body, []byte(text)) {
+		r.fail(fmt.Errorf("expected body to contain %q, got %s", text, r.Body))
+	}
+	return r
+}
+
+// BindJSON decode the response body, returns the first failed expectation if any
+func (r *Response) BindJSON(obj any) error {
+	if r.err != nil {
+		return r.err
+	}
+	err := json.Unmarshal(r.Body, obj)
+	if err != nil {
+		r.fail(fmt.Errorf("decode response body error: %w", err))
+	}
+	return r.err
+}
+
+// Err the first failed expectation
+func (r *Response) Err() error {
+	return r.err
+}
+
+func (r *Response) String() string {
+	return string(r.Body)
+}
@@ -0,0 +1,61 @@
+package easierweb
+
+import (
+	"fmt"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+const (
+	EngineNetHTTP  = "net/http"
+	EngineFastHTTP = "fasthttp"
+)
+
+// RunFast start the server on fasthttp, the routes, handles and middlewares are the same as Run
+// the requests are converted to *http.Request, so the Context works as usual, except for:
+// websocket and server-sent events are not supported (the connection cannot be hijacked, the response is buffered),
+// and the request body is read into memory, limited by MaxBodySize (fasthttp default 4MB if it is not set)
+func (r *Router) RunFast(addr string) error {
+	server := r.newFastServer()
+	r.addFastServer(server)
+	r.consoleStartPrint(addr)
+	return r.serve(func() error {
+		return server.ListenAndServe(addr)
+	})
+}
+
+// newFastServer create the fasthttp.Server with the ServerOptions
+func (r *Router) newFastServer() *fasthttp.Server {
+	server := &fasthttp.Server{
+		Handler:      fasthttpadaptor.NewFastHTTPHandler(r),
+		ReadTimeout:  r.serverOptions.ReadTimeout,
+		WriteTimeout: r.serverOptions.WriteTimeout,
+		IdleTimeout:  r.serverOptions.IdleTimeout,
+		Logger:       fastLogger{r: r},
+		// the Server header is not set by net/http either
+		NoDefaultServerHeader: true,
+	}
+	if r.serverOptions.MaxHeaderBytes > 0 {
+		server.ReadBufferSize = r.serverOptions.MaxHeaderBytes
+	}
+	if r.maxBodySize > 0 && r.maxBodySize <= int64(^uint(0)>>1) {
+		server.MaxRequestBodySize = int(r.maxBodySize)
+	}
+	return server
+}
+
+// addFastServer track the fasthttp server to be closed by Close
+func (r *Router) addFastServer(server *fasthttp.Server) {
+	r.serversLock.Lock()
+	defer r.serversLock.Unlock()
+	r.fastServers = append(r.fastServers, server)
+}
+
+// fastLogger write the fasthttp logs to the router logger
+type fastLogger struct {
+	r *Router
+}
+
+func (f fastLogger) Printf(format string, args ...any) {
+	f.r.logger.Error(fmt.Sprintf(format, args...))
+}
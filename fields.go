@@ -0,0 +1,120 @@
+package easierweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+type FieldsOptions struct {
+	// prune the results of the default response handle to the requested fields, e.g. ?fields=id,name,owner.name
+	Enabled bool
+	// the query parameter of the fields, default "fields"
+	Query string
+	// maximum number of the requested fields, the extra fields are ignored, default 100
+	MaxFields int
+}
+
+// fieldTree the requested fields, nil means the whole value is kept
+type fieldTree map[string]fieldTree
+
+// Fields get the requested fields of the request (see RouterOptions.Fields), e.g. ?fields=id,name,owner.name
+// nil if the field filtering is disabled or the fields are not requested, the handles can use it to select the columns
+func (c *Context) Fields() []string {
+	opt := c.router.fields
+	if !opt.Enabled {
+		return nil
+	}
+	value := c.Request.URL.Query().Get(opt.Query)
+	if value == "" {
+		return nil
+	}
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if len(fields) >= opt.MaxFields {
+			break
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// SelectFields prune the object to the requested fields (ctx.Fields), the object is returned as is if the fields are not requested
+// the fields are the JSON keys, the nested fields are separated by dots (owner.name), the fields apply to each element of the arrays,
+// the fields of a Page apply to its items, the unknown fields are ignored, it is used by the default response handle
+func (c *Context) SelectFields(obj any) any {
+	fields := c.Fields()
+	if len(fields) == 0 || obj == nil {
+		return obj
+	}
+	marshal, err := c.router.jsonCodec.Marshal(obj)
+	if err != nil {
+		// the error is reported by the writer
+		return obj
+	}
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(marshal))
+	// keep the precision of the large numbers
+	decoder.UseNumber()
+	if decoder.Decode(&value) != nil {
+		return obj
+	}
+	tree := fieldTree{}
+	for _, field := range fields {
+		tree.add(strings.Split(field, "."))
+	}
+	// the fields of the Page apply to the items
+	if _, ok := obj.(paginated); ok {
+		if page, isMap := value.(map[string]any); isMap {
+			page["items"] = tree.prune(page["items"])
+			return page
+		}
+	}
+	return tree.prune(value)
+}
+
+func (t fieldTree) add(path []string) {
+	sub, ok := t[path[0]]
+	if ok && sub == nil {
+		// the whole value is already requested
+		return
+	}
+	if len(path) == 1 {
+		t[path[0]] = nil
+		return
+	}
+	if sub == nil {
+		sub = fieldTree{}
+		t[path[0]] = sub
+	}
+	sub.add(path[1:])
+}
+
+func (t fieldTree) prune(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		pruned := make(map[string]any, len(t))
+		for k, sub := range t {
+			item, ok := v[k]
+			if !ok {
+				continue
+			}
+			if sub != nil {
+				item = sub.prune(item)
+			}
+			pruned[k] = item
+		}
+		return pruned
+	case []any:
+		for i, item := range v {
+			v[i] = t.prune(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
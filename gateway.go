@@ -0,0 +1,69 @@
+package easierweb
+
+import (
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+type GatewayOptions struct {
+	// strip the path prefix before the request is handled by the gateway, the gateway routes usually contain the full path
+	StripPrefix bool
+	// the metadata sent to the grpc services, e.g. the user id set by the authentication middleware
+	// they are sent as the Grpc-Metadata-* headers, which are forwarded by the default header matcher of grpc-gateway
+	Metadata func(ctx *Context) map[string]string
+	// the middlewares of the gateway routes, e.g. authentication, access log
+	Middlewares []Handle
+}
+
+const grpcMetadataHeaderPrefix = "Grpc-Metadata-"
+
+// Gateway mount a grpc-gateway mux (runtime.ServeMux, or any http.Handler that transcodes the requests) at the path prefix
+// so the rest and grpc services share the server and the middlewares, the request id is sent as the x-request-id metadata
+// the Grpc-Metadata-* headers of the client are removed, the metadata can only be set by the Metadata option
+// e.g. router.Gateway("/v1", gwMux, easierweb.GatewayOptions{Middlewares: []easierweb.Handle{middlewares.JWT(jwtOpts)}})
+func (r *Router) Gateway(prefix string, mux http.Handler, opts ...GatewayOptions) *Router {
+	opt := GatewayOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	serve := WrapHTTPHandler(mux)
+	if opt.StripPrefix {
+		serve = stripPrefix(r.rootPath+prefix, mux)
+	}
+	handle := func(ctx *Context) {
+		header := ctx.Request.Header.Clone()
+		for k := range header {
+			if strings.HasPrefix(k, grpcMetadataHeaderPrefix) {
+				header.Del(k)
+			}
+		}
+		if id := ctx.RequestID(); id != "" {
+			header.Set(grpcMetadataHeaderPrefix+"X-Request-Id", id)
+		}
+		if opt.Metadata != nil {
+			for k, v := range opt.Metadata(ctx) {
+				header.Set(grpcMetadataHeaderPrefix+textproto.CanonicalMIMEHeaderKey(k), v)
+			}
+		}
+		req := ctx.Request
+		r2 := new(http.Request)
+		*r2 = *req
+		r2.Header = header
+		ctx.Request = r2
+		defer func() {
+			ctx.Request = req
+		}()
+		serve(ctx)
+	}
+	r.lastRoutes = nil
+	for _, method := range methodNames {
+		// the body is decoded by the gateway
+		if prefix != "" {
+			r.api(method, prefix, handle, nil, opt.Middlewares).streamBody = true
+		}
+		r.api(method, prefix+"/*gatewaypath", handle, nil, opt.Middlewares).streamBody = true
+	}
+	return r
+}
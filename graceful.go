@@ -0,0 +1,126 @@
+package easierweb
+
+import (
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// gracefulEnv the environment variable that passes the inherited listener (fd 3) to the new process
+const gracefulEnv = "EASIERWEB_GRACEFUL_FD"
+
+// RunGraceful start the server on the address, SIGUSR2 upgrades the binary without downtime (for the deployments without a load balancer):
+// the new process of the current executable (with the same arguments) inherits the listener, and once it serves, the old process
+// is sent SIGTERM and drains the in-flight requests (at most ShutdownTimeout), it implies ShutdownSignals, not supported on windows
+func (r *Router) RunGraceful(addr string) error {
+	return r.runGraceful(addr, false, "", "", nil)
+}
+
+// RunGracefulTLS the TLS version of RunGraceful
+func (r *Router) RunGracefulTLS(addr string, certFile string, keyFile string, tlsConfig *tls.Config) error {
+	return r.runGraceful(addr, true, certFile, keyFile, tlsConfig)
+}
+
+func (r *Router) runGraceful(addr string, useTLS bool, certFile string, keyFile string, tlsConfig *tls.Config) error {
+	server := r.newServer(addr)
+	if useTLS {
+		config, cf, kf, err := r.tlsConfig(tlsConfig, certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		server.TLSConfig, certFile, keyFile = config, cf, kf
+	}
+	l, inherited, err := gracefulListener(addr)
+	if err != nil {
+		return err
+	}
+	server.Handler = r.handler()
+	r.addServer(server)
+	r.shutdownSignals = true
+	stop := r.watchUpgrade(l)
+	defer stop()
+	if inherited {
+		// the listener is accepting, the old process can stop now
+		err = stopParent()
+		if err != nil {
+			r.logger.Warn("stop the old process error: " + err.Error())
+		}
+	}
+	r.consoleStartPrint(addr)
+	return r.serve(func() error {
+		if useTLS {
+			return server.ServeTLS(l, certFile, keyFile)
+		}
+		return server.Serve(l)
+	})
+}
+
+// gracefulListener the listener inherited from the old process, or a new one
+func gracefulListener(addr string) (net.Listener, bool, error) {
+	fd := os.Getenv(gracefulEnv)
+	if fd == "" {
+		l, err := net.Listen("tcp", addr)
+		return l, false, err
+	}
+	_ = os.Unsetenv(gracefulEnv)
+	n, err := strconv.Atoi(fd)
+	if err != nil {
+		return nil, false, errors.New("invalid " + gracefulEnv + ": " + fd)
+	}
+	file := os.NewFile(uintptr(n), "listener")
+	defer file.Close()
+	l, err := net.FileListener(file)
+	if err != nil {
+		return nil, false, err
+	}
+	return l, true, nil
+}
+
+// upgrade start the new process with the listener, the old process keeps serving if it fails
+func (r *Router) upgrade(l net.Listener) {
+	filer, ok := l.(interface{ File() (*os.File, error) })
+	if !ok {
+		r.logger.Error("upgrade error: the listener cannot be inherited")
+		return
+	}
+	file, err := filer.File()
+	if err != nil {
+		r.logger.Error("upgrade error: " + err.Error())
+		return
+	}
+	defer file.Close()
+	path, err := os.Executable()
+	if err != nil {
+		r.logger.Error("upgrade error: " + err.Error())
+		return
+	}
+	env := make([]string, 0, len(os.Environ())+1)
+	for _, e := range os.Environ() {
+		if !strings.HasPrefix(e, gracefulEnv+"=") {
+			env = append(env, e)
+		}
+	}
+	cmd := exec.Command(path, os.Args[1:]...)
+	// the ExtraFiles start from fd 3
+	cmd.Env = append(env, gracefulEnv+"=3")
+	cmd.ExtraFiles = []*os.File{file}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err = cmd.Start()
+	if err != nil {
+		r.logger.Error("upgrade error: " + err.Error())
+		return
+	}
+	r.logger.Info("upgrading, the new process is started", slog.Int("pid", cmd.Process.Pid))
+	go func() {
+		// release the new process if it exits before the old one, e.g. it fails to start
+		err := cmd.Wait()
+		if err != nil {
+			r.logger.Warn("the new process exited: "+err.Error(), slog.Int("pid", cmd.Process.Pid))
+		}
+	}()
+}
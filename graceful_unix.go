@@ -0,0 +1,36 @@
+//go:build !windows
+
+package easierweb
+
+import (
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchUpgrade upgrade the binary on SIGUSR2 until the returned function is called
+func (r *Router) watchUpgrade(l net.Listener) func() {
+	upgrade := make(chan os.Signal, 1)
+	signal.Notify(upgrade, syscall.SIGUSR2)
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-upgrade:
+				r.upgrade(l)
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(upgrade)
+		close(stop)
+	}
+}
+
+// stopParent send SIGTERM to the old process, it is closed gracefully
+func stopParent() error {
+	return syscall.Kill(os.Getppid(), syscall.SIGTERM)
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package easierweb
+
+import (
+	"errors"
+	"net"
+)
+
+// watchUpgrade the binary upgrade is not supported on windows (no SIGUSR2)
+func (r *Router) watchUpgrade(net.Listener) func() {
+	r.logger.Warn("graceful upgrade is not supported on windows")
+	return func() {}
+}
+
+func stopParent() error {
+	return errors.New("graceful upgrade is not supported on windows")
+}
@@ -1,6 +1,7 @@
 package easierweb
 
 import (
+	"io/fs"
 	"net/http"
 )
 
@@ -18,136 +19,249 @@ func (r *Router) Group(path string, middlewares ...Handle) *Group {
 	}
 }
 
+// Path get the path prefix of the group
+func (g *Group) Path() string {
+	return g.path
+}
+
+func (g *Group) Group(path string, middlewares ...Handle) *Group {
+	return &Group{
+		router:      g.router,
+		path:        g.path + path,
+		middlewares: g.join(middlewares),
+	}
+}
+
+func (g *Group) Use(middlewares ...Handle) *Group {
+	g.middlewares = append(g.middlewares, middlewares...)
+	return g
+}
+
 // easier usage function
 
 func (g *Group) EasyGET(path string, easyHandle any, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.EasyGET(g.path+path, easyHandle, middlewares...)
 	return g
 }
 
 func (g *Group) EasyHEAD(path string, easyHandle any, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.EasyHEAD(g.path+path, easyHandle, middlewares...)
 	return g
 }
 
 func (g *Group) EasyOPTIONS(path string, easyHandle any, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.EasyOPTIONS(g.path+path, easyHandle, middlewares...)
 	return g
 }
 
 func (g *Group) EasyPOST(path string, easyHandle any, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.EasyPOST(g.path+path, easyHandle, middlewares...)
 	return g
 }
 
 func (g *Group) EasyPUT(path string, easyHandle any, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.EasyPUT(g.path+path, easyHandle, middlewares...)
 	return g
 }
 
 func (g *Group) EasyPATCH(path string, easyHandle any, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.EasyPATCH(g.path+path, easyHandle, middlewares...)
 	return g
 }
 
 func (g *Group) EasyDELETE(path string, easyHandle any, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.EasyDELETE(g.path+path, easyHandle, middlewares...)
 	return g
 }
 
 func (g *Group) EasyAny(path string, easyHandle any, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.EasyAny(g.path+path, easyHandle, middlewares...)
 	return g
 }
 
 func (g *Group) EasyAPI(method, path string, easyHandle any, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.EasyAPI(method, g.path+path, easyHandle, middlewares...)
 	return g
 }
 
+func (g *Group) EasyController(path string, controller any, middlewares ...Handle) *Group {
+	middlewares = g.join(middlewares)
+	g.router.EasyController(g.path+path, controller, middlewares...)
+	return g
+}
+
 // basic usage function
 
 func (g *Group) GET(path string, handle Handle, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.GET(g.path+path, handle, middlewares...)
 	return g
 }
 
 func (g *Group) HEAD(path string, handle Handle, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.HEAD(g.path+path, handle, middlewares...)
 	return g
 }
 
 func (g *Group) OPTIONS(path string, handle Handle, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.OPTIONS(g.path+path, handle, middlewares...)
 	return g
 }
 
 func (g *Group) POST(path string, handle Handle, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.POST(g.path+path, handle, middlewares...)
 	return g
 }
 
 func (g *Group) PUT(path string, handle Handle, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.PUT(g.path+path, handle, middlewares...)
 	return g
 }
 
 func (g *Group) PATCH(path string, handle Handle, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.PATCH(g.path+path, handle, middlewares...)
 	return g
 }
 
 func (g *Group) DELETE(path string, handle Handle, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.DELETE(g.path+path, handle, middlewares...)
 	return g
 }
 
 func (g *Group) Any(path string, handle Handle, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.Any(g.path+path, handle, middlewares...)
 	return g
 }
 
 func (g *Group) API(method, path string, handle Handle, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.API(method, g.path+path, handle, middlewares...)
 	return g
 }
 
 func (g *Group) WS(path string, handle Handle, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.WS(g.path+path, handle, middlewares...)
 	return g
 }
 
+func (g *Group) EasyWS(path string, easyHandle any, middlewares ...Handle) *Group {
+	middlewares = g.join(middlewares)
+	g.router.EasyWS(g.path+path, easyHandle, middlewares...)
+	return g
+}
+
 func (g *Group) SSE(path string, handle Handle, middlewares ...Handle) *Group {
-	middlewares = append(g.middlewares, middlewares...)
+	middlewares = g.join(middlewares)
 	g.router.SSE(g.path+path, handle, middlewares...)
 	return g
 }
 
-func (g *Group) Static(path, dir string) *Group {
-	g.router.Static(g.path+path, dir)
+func (g *Group) Handle(path string, handler http.Handler, middlewares ...Handle) *Group {
+	middlewares = g.join(middlewares)
+	g.router.Handle(g.path+path, handler, middlewares...)
+	return g
+}
+
+func (g *Group) Mount(prefix string, handler http.Handler, middlewares ...Handle) *Group {
+	middlewares = g.join(middlewares)
+	g.router.Mount(g.path+prefix, handler, middlewares...)
+	return g
+}
+
+func (g *Group) Proxy(prefix, target string, opts ...ProxyOptions) *Group {
+	opt := ProxyOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	opt.Middlewares = g.join(opt.Middlewares)
+	g.router.Proxy(g.path+prefix, target, opt)
 	return g
 }
 
-func (g *Group) StaticFS(path string, fs http.FileSystem) *Group {
-	g.router.StaticFS(g.path+path, fs)
+func (g *Group) Gateway(prefix string, mux http.Handler, opts ...GatewayOptions) *Group {
+	opt := GatewayOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	opt.Middlewares = g.join(opt.Middlewares)
+	g.router.Gateway(g.path+prefix, mux, opt)
 	return g
 }
+
+func (g *Group) Static(path, dir string, opts ...StaticOptions) *Group {
+	g.router.Static(g.path+path, dir, opts...)
+	return g
+}
+
+func (g *Group) StaticFS(path string, fs http.FileSystem, opts ...StaticOptions) *Group {
+	g.router.StaticFS(g.path+path, fs, opts...)
+	return g
+}
+
+func (g *Group) StaticFile(path, filePath string) *Group {
+	g.router.StaticFile(g.path+path, filePath)
+	return g
+}
+
+func (g *Group) StaticData(path string, data []byte, contentType string) *Group {
+	g.router.StaticData(g.path+path, data, contentType)
+	return g
+}
+
+func (g *Group) StaticFiles(path string, fsys fs.FS, opts ...StaticOptions) *Group {
+	g.router.StaticFiles(g.path+path, fsys, opts...)
+	return g
+}
+
+// Name name the last registered route of the group
+func (g *Group) Name(name string) *Group {
+	g.router.Name(name)
+	return g
+}
+
+// RequestPlugins add the request plugins of the last registered easy route of the group
+func (g *Group) RequestPlugins(plugins ...RequestPlugin) *Group {
+	g.router.RequestPlugins(plugins...)
+	return g
+}
+
+// ResponsePlugins add the response plugins of the last registered easy route of the group
+func (g *Group) ResponsePlugins(plugins ...ResponsePlugin) *Group {
+	g.router.ResponsePlugins(plugins...)
+	return g
+}
+
+// Where set the constraint of the path parameter of the last registered route of the group
+func (g *Group) Where(name, pattern string) *Group {
+	g.router.Where(name, pattern)
+	return g
+}
+
+func (g *Group) Describe(doc APIDoc) *Group {
+	g.router.Describe(doc)
+	return g
+}
+
+// join returns a new slice so routes never share the group's backing array
+func (g *Group) join(middlewares []Handle) []Handle {
+	handles := make([]Handle, 0, len(g.middlewares)+len(middlewares))
+	handles = append(handles, g.middlewares...)
+	return append(handles, middlewares...)
+}
@@ -28,6 +28,11 @@ func TestGroup(t *testing.T) {
 	group3 := router.Group("/3", groupTestMiddleware3)
 	{
 		group3.GET("/hello", groupTeatApi)
+		// nested group, inherits the middlewares of group3
+		group4 := group3.Group("/4").Use(groupTestMiddleware1)
+		{
+			group4.GET("/hello", groupTeatApi)
+		}
 	}
 
 	go func() {
@@ -38,6 +43,8 @@ func TestGroup(t *testing.T) {
 		time.Sleep(1 * time.Second)
 		groupTeatHttpClient("GET", "/3/hello", "")
 		time.Sleep(1 * time.Second)
+		groupTeatHttpClient("GET", "/3/4/hello", "")
+		time.Sleep(1 * time.Second)
 		fmt.Println("\n[TestGroup](go func) close router")
 		err := router.Close()
 		if err != nil {
@@ -1,11 +1,14 @@
 package easierweb
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/julienschmidt/httprouter"
-	"golang.org/x/net/websocket"
+	"log/slog"
 	"net/http"
 	"reflect"
+	"runtime/debug"
 )
 
 type Handle func(ctx *Context)
@@ -16,28 +19,38 @@ type ResponseHandle func(ctx *Context, result any, err error)
 
 type ErrorHandle func(ctx *Context, err any)
 
-func (r *Router) handle(route string, handle Handle, res http.ResponseWriter, req *http.Request, par httprouter.Params, ws *websocket.Conn, sse bool, middlewares ...Handle) {
+// RecoveryHook called with the panic value and the stack trace when a panic is recovered, e.g. report to sentry
+type RecoveryHook func(ctx *Context, err any, stack []byte)
+
+// handle execute the middlewares and the handle, the route is nil for the not found and method not allowed handles
+func (r *Router) handle(rt *route, handle Handle, res http.ResponseWriter, req *http.Request, par httprouter.Params, middlewares ...Handle) {
 
 	ctx := r.contextPool.Get().(*Context)
 
-	err := setContext(ctx, r, route, res, req, par, ws, middlewares...)
+	err := setContext(ctx, r, rt, res, req, par, middlewares...)
 
 	defer func() {
-		if ctx != nil {
-			r.contextPool.Put(ctx)
-		}
 		sErr := recover()
-		if sErr != nil && r.errorHandle != nil {
-			r.errorBottomUp(ctx, sErr)
+		if sErr != nil {
+			// panics of middlewares and handles (including websocket handles) are recovered here
+			ctx.stack = debug.Stack()
+			ctx.discardBuffer()
+			r.recovery(ctx, sErr)
+			if ctx.WebsocketConn != nil {
+				_ = ctx.WebsocketConn.CloseWithCode(CloseInternalServerError, "internal server error")
+			}
 		}
+		r.commitResponse(ctx)
+		// the context can only be reused after the error handle is completed
+		r.contextPool.Put(ctx)
 	}()
 
 	if err != nil {
 		panic(err)
 	}
 
-	if sse {
-		res.Header().Set("Content-Type", "text/event-stream")
+	if rt != nil && rt.kind == routeKindSSE {
+		res.Header().Set("Content-Type", MediaTypeEventStream)
 		res.Header().Set("Cache-Control", "no-cache")
 		res.Header().Set("Connection", "keep-alive")
 		res.Header().Set("Access-Control-Allow-Origin", "*")
@@ -48,6 +61,10 @@ func (r *Router) handle(route string, handle Handle, res http.ResponseWriter, re
 		ctx.Flusher = flusher
 	}
 
+	if r.responseBuffer.Enabled {
+		ctx.BufferResponse()
+	}
+
 	// middleware execution
 	ctx.handles = append(ctx.handles, handle)
 	for ctx.index < len(ctx.handles) {
@@ -56,7 +73,7 @@ func (r *Router) handle(route string, handle Handle, res http.ResponseWriter, re
 	}
 
 	// if a websocket connection exists, the websocket connection is automatically closed when the function returns
-	if ws != nil {
+	if ctx.WebsocketConn != nil {
 		err = ctx.Close()
 		if err != nil {
 			panic(err)
@@ -64,7 +81,7 @@ func (r *Router) handle(route string, handle Handle, res http.ResponseWriter, re
 	}
 }
 
-func (r *Router) easyHandle(easyHandle any) Handle {
+func (r *Router) easyHandle(easyHandle any, plugins *routePlugins) Handle {
 	return func(ctx *Context) {
 		// verify
 		if r.requestHandle == nil {
@@ -73,30 +90,81 @@ func (r *Router) easyHandle(easyHandle any) Handle {
 		if r.responseHandle == nil {
 			panic(errors.New("response handle is empty"))
 		}
+		requestHandle, responseChain := r.chains(plugins)
+		// the response is not serialized if the client has disconnected
+		responseHandle := func(ctx *Context, result any, err error) {
+			if ctx.Err() != nil {
+				ctx.Logger.Debug("response is skipped: "+ctx.Err().Error(), slog.String("route", ctx.Route))
+				return
+			}
+			// the handle has written the response itself, the error cannot be written after it
+			if ctx.Written() {
+				if err != nil {
+					ctx.Logger.Warn("response is already written, the error is not written: "+err.Error(), slog.String("route", ctx.Route))
+				}
+				return
+			}
+			responseChain(ctx, result, err)
+		}
 		// reflection gets the type of function
 		funcType := reflect.TypeOf(easyHandle)
 
 		// create a slice of the parameter value
-		var paramValues []reflect.Value
+		// the parameters are *Context, context.Context (the context itself) and the request object (at most one)
+		if funcType.NumIn() > 2 {
+			panic(errors.New("handle input parameters does not match"))
+		}
+		paramValues := make([]reflect.Value, funcType.NumIn())
 
 		var reqObj any = nil
-		// if there is no second parameter, there is no auto-binding
-		if funcType.NumIn() == 1 {
-			paramValues = make([]reflect.Value, 1)
-			paramValues[0] = reflect.ValueOf(ctx).Elem().Addr()
-		} else if funcType.NumIn() == 2 {
-			paramValues = make([]reflect.Value, 2)
-			paramValues[0] = reflect.ValueOf(ctx).Elem().Addr()
-			paramValues[1] = reflect.New(funcType.In(1)).Elem()
-			reqObj = paramValues[1].Addr().Interface()
-		} else {
-			panic(errors.New("handle input parameters does not match"))
+		for i := range paramValues {
+			if isContextParam(funcType.In(i)) {
+				paramValues[i] = reflect.ValueOf(ctx)
+				continue
+			}
+			if reqObj != nil {
+				panic(errors.New("handle input parameters does not match"))
+			}
+			// the pointer parameter (e.g. *Req) is allocated and bound itself, so the binding hooks and the validator get the *Req
+			if funcType.In(i).Kind() == reflect.Pointer {
+				paramValues[i] = reflect.New(funcType.In(i).Elem())
+				reqObj = paramValues[i].Interface()
+				continue
+			}
+			paramValues[i] = reflect.New(funcType.In(i)).Elem()
+			reqObj = paramValues[i].Addr().Interface()
 		}
 
 		if reqObj != nil {
-			err := r.requestHandle(ctx, reqObj)
+			if d, ok := reqObj.(Defaulter); ok {
+				d.Default()
+			}
+			if b, ok := reqObj.(BeforeBinder); ok {
+				err := b.BeforeBind(ctx)
+				if err != nil {
+					responseHandle(ctx, nil, err)
+					return
+				}
+			}
+			err := requestHandle(ctx, reqObj)
 			if err != nil {
-				r.responseHandle(ctx, nil, err)
+				responseHandle(ctx, nil, err)
+				return
+			}
+			if b, ok := reqObj.(AfterBinder); ok {
+				err = b.AfterBind(ctx)
+				if err != nil {
+					responseHandle(ctx, nil, err)
+					return
+				}
+			}
+			// validate the request object before the handle is invoked
+			if r.validator != nil {
+				err = r.validator.Validate(reqObj)
+				if err != nil {
+					responseHandle(ctx, nil, err)
+					return
+				}
 			}
 		}
 
@@ -105,7 +173,7 @@ func (r *Router) easyHandle(easyHandle any) Handle {
 
 		// no object return, no error return
 		if len(returnValues) == 0 {
-			r.responseHandle(ctx, nil, nil)
+			responseHandle(ctx, nil, nil)
 			return
 		}
 
@@ -119,7 +187,7 @@ func (r *Router) easyHandle(easyHandle any) Handle {
 			// if first return value is error
 			if isErr {
 				// return error
-				r.responseHandle(ctx, nil, firstValue)
+				responseHandle(ctx, nil, firstValue)
 				return
 			}
 		}
@@ -135,13 +203,50 @@ func (r *Router) easyHandle(easyHandle any) Handle {
 
 		// just result return
 		if len(returnValues) == 1 {
-			r.responseHandle(ctx, resultValue, nil)
+			responseHandle(ctx, resultValue, nil)
 			return
 		}
 
 		// has result return and error return
 		errValue, _ := returnValues[1].Interface().(error)
-		r.responseHandle(ctx, resultValue, errValue)
+		responseHandle(ctx, resultValue, errValue)
+	}
+}
+
+var (
+	contextPtrType = reflect.TypeOf((*Context)(nil))
+	stdContextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+// isContextParam whether the parameter of the easy handle is *Context or context.Context
+func isContextParam(t reflect.Type) bool {
+	return t == contextPtrType || t == stdContextType
+}
+
+// easyRequestType the request object type of the easy handle, nil if there is none
+func easyRequestType(funcType reflect.Type) reflect.Type {
+	for i := 0; i < funcType.NumIn(); i++ {
+		if !isContextParam(funcType.In(i)) {
+			return funcType.In(i)
+		}
+	}
+	return nil
+}
+
+func (r *Router) recovery(ctx *Context, err any) {
+	if r.recoveryHook != nil {
+		func() {
+			defer func() {
+				hErr := recover()
+				if hErr != nil {
+					r.logger.Error(fmt.Sprintf("recovery hook error: %s", hErr))
+				}
+			}()
+			r.recoveryHook(ctx, err, ctx.stack)
+		}()
+	}
+	if r.errorHandle != nil {
+		r.errorBottomUp(ctx, err)
 	}
 }
 
@@ -0,0 +1,184 @@
+package easierweb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthChecker check a dependency of the service, e.g. db.PingContext
+type HealthChecker func(ctx context.Context) error
+
+type HealthOptions struct {
+	// liveness path, default "/healthz"
+	LivenessPath string
+	// readiness path, default "/readyz"
+	ReadinessPath string
+	// default timeout of each checker, default 5 seconds
+	Timeout time.Duration
+	// time to wait after the readiness is failing before Close shuts down the servers, so that the load balancers can drain the traffic
+	ShutdownDelay time.Duration
+	// route-level middlewares of the endpoints
+	Middlewares []Handle
+}
+
+// Health the liveness and readiness state of the service
+type Health struct {
+	mu           sync.Mutex
+	checkers     []healthChecker
+	timeout      time.Duration
+	delay        time.Duration
+	shuttingDown atomic.Bool
+}
+
+type healthChecker struct {
+	name    string
+	timeout time.Duration
+	check   HealthChecker
+}
+
+type HealthResult struct {
+	Status string                       `json:"status"`
+	Checks map[string]HealthCheckResult `json:"checks,omitempty"`
+}
+
+type HealthCheckResult struct {
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	Latency string `json:"latency"`
+}
+
+const (
+	HealthStatusOK           = "ok"
+	HealthStatusFail         = "fail"
+	HealthStatusShuttingDown = "shutting_down"
+)
+
+// EnableHealth register the liveness (always ok while the process is serving) and readiness (all checkers pass) endpoints
+// the readiness is failing once Close is called
+func (r *Router) EnableHealth(opts ...HealthOptions) *Router {
+	opt := HealthOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.LivenessPath == "" {
+		opt.LivenessPath = "/healthz"
+	}
+	if opt.ReadinessPath == "" {
+		opt.ReadinessPath = "/readyz"
+	}
+	if opt.Timeout <= 0 {
+		opt.Timeout = 5 * time.Second
+	}
+	if r.health == nil {
+		r.health = &Health{}
+	}
+	r.health.timeout = opt.Timeout
+	r.health.delay = opt.ShutdownDelay
+	r.GET(opt.LivenessPath, func(ctx *Context) {
+		ctx.WriteJSON(http.StatusOK, HealthResult{Status: HealthStatusOK})
+	}, opt.Middlewares...)
+	return r.GET(opt.ReadinessPath, func(ctx *Context) {
+		result := r.health.Check(ctx.Request.Context())
+		code := http.StatusOK
+		if result.Status != HealthStatusOK {
+			code = http.StatusServiceUnavailable
+		}
+		ctx.WriteJSON(code, result)
+	}, opt.Middlewares...)
+}
+
+// Health get the health state, returns nil if the health is not enabled
+func (r *Router) Health() *Health {
+	return r.health
+}
+
+// AddChecker add a named checker of the readiness, zero timeout means the HealthOptions.Timeout
+func (h *Health) AddChecker(name string, timeout time.Duration, checker HealthChecker) *Health {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkers = append(h.checkers, healthChecker{name: name, timeout: timeout, check: checker})
+	return h
+}
+
+// Check run all checkers concurrently
+func (h *Health) Check(ctx context.Context) HealthResult {
+	h.mu.Lock()
+	checkers := append([]healthChecker{}, h.checkers...)
+	h.mu.Unlock()
+	result := HealthResult{Status: HealthStatusOK, Checks: make(map[string]HealthCheckResult, len(checkers))}
+	if h.shuttingDown.Load() {
+		result.Status = HealthStatusShuttingDown
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checkers {
+		c := c
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := h.run(ctx, c)
+			mu.Lock()
+			defer mu.Unlock()
+			result.Checks[c.name] = res
+			if res.Status != HealthStatusOK && result.Status == HealthStatusOK {
+				result.Status = HealthStatusFail
+			}
+		}()
+	}
+	wg.Wait()
+	return result
+}
+
+// run the checker with the timeout, a checker that ignores the context is abandoned after the timeout
+func (h *Health) run(ctx context.Context, c healthChecker) HealthCheckResult {
+	timeout := c.timeout
+	if timeout <= 0 {
+		timeout = h.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if sErr := recover(); sErr != nil {
+				done <- fmt.Errorf("panic: %v", sErr)
+			}
+		}()
+		done <- c.check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	res := HealthCheckResult{Status: HealthStatusOK, Latency: time.Since(start).String()}
+	if err != nil {
+		res.Status = HealthStatusFail
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// ShuttingDown whether the readiness is failing because of the shutdown
+func (h *Health) ShuttingDown() bool {
+	return h.shuttingDown.Load()
+}
+
+// shutdown flip the readiness to failing, and wait for the ShutdownDelay
+func (h *Health) shutdown(ctx context.Context) {
+	if h.shuttingDown.Swap(true) || h.delay <= 0 {
+		return
+	}
+	timer := time.NewTimer(h.delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,187 @@
+package easierweb
+
+import (
+	"errors"
+	"sync"
+)
+
+type HubOptions struct {
+	// called after the connection joined the room
+	OnJoin func(room string, conn *WSConn)
+	// called after the connection left the room (including the connection is closed)
+	OnLeave func(room string, conn *WSConn)
+}
+
+// Hub websocket connection registry grouped by room, connections leave all rooms automatically when closed
+type Hub struct {
+	mu      sync.RWMutex
+	rooms   map[string]map[*WSConn]struct{}
+	members map[*WSConn]map[string]struct{}
+	onJoin  func(room string, conn *WSConn)
+	onLeave func(room string, conn *WSConn)
+}
+
+func NewHub(opts ...HubOptions) *Hub {
+	h := &Hub{
+		rooms:   make(map[string]map[*WSConn]struct{}),
+		members: make(map[*WSConn]map[string]struct{}),
+	}
+	for _, v := range opts {
+		if v.OnJoin != nil {
+			h.onJoin = v.OnJoin
+		}
+		if v.OnLeave != nil {
+			h.onLeave = v.OnLeave
+		}
+	}
+	return h
+}
+
+// Join add the connection to the room, the closed connection is not added
+func (h *Hub) Join(room string, conn *WSConn) {
+	if conn.isClosed() {
+		return
+	}
+	h.mu.Lock()
+	rooms, ok := h.members[conn]
+	if !ok {
+		rooms = make(map[string]struct{})
+		h.members[conn] = rooms
+	}
+	if _, joined := rooms[room]; joined {
+		h.mu.Unlock()
+		return
+	}
+	rooms[room] = struct{}{}
+	if h.rooms[room] == nil {
+		h.rooms[room] = make(map[*WSConn]struct{})
+	}
+	h.rooms[room][conn] = struct{}{}
+	h.mu.Unlock()
+	if h.onJoin != nil {
+		h.onJoin(room, conn)
+	}
+	if !ok {
+		// registered outside the lock, it is called immediately if the connection is closed after the check
+		conn.OnClose(func() {
+			h.LeaveAll(conn)
+		})
+	}
+}
+
+// Leave remove the connection from the room, the connection is forgotten by the hub if it is not in any room
+func (h *Hub) Leave(room string, conn *WSConn) {
+	h.mu.Lock()
+	left := h.remove(room, conn)
+	if len(h.members[conn]) == 0 {
+		delete(h.members, conn)
+	}
+	h.mu.Unlock()
+	if left && h.onLeave != nil {
+		h.onLeave(room, conn)
+	}
+}
+
+// LeaveAll remove the connection from all rooms
+func (h *Hub) LeaveAll(conn *WSConn) {
+	h.mu.Lock()
+	var left []string
+	for room := range h.members[conn] {
+		if h.remove(room, conn) {
+			left = append(left, room)
+		}
+	}
+	delete(h.members, conn)
+	h.mu.Unlock()
+	if h.onLeave != nil {
+		for _, room := range left {
+			h.onLeave(room, conn)
+		}
+	}
+}
+
+// Broadcast send the text message to all connections in the room
+// connections that fail to write are closed and removed from the hub
+func (h *Hub) Broadcast(room string, msg []byte) error {
+	return h.broadcast(room, TextMessage, msg)
+}
+
+func (h *Hub) BroadcastBinary(room string, msg []byte) error {
+	return h.broadcast(room, BinaryMessage, msg)
+}
+
+// BroadcastJSON send the json message to all connections in the room, it is marshaled by the RouterOptions.JSONCodec of the router
+func (h *Hub) BroadcastJSON(room string, obj any) error {
+	conns := h.Conns(room)
+	if len(conns) == 0 {
+		return nil
+	}
+	marshal, err := conns[0].jsonCodec.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return h.write(conns, TextMessage, marshal)
+}
+
+func (h *Hub) Conns(room string) []*WSConn {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	conns := make([]*WSConn, 0, len(h.rooms[room]))
+	for conn := range h.rooms[room] {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+func (h *Hub) Count(room string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.rooms[room])
+}
+
+func (h *Hub) Rooms() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	rooms := make([]string, 0, len(h.rooms))
+	for room := range h.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+func (h *Hub) broadcast(room string, messageType int, msg []byte) error {
+	return h.write(h.Conns(room), messageType, msg)
+}
+
+// write the message to the connections, the connections that fail to write are closed
+func (h *Hub) write(conns []*WSConn, messageType int, msg []byte) error {
+	var errs []error
+	// write outside the lock, a slow connection does not block join and leave
+	for _, conn := range conns {
+		err := conn.WriteMessage(messageType, msg)
+		if err != nil {
+			errs = append(errs, err)
+			_ = conn.Close()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// remove must be called with the lock held
+func (h *Hub) remove(room string, conn *WSConn) bool {
+	conns, ok := h.rooms[room]
+	if !ok {
+		return false
+	}
+	if _, ok = conns[conn]; !ok {
+		return false
+	}
+	delete(conns, conn)
+	if len(conns) == 0 {
+		delete(h.rooms, room)
+	}
+	if rooms, ok := h.members[conn]; ok {
+		delete(rooms, room)
+	}
+	return true
+}
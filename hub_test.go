@@ -0,0 +1,210 @@
+package easierweb
+
+import (
+	"fmt"
+	"github.com/gorilla/websocket"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hub test
+
+func TestHub(t *testing.T) {
+
+	fmt.Println("\n[TestHub] start")
+
+	hub := NewHub(HubOptions{
+		OnJoin: func(room string, conn *WSConn) {
+			fmt.Println("[TestHub](OnJoin) room ->", room)
+		},
+		OnLeave: func(room string, conn *WSConn) {
+			fmt.Println("[TestHub](OnLeave) room ->", room)
+		},
+	})
+
+	router := New()
+	router.WS("/ws/:room", func(ctx *Context) {
+		hub.Join(ctx.Path.Get("room"), ctx.WebsocketConn)
+		for {
+			msg, err := ctx.Receive()
+			if err != nil {
+				return
+			}
+			err = hub.Broadcast(ctx.Path.Get("room"), msg)
+			if err != nil {
+				fmt.Println("[TestHub] broadcast error ->", err)
+			}
+		}
+	})
+	server := httptest.NewServer(router.router)
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/test"
+	client1, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client2, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(client2 *websocket.Conn) {
+		_ = client2.Close()
+	}(client2)
+
+	// wait for both connections to join the room
+	hubTestWait(func() bool { return hub.Count("test") == 2 })
+	if hub.Count("test") != 2 {
+		t.Fatal("the number of connections in the room does not match")
+	}
+
+	err = client1.WriteMessage(websocket.TextMessage, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, client := range []*websocket.Conn{client1, client2} {
+		_, msg, err := client.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Println("[TestHub] client read broadcast msg ->", string(msg))
+		if string(msg) != "hello" {
+			t.Fatal("broadcast message does not match")
+		}
+	}
+
+	// the closed connection leaves the room automatically
+	_ = client1.Close()
+	hubTestWait(func() bool { return hub.Count("test") == 1 })
+	if hub.Count("test") != 1 {
+		t.Fatal("the closed connection is not removed")
+	}
+
+	fmt.Println("\n[TestHub] end")
+}
+
+func hubTestWait(cond func() bool) {
+	for i := 0; i < 100 && !cond(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// hub closed connection test
+
+func TestHubJoinClosed(t *testing.T) {
+
+	fmt.Println("\n[TestHubJoinClosed] start")
+
+	hub := NewHub()
+	result := make(chan [2]int, 1)
+	router := New()
+	router.WS("/ws", func(ctx *Context) {
+		_ = ctx.WebsocketConn.Close()
+		hub.Join("test", ctx.WebsocketConn)
+		called := 0
+		ctx.WebsocketConn.OnClose(func() {
+			called++
+		})
+		result <- [2]int{hub.Count("test"), called}
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(client *websocket.Conn) {
+		_ = client.Close()
+	}(client)
+
+	r := <-result
+	fmt.Println("[TestHubJoinClosed] count, close hook calls ->", r)
+	if r[0] != 0 {
+		t.Fatal("the closed connection is added to the room")
+	}
+	if r[1] != 1 {
+		t.Fatal("the close hook of the closed connection is not called")
+	}
+
+	fmt.Println("\n[TestHubJoinClosed] end")
+}
+
+// hub leave and json broadcast test
+
+type hubTestCodec struct{}
+
+func (hubTestCodec) Marshal(v any) ([]byte, error) {
+	return []byte(`{"codec":"custom"}`), nil
+}
+
+func (hubTestCodec) Unmarshal(data []byte, v any) error {
+	return nil
+}
+
+func TestHubLeave(t *testing.T) {
+
+	fmt.Println("\n[TestHubLeave] start")
+
+	hub := NewHub()
+	result := make(chan int, 1)
+	router := New(RouterOptions{CloseConsolePrint: true, JSONCodec: hubTestCodec{}})
+	router.WS("/ws", func(ctx *Context) {
+		hub.Join("a", ctx.WebsocketConn)
+		hub.Join("b", ctx.WebsocketConn)
+		hub.Leave("a", ctx.WebsocketConn)
+		hub.mu.RLock()
+		rooms := len(hub.members[ctx.WebsocketConn])
+		hub.mu.RUnlock()
+		if rooms != 1 {
+			result <- -1
+			return
+		}
+		// the connection is forgotten after it left the last room
+		hub.Leave("b", ctx.WebsocketConn)
+		hub.mu.RLock()
+		_, ok := hub.members[ctx.WebsocketConn]
+		hub.mu.RUnlock()
+		if ok {
+			result <- -2
+			return
+		}
+		hub.Join("json", ctx.WebsocketConn)
+		result <- len(hub.Rooms())
+		_, _ = ctx.Receive()
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(client *websocket.Conn) {
+		_ = client.Close()
+	}(client)
+
+	r := <-result
+	fmt.Println("[TestHubLeave] rooms ->", r)
+	if r != 1 {
+		t.Fatal("the rooms of the connection do not match")
+	}
+
+	// the message is marshaled by the json codec of the router
+	err = hub.BroadcastJSON("json", map[string]string{"codec": "std"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, msg, err := client.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println("[TestHubLeave] json message ->", string(msg))
+	if string(msg) != `{"codec":"custom"}` {
+		t.Fatal("json message does not match")
+	}
+
+	fmt.Println("\n[TestHubLeave] end")
+}
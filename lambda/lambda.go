@@ -0,0 +1,252 @@
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	awslambda "github.com/aws/aws-lambda-go/lambda"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Start run the router (or any http.Handler) as a lambda function, instead of Run
+// the API Gateway REST API (v1), HTTP API (v2) and ALB events are supported
+// e.g. lambda.Start(router)
+func Start(handler http.Handler) {
+	awslambda.Start(Handler(handler))
+}
+
+// Handler convert the http.Handler to a lambda handler, the event type is detected by the payload
+func Handler(handler http.Handler) func(ctx context.Context, event json.RawMessage) (any, error) {
+	return func(ctx context.Context, event json.RawMessage) (any, error) {
+		probe := struct {
+			Version        string `json:"version"`
+			HTTPMethod     string `json:"httpMethod"`
+			RequestContext struct {
+				ELB  json.RawMessage `json:"elb"`
+				HTTP json.RawMessage `json:"http"`
+			} `json:"requestContext"`
+		}{}
+		err := json.Unmarshal(event, &probe)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case probe.Version == "2.0" || len(probe.RequestContext.HTTP) > 0:
+			req := events.APIGatewayV2HTTPRequest{}
+			err = json.Unmarshal(event, &req)
+			if err != nil {
+				return nil, err
+			}
+			return serveV2(ctx, handler, req)
+		case len(probe.RequestContext.ELB) > 0:
+			req := events.ALBTargetGroupRequest{}
+			err = json.Unmarshal(event, &req)
+			if err != nil {
+				return nil, err
+			}
+			return serveALB(ctx, handler, req)
+		case probe.HTTPMethod != "":
+			req := events.APIGatewayProxyRequest{}
+			err = json.Unmarshal(event, &req)
+			if err != nil {
+				return nil, err
+			}
+			return serveV1(ctx, handler, req)
+		}
+		return nil, errors.New("unsupported lambda event")
+	}
+}
+
+func serveV1(ctx context.Context, handler http.Handler, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	query := url.Values{}
+	for k, v := range event.QueryStringParameters {
+		query.Set(k, v)
+	}
+	for k, v := range event.MultiValueQueryStringParameters {
+		query[k] = v
+	}
+	req, err := newRequest(ctx, event.HTTPMethod, event.Path, query.Encode(), event.Body, event.IsBase64Encoded)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	setHeaders(req, event.Headers, event.MultiValueHeaders)
+	setRemoteAddr(req, event.RequestContext.Identity.SourceIP)
+
+	rec := serve(handler, req)
+	body, isBase64 := encodeBody(rec)
+	return events.APIGatewayProxyResponse{
+		StatusCode:        rec.code,
+		MultiValueHeaders: rec.header,
+		Body:              body,
+		IsBase64Encoded:   isBase64,
+	}, nil
+}
+
+func serveV2(ctx context.Context, handler http.Handler, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	req, err := newRequest(ctx, event.RequestContext.HTTP.Method, event.RawPath, event.RawQueryString, event.Body, event.IsBase64Encoded)
+	if err != nil {
+		return events.APIGatewayV2HTTPResponse{}, err
+	}
+	setHeaders(req, event.Headers, nil)
+	if len(event.Cookies) > 0 {
+		req.Header.Set("Cookie", strings.Join(event.Cookies, "; "))
+	}
+	setRemoteAddr(req, event.RequestContext.HTTP.SourceIP)
+
+	rec := serve(handler, req)
+	body, isBase64 := encodeBody(rec)
+	res := events.APIGatewayV2HTTPResponse{
+		StatusCode:      rec.code,
+		Headers:         make(map[string]string, len(rec.header)),
+		Body:            body,
+		IsBase64Encoded: isBase64,
+		Cookies:         rec.header.Values("Set-Cookie"),
+	}
+	for k, v := range rec.header {
+		if k != "Set-Cookie" {
+			res.Headers[k] = strings.Join(v, ",")
+		}
+	}
+	return res, nil
+}
+
+func serveALB(ctx context.Context, handler http.Handler, event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	// the query parameters are not decoded by the load balancer
+	var query []string
+	for k, v := range event.QueryStringParameters {
+		query = append(query, k+"="+v)
+	}
+	for k, values := range event.MultiValueQueryStringParameters {
+		for _, v := range values {
+			query = append(query, k+"="+v)
+		}
+	}
+	req, err := newRequest(ctx, event.HTTPMethod, event.Path, strings.Join(query, "&"), event.Body, event.IsBase64Encoded)
+	if err != nil {
+		return events.ALBTargetGroupResponse{}, err
+	}
+	setHeaders(req, event.Headers, event.MultiValueHeaders)
+	if forwarded := req.Header.Get("X-Forwarded-For"); forwarded != "" {
+		setRemoteAddr(req, strings.TrimSpace(strings.Split(forwarded, ",")[0]))
+	}
+
+	rec := serve(handler, req)
+	body, isBase64 := encodeBody(rec)
+	res := events.ALBTargetGroupResponse{
+		StatusCode:        rec.code,
+		StatusDescription: fmt.Sprintf("%d %s", rec.code, http.StatusText(rec.code)),
+		Body:              body,
+		IsBase64Encoded:   isBase64,
+	}
+	// the multi-value headers must be used in the response if they are enabled on the target group
+	if event.MultiValueHeaders != nil {
+		res.MultiValueHeaders = rec.header
+	} else {
+		res.Headers = make(map[string]string, len(rec.header))
+		for k, v := range rec.header {
+			res.Headers[k] = strings.Join(v, ",")
+		}
+	}
+	return res, nil
+}
+
+func newRequest(ctx context.Context, method, path, rawQuery, body string, isBase64 bool) (*http.Request, error) {
+	data := []byte(body)
+	if isBase64 {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return nil, err
+		}
+		data = decoded
+	}
+	target := path
+	if rawQuery != "" {
+		target += "?" + rawQuery
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.RequestURI = target
+	return req, nil
+}
+
+func setHeaders(req *http.Request, headers map[string]string, multiValueHeaders map[string][]string) {
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	for k, values := range multiValueHeaders {
+		req.Header.Del(k)
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Host = req.Header.Get("Host")
+}
+
+func setRemoteAddr(req *http.Request, ip string) {
+	if ip != "" {
+		req.RemoteAddr = net.JoinHostPort(ip, "0")
+	}
+}
+
+// responseRecorder the response is returned as the lambda result, so it is buffered
+type responseRecorder struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	return r.body.Write(data)
+}
+
+func serve(handler http.Handler, req *http.Request) *responseRecorder {
+	rec := &responseRecorder{header: make(http.Header)}
+	handler.ServeHTTP(rec, req)
+	if rec.code == 0 {
+		rec.code = http.StatusOK
+	}
+	if rec.header.Get("Content-Type") == "" && rec.body.Len() > 0 {
+		rec.header.Set("Content-Type", http.DetectContentType(rec.body.Bytes()))
+	}
+	return rec
+}
+
+var textContentTypes = []string{"text/", "application/json", "application/problem+json", "application/xml", "application/yaml", "application/x-yaml", "application/javascript", "application/x-www-form-urlencoded", "image/svg+xml"}
+
+// encodeBody the binary (and compressed) bodies are encoded as base64
+func encodeBody(rec *responseRecorder) (string, bool) {
+	if rec.header.Get("Content-Encoding") == "" {
+		contentType := strings.ToLower(rec.header.Get("Content-Type"))
+		for _, t := range textContentTypes {
+			if strings.HasPrefix(contentType, t) {
+				return rec.body.String(), false
+			}
+		}
+		if rec.body.Len() == 0 {
+			return "", false
+		}
+	}
+	return base64.StdEncoding.EncodeToString(rec.body.Bytes()), true
+}
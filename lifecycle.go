@@ -0,0 +1,53 @@
+package easierweb
+
+import (
+	"context"
+	"fmt"
+)
+
+// OnStart add the hook that is called before the server starts listening (by Run, RunTLS, etc.), e.g. warm up the caches
+func (r *Router) OnStart(hook func()) *Router {
+	r.onStart = append(r.onStart, hook)
+	return r
+}
+
+// OnPreShutdown add the hook that is called by Close before the servers stop accepting and drain the requests, e.g. deregister from the service discovery,
+// so the new traffic is not routed to the closing instance, the hooks are called in the reverse order of adding (like defer)
+func (r *Router) OnPreShutdown(hook func(ctx context.Context)) *Router {
+	r.onPreShutdown = append(r.onPreShutdown, hook)
+	return r
+}
+
+// OnShutdown add the hook that is called by Close after the in-flight requests are completed, e.g. close the database pools and flush the telemetry
+// the hooks are called in the reverse order of adding (like defer), the context is canceled when the ShutdownTimeout is exceeded
+func (r *Router) OnShutdown(hook func(ctx context.Context)) *Router {
+	r.onShutdown = append(r.onShutdown, hook)
+	return r
+}
+
+// OnRouteRegistered add the hook that is called when a route is registered after it, e.g. register the permissions of the routes
+// the name of the route is set by Name after the registration, so it is empty in the RouteInfo
+func (r *Router) OnRouteRegistered(hook func(info RouteInfo)) *Router {
+	r.onRouteRegistered = append(r.onRouteRegistered, hook)
+	return r
+}
+
+func (r *Router) startHooks() {
+	for _, hook := range r.onStart {
+		hook()
+	}
+}
+
+func (r *Router) shutdownHooks(ctx context.Context, hooks []func(ctx context.Context)) {
+	for i := len(hooks) - 1; i >= 0; i-- {
+		func() {
+			defer func() {
+				err := recover()
+				if err != nil {
+					r.logger.Error(fmt.Sprintf("shutdown hook error: %s", err))
+				}
+			}()
+			hooks[i](ctx)
+		}()
+	}
+}
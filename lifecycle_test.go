@@ -0,0 +1,71 @@
+package easierweb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// lifecycle hooks test
+
+func TestShutdownHooks(t *testing.T) {
+
+	fmt.Println("\n[TestShutdownHooks] start")
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := "http://" + l.Addr().String() + "/ping"
+	var trace []string
+	router := New(RouterOptions{CloseConsolePrint: true})
+	router.GET("/ping", func(ctx *Context) {
+		ctx.WriteString(http.StatusOK, "pong")
+	})
+	router.OnShutdown(func(ctx context.Context) {
+		trace = append(trace, "shutdown")
+	})
+	router.OnPreShutdown(func(ctx context.Context) {
+		trace = append(trace, "pre-shutdown 1")
+	})
+	// the server still accepts the requests when the pre-shutdown hooks are called
+	router.OnPreShutdown(func(ctx context.Context) {
+		res, err := http.Get(url)
+		if err != nil {
+			trace = append(trace, "pre-shutdown 2 "+err.Error())
+			return
+		}
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		trace = append(trace, "pre-shutdown 2 "+string(body))
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- router.RunListener(l)
+	}()
+	for i := 0; i < 100; i++ {
+		if res, err := http.Get(url); err == nil {
+			_ = res.Body.Close()
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err = router.Close(); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	result := strings.Join(trace, ", ")
+	fmt.Println("[TestShutdownHooks] result ->", result)
+	if result != "pre-shutdown 2 pong, pre-shutdown 1, shutdown" {
+		t.Fatal("shutdown hooks do not match")
+	}
+
+	fmt.Println("\n[TestShutdownHooks] end")
+}
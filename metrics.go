@@ -0,0 +1,255 @@
+package easierweb
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	defaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	defaultSizeBuckets    = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+// Metrics collect the http metrics and render them in the prometheus text format
+// the route pattern (not the raw path) is used as the label
+type Metrics struct {
+	mu       sync.Mutex
+	requests map[metricsKey]*requestMetrics
+	inFlight map[metricsKey]int64
+	gauges   map[string]*gaugeMetrics
+}
+
+type metricsKey struct {
+	route  string
+	method string
+	status int
+}
+
+type requestMetrics struct {
+	count   uint64
+	latency *histogram
+	size    *histogram
+}
+
+type gaugeMetrics struct {
+	help   string
+	values map[string]float64
+}
+
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range h.buckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests: make(map[metricsKey]*requestMetrics),
+		inFlight: make(map[metricsKey]int64),
+		gauges:   make(map[string]*gaugeMetrics),
+	}
+}
+
+// EnableMetrics record the metrics of all routes and expose them on the path
+func (r *Router) EnableMetrics(path string, middlewares ...Handle) *Router {
+	if r.metrics == nil {
+		r.metrics = NewMetrics()
+		// the metrics middleware is executed first, so that the time cost of other middlewares is included
+		r.middlewares = append([]Handle{r.metrics.Middleware()}, r.middlewares...)
+	}
+	return r.GET(path, func(ctx *Context) {
+		ctx.SetContentType("text/plain; version=0.0.4; charset=utf-8")
+		ctx.Write(http.StatusOK, []byte(r.metrics.String()))
+	}, middlewares...)
+}
+
+// Metrics get the metrics collector, returns nil if the metrics is not enabled
+func (r *Router) Metrics() *Metrics {
+	return r.metrics
+}
+
+func (m *Metrics) Middleware() Handle {
+	return func(ctx *Context) {
+		start := time.Now()
+		key := metricsKey{route: ctx.Route, method: ctx.Request.Method}
+		m.mu.Lock()
+		m.inFlight[key]++
+		m.mu.Unlock()
+		defer func() {
+			err := recover()
+			code := ctx.Code
+			if err != nil {
+				code = http.StatusInternalServerError
+			} else if code == 0 {
+				code = http.StatusOK
+			}
+			m.observe(key, code, time.Since(start), len(ctx.Result))
+			if err != nil {
+				panic(err)
+			}
+		}()
+		ctx.Next()
+	}
+}
+
+// SetGauge set the value of a custom gauge, e.g. m.SetGauge("circuit_state", "state of the circuit", 1, "name", "upstream")
+func (m *Metrics) SetGauge(name, help string, value float64, labels ...string) {
+	var b strings.Builder
+	for i := 0; i+1 < len(labels); i += 2 {
+		if b.Len() > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(fmt.Sprintf("%s=\"%s\"", labels[i], escapeLabel(labels[i+1])))
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	g, ok := m.gauges[name]
+	if !ok {
+		g = &gaugeMetrics{help: help, values: make(map[string]float64)}
+		m.gauges[name] = g
+	}
+	g.values[b.String()] = value
+}
+
+func (m *Metrics) observe(key metricsKey, code int, cost time.Duration, size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight[key]--
+	key.status = code
+	rm, ok := m.requests[key]
+	if !ok {
+		rm = &requestMetrics{
+			latency: newHistogram(defaultLatencyBuckets),
+			size:    newHistogram(defaultSizeBuckets),
+		}
+		m.requests[key] = rm
+	}
+	rm.count++
+	rm.latency.observe(cost.Seconds())
+	rm.size.observe(float64(size))
+}
+
+// String render the metrics in the prometheus text format
+func (m *Metrics) String() string {
+	var b strings.Builder
+	m.Render(&b)
+	return b.String()
+}
+
+// Render write the metrics in the prometheus text format
+func (m *Metrics) Render(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]metricsKey, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sortMetricsKeys(keys)
+
+	fmt.Fprintln(w, "# HELP easierweb_http_requests_total Total number of HTTP requests.")
+	fmt.Fprintln(w, "# TYPE easierweb_http_requests_total counter")
+	for _, k := range keys {
+		fmt.Fprintf(w, "easierweb_http_requests_total{%s} %d\n", k.labels(), m.requests[k].count)
+	}
+
+	fmt.Fprintln(w, "# HELP easierweb_http_request_duration_seconds HTTP request latencies in seconds.")
+	fmt.Fprintln(w, "# TYPE easierweb_http_request_duration_seconds histogram")
+	for _, k := range keys {
+		writeHistogram(w, "easierweb_http_request_duration_seconds", k.labels(), m.requests[k].latency)
+	}
+
+	fmt.Fprintln(w, "# HELP easierweb_http_response_size_bytes HTTP response sizes in bytes.")
+	fmt.Fprintln(w, "# TYPE easierweb_http_response_size_bytes histogram")
+	for _, k := range keys {
+		writeHistogram(w, "easierweb_http_response_size_bytes", k.labels(), m.requests[k].size)
+	}
+
+	inFlightKeys := make([]metricsKey, 0, len(m.inFlight))
+	for k := range m.inFlight {
+		inFlightKeys = append(inFlightKeys, k)
+	}
+	sortMetricsKeys(inFlightKeys)
+	fmt.Fprintln(w, "# HELP easierweb_http_requests_in_flight Number of HTTP requests currently being served.")
+	fmt.Fprintln(w, "# TYPE easierweb_http_requests_in_flight gauge")
+	for _, k := range inFlightKeys {
+		fmt.Fprintf(w, "easierweb_http_requests_in_flight{route=\"%s\",method=\"%s\"} %d\n", escapeLabel(k.route), k.method, m.inFlight[k])
+	}
+
+	names := make([]string, 0, len(m.gauges))
+	for name := range m.gauges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g := m.gauges[name]
+		fmt.Fprintf(w, "# HELP %s %s\n", name, g.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+		labels := make([]string, 0, len(g.values))
+		for l := range g.values {
+			labels = append(labels, l)
+		}
+		sort.Strings(labels)
+		for _, l := range labels {
+			if l == "" {
+				fmt.Fprintf(w, "%s %s\n", name, formatFloat(g.values[l]))
+			} else {
+				fmt.Fprintf(w, "%s{%s} %s\n", name, l, formatFloat(g.values[l]))
+			}
+		}
+	}
+}
+
+func writeHistogram(w io.Writer, name, labels string, h *histogram) {
+	for i, b := range h.buckets {
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatFloat(b), h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.count)
+}
+
+func (k metricsKey) labels() string {
+	return fmt.Sprintf("route=\"%s\",method=\"%s\",status=\"%d\"", escapeLabel(k.route), k.method, k.status)
+}
+
+func sortMetricsKeys(keys []metricsKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].status < keys[j].status
+	})
+}
+
+func escapeLabel(v string) string {
+	return strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n").Replace(v)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,129 @@
+package middlewares
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"github.com/dpwgc/easierweb"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+type AccessLogOptions struct {
+	// default ctx.Logger (RouterOptions.Logger), zap/zerolog can be used through the slog.Handler adapters
+	Logger *slog.Logger
+	// level of the access log, default slog.LevelInfo
+	Level slog.Level
+	// the request paths that are not logged, e.g. /healthz
+	SkipPaths []string
+	// skip the request if it returns true
+	Skip func(ctx *easierweb.Context) bool
+	// the request headers that are logged (as the headers group), the secret ones are redacted by the RouterOptions.Redact rules
+	Headers []string
+}
+
+// AccessLog record the structured access log: method, route, path, status, latency, bytes, client ip and request id
+func AccessLog(opts ...AccessLogOptions) easierweb.Handle {
+	opt := AccessLogOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	skipPaths := make(map[string]bool, len(opt.SkipPaths))
+	for _, v := range opt.SkipPaths {
+		skipPaths[v] = true
+	}
+	return func(ctx *easierweb.Context) {
+		if skipPaths[ctx.Request.URL.Path] || (opt.Skip != nil && opt.Skip(ctx)) {
+			ctx.Next()
+			return
+		}
+		start := time.Now()
+		res := ctx.ResponseWriter
+		cw := &countWriter{ResponseWriter: res}
+		ctx.ResponseWriter = cw
+		defer func() {
+			ctx.ResponseWriter = res
+		}()
+
+		ctx.Next()
+
+		status := cw.status
+		if status == 0 {
+			status = ctx.Code
+		}
+		if status == 0 {
+			status = http.StatusOK
+		}
+		logger := opt.Logger
+		if logger == nil {
+			logger = ctx.Logger
+		}
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Request.Method),
+			slog.String("route", ctx.Route),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.Int64("bytes", cw.bytes),
+			slog.String("client_ip", ctx.ClientIP()),
+			slog.String("request_id", ctx.RequestID()),
+		}
+		if len(opt.Headers) > 0 {
+			attrs = append(attrs, slog.Any("headers", accessLogHeaders(ctx, opt.Headers)))
+		}
+		logger.LogAttrs(context.Background(), opt.Level, "access", attrs...)
+	}
+}
+
+func accessLogHeaders(ctx *easierweb.Context, names []string) map[string]string {
+	headers := make(map[string]string, len(names))
+	for _, name := range names {
+		value := ctx.Request.Header.Get(name)
+		if value == "" {
+			continue
+		}
+		if ctx.Redactor().Header(name) {
+			value = easierweb.Redacted
+		}
+		headers[name] = value
+	}
+	return headers
+}
+
+// countWriter record the status code and the number of bytes written
+type countWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (c *countWriter) WriteHeader(code int) {
+	if c.status == 0 {
+		c.status = code
+	}
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *countWriter) Write(data []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	n, err := c.ResponseWriter.Write(data)
+	c.bytes += int64(n)
+	return n, err
+}
+
+func (c *countWriter) Flush() {
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (c *countWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := c.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("the response writer does not support hijacking")
+}
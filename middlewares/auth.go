@@ -0,0 +1,136 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"strconv"
+)
+
+type BasicAuthOptions struct {
+	// usernames and passwords
+	Users map[string]string
+	// verify the username and password, overrides Users
+	Verifier func(ctx *easierweb.Context, username string, password string) bool
+	// realm of the WWW-Authenticate header, default "Restricted"
+	Realm string
+	// called when the authentication fails, default 401 *easierweb.Error passed to the RouterOptions.ErrorHandle
+	ErrorHandle func(ctx *easierweb.Context, err error)
+}
+
+type APIKeyAuthOptions struct {
+	// header of the key, default "X-API-Key"
+	Header string
+	// query parameter of the key, it is read if the header is absent, e.g. "api_key", default disabled
+	Query string
+	// keys and their principals, e.g. {"k3y": "billing-service"}
+	Keys map[string]string
+	// verify the key and return the principal, overrides Keys
+	Verifier func(ctx *easierweb.Context, key string) (string, bool)
+	// called when the authentication fails, default 401 *easierweb.Error passed to the RouterOptions.ErrorHandle
+	ErrorHandle func(ctx *easierweb.Context, err error)
+}
+
+var (
+	ErrCredentialsMissing = errors.New("missing credentials")
+	ErrCredentialsInvalid = errors.New("invalid credentials")
+)
+
+// BasicAuth authenticate the users by the HTTP basic authentication, the principal (ctx.Principal()) is the username
+func BasicAuth(opts ...BasicAuthOptions) easierweb.Handle {
+	opt := BasicAuthOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Verifier == nil {
+		users := opt.Users
+		opt.Verifier = func(ctx *easierweb.Context, username string, password string) bool {
+			expected, ok := users[username]
+			// compare anyway, so that the unknown usernames take the same time
+			return subtle.ConstantTimeCompare([]byte(expected), []byte(password)) == 1 && ok
+		}
+	}
+	if opt.Realm == "" {
+		opt.Realm = "Restricted"
+	}
+	challenge := "Basic realm=" + strconv.Quote(opt.Realm) + `, charset="UTF-8"`
+	if opt.ErrorHandle == nil {
+		opt.ErrorHandle = func(ctx *easierweb.Context, err error) {
+			ctx.SetHeader("WWW-Authenticate", challenge)
+			ctx.HandleError(unauthorized(err))
+		}
+	}
+	return func(ctx *easierweb.Context) {
+		username, password, ok := ctx.Request.BasicAuth()
+		if !ok {
+			opt.ErrorHandle(ctx, ErrCredentialsMissing)
+			ctx.Abort()
+			return
+		}
+		if !opt.Verifier(ctx, username, password) {
+			opt.ErrorHandle(ctx, ErrCredentialsInvalid)
+			ctx.Abort()
+			return
+		}
+		ctx.SetPrincipal(username)
+		ctx.Next()
+	}
+}
+
+// APIKeyAuth authenticate the clients by the API keys, the principal (ctx.Principal()) is returned by the verifier (or the value of Keys)
+func APIKeyAuth(opts ...APIKeyAuthOptions) easierweb.Handle {
+	opt := APIKeyAuthOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Header == "" {
+		opt.Header = "X-API-Key"
+	}
+	if opt.Verifier == nil {
+		keys := opt.Keys
+		opt.Verifier = func(ctx *easierweb.Context, key string) (string, bool) {
+			// compare all keys in constant time, the map lookup would leak the timing
+			principal, found := "", false
+			for k, v := range keys {
+				if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+					principal, found = v, true
+				}
+			}
+			return principal, found
+		}
+	}
+	if opt.ErrorHandle == nil {
+		opt.ErrorHandle = func(ctx *easierweb.Context, err error) {
+			ctx.HandleError(unauthorized(err))
+		}
+	}
+	return func(ctx *easierweb.Context) {
+		key := ctx.Request.Header.Get(opt.Header)
+		if key == "" && opt.Query != "" {
+			key = ctx.Request.URL.Query().Get(opt.Query)
+		}
+		if key == "" {
+			opt.ErrorHandle(ctx, ErrCredentialsMissing)
+			ctx.Abort()
+			return
+		}
+		principal, ok := opt.Verifier(ctx, key)
+		if !ok {
+			opt.ErrorHandle(ctx, ErrCredentialsInvalid)
+			ctx.Abort()
+			return
+		}
+		ctx.SetPrincipal(principal)
+		ctx.Next()
+	}
+}
+
+func unauthorized(err error) *easierweb.Error {
+	return httpError(http.StatusUnauthorized, "UNAUTHORIZED", err)
+}
+
+// httpError the *easierweb.Error of the rejected request, it is passed to the RouterOptions.ErrorHandle (default problem details)
+func httpError(status int, code string, err error) *easierweb.Error {
+	return easierweb.NewError(status, err.Error()).WithCode(code).Wrap(err)
+}
@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// basic auth and api key auth test
+
+func TestAuth(t *testing.T) {
+
+	fmt.Println("\n[TestAuth] start")
+
+	router := easierweb.New(easierweb.RouterOptions{CloseConsolePrint: true})
+	handle := func(ctx *easierweb.Context) {
+		ctx.WriteString(http.StatusOK, ctx.Principal())
+	}
+	router.GET("/basic", handle, BasicAuth(BasicAuthOptions{Users: map[string]string{"admin": "secret"}}))
+	router.GET("/key", handle, APIKeyAuth(APIKeyAuthOptions{Keys: map[string]string{"key-1": "service-1"}, Query: "api_key"}))
+
+	cases := []struct {
+		name   string
+		path   string
+		header string
+		value  string
+		code   int
+		body   string
+	}{
+		{"basic", "/basic", "Authorization", "Basic YWRtaW46c2VjcmV0", http.StatusOK, "admin"},
+		{"basic missing", "/basic", "", "", http.StatusUnauthorized, `"detail":"` + ErrCredentialsMissing.Error() + `"`},
+		// admin:wrong
+		{"basic invalid", "/basic", "Authorization", "Basic YWRtaW46d3Jvbmc=", http.StatusUnauthorized, `"detail":"` + ErrCredentialsInvalid.Error() + `"`},
+		// other:secret
+		{"basic unknown user", "/basic", "Authorization", "Basic b3RoZXI6c2VjcmV0", http.StatusUnauthorized, `"code":"UNAUTHORIZED"`},
+		{"api key", "/key", "X-API-Key", "key-1", http.StatusOK, "service-1"},
+		{"api key query", "/key?api_key=key-1", "", "", http.StatusOK, "service-1"},
+		{"api key missing", "/key", "", "", http.StatusUnauthorized, `"detail":"` + ErrCredentialsMissing.Error() + `"`},
+		{"api key invalid", "/key", "X-API-Key", "key-2", http.StatusUnauthorized, `"detail":"` + ErrCredentialsInvalid.Error() + `"`},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, c.path, nil)
+		if c.header != "" {
+			req.Header.Set(c.header, c.value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		fmt.Println("[TestAuth] result ->", c.name, rec.Code, rec.Header().Get("WWW-Authenticate"), rec.Body.String())
+		if rec.Code != c.code || !strings.Contains(rec.Body.String(), c.body) {
+			t.Fatal(c.name + ": auth does not match")
+		}
+		if c.code == http.StatusUnauthorized && rec.Header().Get("Content-Type") != easierweb.MediaTypeProblemJSON {
+			t.Fatal(c.name + ": the error is not the problem details")
+		}
+		if c.path == "/basic" && c.code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") != `Basic realm="Restricted", charset="UTF-8"` {
+			t.Fatal(c.name + ": the challenge is not set")
+		}
+	}
+
+	fmt.Println("\n[TestAuth] end")
+}
@@ -0,0 +1,280 @@
+package middlewares
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheStore store the cached responses, e.g. in-memory LRU, redis, memcached
+type CacheStore interface {
+	// Get returns nil (without error) if the key is not found or expired
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(key string) error
+}
+
+type CacheOptions struct {
+	// time to live of the cached responses, default 1 minute
+	TTL time.Duration
+	// default in-memory LRU store with 1000 entries
+	Store CacheStore
+	// the request headers that are part of the cache key, e.g. Accept, Accept-Encoding, Authorization
+	// the requests with the Authorization or Cookie header are not cached unless the header is listed,
+	// the responses with the Vary header are not cached unless all of its headers are listed
+	VaryHeaders []string
+	// prefix of the cache key, default "cache:"
+	KeyPrefix string
+	// maximum size in bytes of the cached response body, the larger responses are not cached, default 1MB
+	MaxSize int
+}
+
+// cachedResponse the stored response, it is encoded as json
+type cachedResponse struct {
+	Code   int         `json:"code"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// Cache cache the successful GET responses by the method, path, query and the vary headers
+// the response is not cached if ctx.NoCache() is called, or it has the Set-Cookie header or Cache-Control no-store or private,
+// or it varies by the request headers that are not in VaryHeaders (e.g. Vary: Accept-Encoding of the Compress middleware)
+// the X-Cache response header is HIT or MISS
+func Cache(opts ...CacheOptions) easierweb.Handle {
+	opt := CacheOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.TTL <= 0 {
+		opt.TTL = time.Minute
+	}
+	if opt.Store == nil {
+		opt.Store = NewLRUCacheStore(1000)
+	}
+	if opt.KeyPrefix == "" {
+		opt.KeyPrefix = "cache:"
+	}
+	if opt.MaxSize <= 0 {
+		opt.MaxSize = 1 << 20
+	}
+	return func(ctx *easierweb.Context) {
+		if ctx.Request.Method != http.MethodGet || ctx.IsWebsocket() || ctx.Flusher != nil || hasCredentials(ctx.Request, opt.VaryHeaders) {
+			ctx.Next()
+			return
+		}
+		key := cacheKey(ctx.Request, opt.KeyPrefix, opt.VaryHeaders)
+		raw, err := opt.Store.Get(key)
+		if err != nil {
+			ctx.Logger.Error("get cache error: " + err.Error())
+		}
+		if len(raw) > 0 {
+			cached := &cachedResponse{}
+			if json.Unmarshal(raw, cached) == nil {
+				header := ctx.ResponseWriter.Header()
+				for k, v := range cached.Header {
+					header[k] = v
+				}
+				header.Set("X-Cache", "HIT")
+				ctx.Write(cached.Code, cached.Body)
+				ctx.Abort()
+				return
+			}
+		}
+
+		res := ctx.ResponseWriter
+		res.Header().Set("X-Cache", "MISS")
+		cw := &cacheWriter{ResponseWriter: res, maxSize: opt.MaxSize}
+		ctx.ResponseWriter = cw
+		defer func() {
+			ctx.ResponseWriter = res
+		}()
+		ctx.Next()
+
+		if ctx.IsNoCache() || cw.code != http.StatusOK || cw.tooLarge || !cacheable(cw.header) || !varyCovered(cw.header, opt.VaryHeaders) {
+			return
+		}
+		cw.header.Del("X-Cache")
+		raw, err = json.Marshal(&cachedResponse{Code: cw.code, Header: cw.header, Body: cw.buf})
+		if err != nil {
+			ctx.Logger.Error("encode cache error: " + err.Error())
+			return
+		}
+		err = opt.Store.Set(key, raw, opt.TTL)
+		if err != nil {
+			ctx.Logger.Error("set cache error: " + err.Error())
+		}
+	}
+}
+
+// cacheKey the hashed key of the method, request uri and the vary headers
+func cacheKey(req *http.Request, prefix string, varyHeaders []string) string {
+	h := sha256.New()
+	h.Write([]byte(req.Method + " " + req.URL.RequestURI()))
+	for _, name := range varyHeaders {
+		h.Write([]byte("\n" + strings.ToLower(name) + ":" + strings.Join(req.Header.Values(name), ",")))
+	}
+	return prefix + hex.EncodeToString(h.Sum(nil))
+}
+
+func cacheable(header http.Header) bool {
+	if header == nil || len(header.Values("Set-Cookie")) > 0 {
+		return false
+	}
+	cc := strings.ToLower(header.Get("Cache-Control"))
+	return !strings.Contains(cc, "no-store") && !strings.Contains(cc, "private")
+}
+
+// hasCredentials whether the request has the Authorization or Cookie header that is not part of the key,
+// the response may be private to the user, it must not be shared with the other users
+func hasCredentials(req *http.Request, varyHeaders []string) bool {
+	for _, name := range []string{"Authorization", "Cookie"} {
+		if req.Header.Get(name) != "" && !containsHeader(varyHeaders, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// varyCovered whether all the request headers of the response Vary header are part of the key
+func varyCovered(header http.Header, varyHeaders []string) bool {
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name != "" && (name == "*" || !containsHeader(varyHeaders, name)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func containsHeader(names []string, name string) bool {
+	for _, v := range names {
+		if strings.EqualFold(v, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheWriter write the response directly and keep a copy of it
+type cacheWriter struct {
+	http.ResponseWriter
+	maxSize  int
+	code     int
+	header   http.Header
+	buf      []byte
+	tooLarge bool
+}
+
+func (c *cacheWriter) WriteHeader(code int) {
+	if c.code == 0 {
+		c.code = code
+		// the headers are captured when the status code is written, the later changes are not sent
+		c.header = c.ResponseWriter.Header().Clone()
+	}
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *cacheWriter) Write(data []byte) (int, error) {
+	if c.code == 0 {
+		c.WriteHeader(http.StatusOK)
+	}
+	if !c.tooLarge {
+		if len(c.buf)+len(data) > c.maxSize {
+			c.tooLarge = true
+			c.buf = nil
+		} else {
+			c.buf = append(c.buf, data...)
+		}
+	}
+	return c.ResponseWriter.Write(data)
+}
+
+func (c *cacheWriter) Flush() {
+	// the streamed response is not cached
+	c.tooLarge = true
+	c.buf = nil
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// LRUCacheStore the in-memory cache store, the least recently used entries are evicted when it is full
+type LRUCacheStore struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+}
+
+type lruEntry struct {
+	key      string
+	value    []byte
+	expireAt time.Time
+}
+
+// NewLRUCacheStore create an in-memory cache store with the maximum number of entries
+func NewLRUCacheStore(capacity int) *LRUCacheStore {
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	return &LRUCacheStore{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (l *LRUCacheStore) Get(key string) ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.items[key]
+	if !ok {
+		return nil, nil
+	}
+	entry := e.Value.(*lruEntry)
+	if time.Now().After(entry.expireAt) {
+		l.order.Remove(e)
+		delete(l.items, key)
+		return nil, nil
+	}
+	l.order.MoveToFront(e)
+	return entry.value, nil
+}
+
+func (l *LRUCacheStore) Set(key string, value []byte, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	expireAt := time.Now().Add(ttl)
+	if e, ok := l.items[key]; ok {
+		entry := e.Value.(*lruEntry)
+		entry.value = value
+		entry.expireAt = expireAt
+		l.order.MoveToFront(e)
+		return nil
+	}
+	l.items[key] = l.order.PushFront(&lruEntry{key: key, value: value, expireAt: expireAt})
+	for l.order.Len() > l.capacity {
+		last := l.order.Back()
+		l.order.Remove(last)
+		delete(l.items, last.Value.(*lruEntry).key)
+	}
+	return nil
+}
+
+func (l *LRUCacheStore) Delete(key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.items[key]; ok {
+		l.order.Remove(e)
+		delete(l.items, key)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// cache test
+
+func TestCache(t *testing.T) {
+
+	fmt.Println("\n[TestCache] start")
+
+	calls := 0
+	handle := func(ctx *easierweb.Context) {
+		calls++
+		ctx.WriteString(http.StatusOK, fmt.Sprint(ctx.Request.URL.Path, " ", calls))
+	}
+	router := easierweb.New(easierweb.RouterOptions{CloseConsolePrint: true})
+	router.GET("/public", handle, Cache())
+	router.GET("/user", handle, Cache(CacheOptions{VaryHeaders: []string{"Authorization"}}))
+	router.GET("/cookie", func(ctx *easierweb.Context) {
+		ctx.SetCookie(&http.Cookie{Name: "a", Value: "b"})
+		handle(ctx)
+	}, Cache())
+	router.GET("/vary", func(ctx *easierweb.Context) {
+		ctx.AddHeader("Vary", "Accept-Language")
+		handle(ctx)
+	}, Cache())
+
+	cases := []struct {
+		name   string
+		path   string
+		header string
+		value  string
+		cache  string
+		body   string
+	}{
+		{"miss", "/public", "", "", "MISS", "/public 1"},
+		{"hit", "/public", "", "", "HIT", "/public 1"},
+		{"query miss", "/public?a=1", "", "", "MISS", "/public 2"},
+		// the responses of the credentialed requests may be private, they are neither read nor stored
+		{"authorization bypass", "/public", "Authorization", "Bearer a", "", "/public 3"},
+		{"cookie bypass", "/public", "Cookie", "session_id=a", "", "/public 4"},
+		{"authorization bypass again", "/public", "Authorization", "Bearer a", "", "/public 5"},
+		// the listed credentials are part of the key
+		{"vary user miss", "/user", "Authorization", "Bearer a", "MISS", "/user 6"},
+		{"vary user hit", "/user", "Authorization", "Bearer a", "HIT", "/user 6"},
+		{"vary other user miss", "/user", "Authorization", "Bearer b", "MISS", "/user 7"},
+		{"set-cookie miss", "/cookie", "", "", "MISS", "/cookie 8"},
+		{"set-cookie not stored", "/cookie", "", "", "MISS", "/cookie 9"},
+		{"vary miss", "/vary", "", "", "MISS", "/vary 10"},
+		{"vary not stored", "/vary", "", "", "MISS", "/vary 11"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, c.path, nil)
+		if c.header != "" {
+			req.Header.Set(c.header, c.value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		fmt.Println("[TestCache] result ->", c.name, rec.Code, rec.Header().Get("X-Cache"), rec.Body.String())
+		if rec.Code != http.StatusOK || rec.Header().Get("X-Cache") != c.cache || rec.Body.String() != c.body {
+			t.Fatal(c.name + ": cache does not match")
+		}
+	}
+
+	fmt.Println("\n[TestCache] end")
+}
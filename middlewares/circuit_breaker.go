@@ -0,0 +1,235 @@
+package middlewares
+
+import (
+	"errors"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+type CircuitBreakerOptions struct {
+	// name of the circuit shared by all routes of the middleware, each route has its own circuit (named by the route path) if it is empty
+	Name string
+	// the requests and failures are counted in the window, default 10 seconds
+	Window time.Duration
+	// minimum requests in the window before the circuit opens, default 20
+	MinRequests int
+	// the circuit opens when the failure rate of the window reaches it, default 0.5
+	FailureRate float64
+	// the requests slower than it are counted as failures, zero means the latency is not checked
+	SlowThreshold time.Duration
+	// time the circuit stays open before the probes are allowed, default 30 seconds
+	OpenTimeout time.Duration
+	// the probes allowed in the half-open state, the circuit closes if all of them succeed, and opens again if any fails, default 1
+	HalfOpenProbes int
+	// whether the request is failed, default the status code >= 500 (the panics are always failures)
+	IsFailure func(ctx *easierweb.Context) bool
+	// the state is set to the gauge easierweb_circuit_breaker_state{name="..."} (0 closed, 1 open, 2 half-open), e.g. router.Metrics()
+	Metrics *easierweb.Metrics
+	// called when the state is changed
+	OnStateChange func(name string, from CircuitState, to CircuitState)
+	// called when the circuit is open, default 503 *easierweb.Error passed to the RouterOptions.ErrorHandle
+	ErrorHandle func(ctx *easierweb.Context, err error)
+}
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreaker reject the requests while the upstream is failing, e.g. the routes that call the upstreams
+// closed: the requests are passed, the circuit opens if the failure rate of the window reaches FailureRate
+// open: the requests are rejected with Retry-After, after OpenTimeout the circuit is half-open
+// half-open: HalfOpenProbes requests are passed to probe the upstream, others are rejected
+// router.GET("/orders", orders, middlewares.CircuitBreaker(middlewares.CircuitBreakerOptions{Name: "orders-service", Metrics: router.Metrics()}))
+func CircuitBreaker(opts ...CircuitBreakerOptions) easierweb.Handle {
+	opt := CircuitBreakerOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Window <= 0 {
+		opt.Window = 10 * time.Second
+	}
+	if opt.MinRequests <= 0 {
+		opt.MinRequests = 20
+	}
+	if opt.FailureRate <= 0 {
+		opt.FailureRate = 0.5
+	}
+	if opt.OpenTimeout <= 0 {
+		opt.OpenTimeout = 30 * time.Second
+	}
+	if opt.HalfOpenProbes <= 0 {
+		opt.HalfOpenProbes = 1
+	}
+	if opt.IsFailure == nil {
+		opt.IsFailure = func(ctx *easierweb.Context) bool {
+			status := ctx.ResponseStatus()
+			if status == 0 {
+				status = ctx.Code
+			}
+			return status >= http.StatusInternalServerError
+		}
+	}
+	if opt.ErrorHandle == nil {
+		opt.ErrorHandle = func(ctx *easierweb.Context, err error) {
+			ctx.HandleError(httpError(http.StatusServiceUnavailable, "CIRCUIT_OPEN", err))
+		}
+	}
+	var lock sync.Mutex
+	circuits := make(map[string]*circuit)
+	get := func(name string) *circuit {
+		lock.Lock()
+		defer lock.Unlock()
+		c, ok := circuits[name]
+		if !ok {
+			c = &circuit{name: name, opt: &opt}
+			c.mu.Lock()
+			c.setState(CircuitClosed, time.Now())
+			c.unlock()
+			circuits[name] = c
+		}
+		return c
+	}
+	return func(ctx *easierweb.Context) {
+		name := opt.Name
+		if name == "" {
+			name = ctx.Route
+		}
+		c := get(name)
+		generation, retryAfter, ok := c.allow(time.Now())
+		if !ok {
+			ctx.SetHeader("Retry-After", strconv.FormatInt(max(1, ceilSeconds(retryAfter)), 10))
+			opt.ErrorHandle(ctx, ErrCircuitOpen)
+			ctx.Abort()
+			return
+		}
+		start := time.Now()
+		defer func() {
+			err := recover()
+			failed := err != nil || opt.IsFailure(ctx) || (opt.SlowThreshold > 0 && time.Since(start) > opt.SlowThreshold)
+			c.done(generation, failed, time.Now())
+			if err != nil {
+				panic(err)
+			}
+		}()
+		ctx.Next()
+	}
+}
+
+type circuit struct {
+	name string
+	opt  *CircuitBreakerOptions
+	mu   sync.Mutex
+	// the state and the time it is entered
+	state CircuitState
+	since time.Time
+	// incremented when the state is changed or the window is reset, the results of the older generations are ignored
+	generation  uint64
+	windowStart time.Time
+	requests    int
+	failures    int
+	probes      int
+	successes   int
+	// the state changes to be passed to OnStateChange after the lock is released
+	changes [][2]CircuitState
+}
+
+// allow whether the request is passed, returns the generation of the request, or the time to wait if it is rejected
+func (c *circuit) allow(now time.Time) (uint64, time.Duration, bool) {
+	c.mu.Lock()
+	defer c.unlock()
+	switch c.state {
+	case CircuitClosed:
+		if now.Sub(c.windowStart) >= c.opt.Window {
+			c.reset(now)
+		}
+		c.requests++
+	case CircuitOpen:
+		if wait := c.opt.OpenTimeout - now.Sub(c.since); wait > 0 {
+			return 0, wait, false
+		}
+		c.setState(CircuitHalfOpen, now)
+		fallthrough
+	case CircuitHalfOpen:
+		if c.probes >= c.opt.HalfOpenProbes {
+			return 0, time.Second, false
+		}
+		c.probes++
+	}
+	return c.generation, 0, true
+}
+
+// done record the result of the request
+func (c *circuit) done(generation uint64, failed bool, now time.Time) {
+	c.mu.Lock()
+	defer c.unlock()
+	if generation != c.generation {
+		return
+	}
+	switch c.state {
+	case CircuitClosed:
+		if failed {
+			c.failures++
+			if c.requests >= c.opt.MinRequests && float64(c.failures)/float64(c.requests) >= c.opt.FailureRate {
+				c.setState(CircuitOpen, now)
+			}
+		}
+	case CircuitHalfOpen:
+		if failed {
+			c.setState(CircuitOpen, now)
+			return
+		}
+		c.successes++
+		if c.successes >= c.opt.HalfOpenProbes {
+			c.setState(CircuitClosed, now)
+		}
+	}
+}
+
+// reset start a new window
+func (c *circuit) reset(now time.Time) {
+	c.generation++
+	c.windowStart = now
+	c.requests, c.failures, c.probes, c.successes = 0, 0, 0, 0
+}
+
+func (c *circuit) setState(state CircuitState, now time.Time) {
+	from := c.state
+	c.state = state
+	c.since = now
+	c.reset(now)
+	if c.opt.Metrics != nil {
+		c.opt.Metrics.SetGauge("easierweb_circuit_breaker_state", "State of the circuit breaker (0 closed, 1 open, 2 half-open).", float64(state), "name", c.name)
+	}
+	if from != state && c.opt.OnStateChange != nil {
+		c.changes = append(c.changes, [2]CircuitState{from, state})
+	}
+}
+
+// unlock release the lock, then call OnStateChange
+func (c *circuit) unlock() {
+	changes := c.changes
+	c.changes = nil
+	c.mu.Unlock()
+	for _, change := range changes {
+		c.opt.OnStateChange(c.name, change[0], change[1])
+	}
+}
@@ -0,0 +1,84 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// circuit breaker test
+
+func TestCircuitBreaker(t *testing.T) {
+
+	fmt.Println("\n[TestCircuitBreaker] start")
+
+	var lock sync.Mutex
+	var changes []string
+	router := easierweb.New(easierweb.RouterOptions{CloseConsolePrint: true})
+	router.GET("/upstream", func(ctx *easierweb.Context) {
+		if ctx.Request.URL.Query().Get("fail") != "" {
+			ctx.WriteString(http.StatusBadGateway, "failed")
+			return
+		}
+		ctx.WriteString(http.StatusOK, "ok")
+	}, CircuitBreaker(CircuitBreakerOptions{
+		Window:      time.Minute,
+		MinRequests: 2,
+		FailureRate: 0.5,
+		OpenTimeout: 100 * time.Millisecond,
+		OnStateChange: func(name string, from CircuitState, to CircuitState) {
+			lock.Lock()
+			defer lock.Unlock()
+			changes = append(changes, from.String()+"->"+to.String())
+		},
+	}))
+	do := func(uri string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, uri, nil))
+		return rec
+	}
+
+	steps := []struct {
+		name  string
+		uri   string
+		sleep time.Duration
+		code  int
+	}{
+		{"closed", "/upstream", 0, http.StatusOK},
+		// 1 failure of 2 requests reaches the failure rate, the circuit opens
+		{"failure opens", "/upstream?fail=1", 0, http.StatusBadGateway},
+		{"open", "/upstream", 0, http.StatusServiceUnavailable},
+		// the failed probe opens the circuit again
+		{"half-open probe failed", "/upstream?fail=1", 150 * time.Millisecond, http.StatusBadGateway},
+		{"open again", "/upstream", 0, http.StatusServiceUnavailable},
+		// the successful probe closes the circuit
+		{"half-open probe", "/upstream", 150 * time.Millisecond, http.StatusOK},
+		{"closed again", "/upstream", 0, http.StatusOK},
+	}
+	for _, s := range steps {
+		time.Sleep(s.sleep)
+		rec := do(s.uri)
+		fmt.Println("[TestCircuitBreaker] result ->", s.name, rec.Code, rec.Header().Get("Retry-After"))
+		if rec.Code != s.code {
+			t.Fatal(s.name + ": circuit breaker does not match")
+		}
+		if rec.Code == http.StatusServiceUnavailable && (rec.Header().Get("Retry-After") != "1" || !strings.Contains(rec.Body.String(), `"code":"CIRCUIT_OPEN"`)) {
+			t.Fatal(s.name + ": the rejected response does not match: " + rec.Body.String())
+		}
+	}
+
+	lock.Lock()
+	result := strings.Join(changes, " ")
+	lock.Unlock()
+	fmt.Println("[TestCircuitBreaker] changes ->", result)
+	if result != "closed->open open->half-open half-open->open open->half-open half-open->closed" {
+		t.Fatal("state changes do not match")
+	}
+
+	fmt.Println("\n[TestCircuitBreaker] end")
+}
@@ -0,0 +1,105 @@
+package middlewares
+
+import (
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"sync"
+)
+
+type CoalesceOptions struct {
+	// key of the identical requests, default the method, request uri, the Authorization and Cookie headers and the vary headers
+	// the custom key must include the user if the responses are private to the user
+	KeyFunc func(ctx *easierweb.Context) string
+	// the request headers that are part of the default key (besides Authorization and Cookie), e.g. Accept, Accept-Encoding
+	VaryHeaders []string
+	// maximum size in bytes of the shared response body, the waiting requests are executed themselves if it is larger, default 1MB
+	MaxSize int
+}
+
+// coalescedCall the in-flight execution of a key, the response is set before done is closed
+type coalescedCall struct {
+	done   chan struct{}
+	code   int
+	header http.Header
+	body   []byte
+	// the response cannot be shared, e.g. panic, streamed or too large
+	failed bool
+}
+
+// Coalesce execute the concurrent identical GET requests once and share the response (singleflight), e.g. the expensive report endpoints
+// the default key includes the Authorization and Cookie headers, so the responses are only shared between the requests of the same user,
+// the responses that vary by the request headers not in the default key (e.g. Vary: Accept-Encoding of Compress) are not shared
+// the X-Coalesced: true header is set on the shared responses
+func Coalesce(opts ...CoalesceOptions) easierweb.Handle {
+	opt := CoalesceOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	// the response Vary header is only checked with the default key
+	var varyHeaders []string
+	if opt.KeyFunc == nil {
+		varyHeaders = append([]string{"Authorization", "Cookie"}, opt.VaryHeaders...)
+		opt.KeyFunc = func(ctx *easierweb.Context) string {
+			return cacheKey(ctx.Request, "", varyHeaders)
+		}
+	}
+	if opt.MaxSize <= 0 {
+		opt.MaxSize = 1 << 20
+	}
+	var lock sync.Mutex
+	calls := make(map[string]*coalescedCall)
+	return func(ctx *easierweb.Context) {
+		if ctx.Request.Method != http.MethodGet || ctx.IsWebsocket() || ctx.Flusher != nil {
+			ctx.Next()
+			return
+		}
+		key := opt.KeyFunc(ctx)
+		lock.Lock()
+		call, ok := calls[key]
+		if !ok {
+			call = &coalescedCall{done: make(chan struct{}), failed: true}
+			calls[key] = call
+		}
+		lock.Unlock()
+
+		if ok {
+			select {
+			case <-call.done:
+			case <-ctx.Request.Context().Done():
+				ctx.Abort()
+				return
+			}
+			if call.failed {
+				ctx.Next()
+				return
+			}
+			header := ctx.ResponseWriter.Header()
+			for k, v := range call.header {
+				header[k] = v
+			}
+			header.Set("X-Coalesced", "true")
+			ctx.Write(call.code, call.body)
+			ctx.Abort()
+			return
+		}
+
+		res := ctx.ResponseWriter
+		cw := &cacheWriter{ResponseWriter: res, maxSize: opt.MaxSize}
+		ctx.ResponseWriter = cw
+		defer func() {
+			err := recover()
+			ctx.ResponseWriter = res
+			if err == nil && cw.code != 0 && !cw.tooLarge && (varyHeaders == nil || varyCovered(cw.header, varyHeaders)) {
+				call.code, call.header, call.body, call.failed = cw.code, cw.header, cw.buf, false
+			}
+			lock.Lock()
+			delete(calls, key)
+			lock.Unlock()
+			close(call.done)
+			if err != nil {
+				panic(err)
+			}
+		}()
+		ctx.Next()
+	}
+}
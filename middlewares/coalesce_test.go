@@ -0,0 +1,84 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// coalesce test
+
+func TestCoalesce(t *testing.T) {
+
+	fmt.Println("\n[TestCoalesce] start")
+
+	var calls atomic.Int32
+	router := easierweb.New(easierweb.RouterOptions{CloseConsolePrint: true})
+	router.GET("/report", func(ctx *easierweb.Context) {
+		n := calls.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		ctx.SetHeader("X-Report", "1")
+		ctx.WriteString(http.StatusOK, fmt.Sprint("report ", n))
+	}, Coalesce())
+	fanOut := func(n int, header func(i int) (string, string)) []*httptest.ResponseRecorder {
+		recs := make([]*httptest.ResponseRecorder, n)
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				req := httptest.NewRequest(http.MethodGet, "/report", nil)
+				if name, value := header(i); name != "" {
+					req.Header.Set(name, value)
+				}
+				recs[i] = httptest.NewRecorder()
+				router.ServeHTTP(recs[i], req)
+			}(i)
+		}
+		wg.Wait()
+		return recs
+	}
+
+	// the concurrent identical requests share one execution
+	recs := fanOut(10, func(i int) (string, string) {
+		return "", ""
+	})
+	coalesced := 0
+	for _, rec := range recs {
+		if rec.Code != http.StatusOK || rec.Body.String() != "report 1" || rec.Header().Get("X-Report") != "1" {
+			t.Fatal("the shared response does not match: " + rec.Body.String())
+		}
+		if rec.Header().Get("X-Coalesced") == "true" {
+			coalesced++
+		}
+	}
+	fmt.Println("[TestCoalesce] shared ->", calls.Load(), coalesced)
+	if calls.Load() != 1 || coalesced != 9 {
+		t.Fatal("the requests are not coalesced")
+	}
+
+	// the requests of the different users are not shared
+	calls.Store(0)
+	recs = fanOut(4, func(i int) (string, string) {
+		return "Authorization", fmt.Sprint("Bearer ", i%2)
+	})
+	fmt.Println("[TestCoalesce] users ->", calls.Load())
+	if calls.Load() != 2 {
+		t.Fatal("the responses are shared between the users")
+	}
+
+	// the completed request is not shared with the later ones
+	calls.Store(0)
+	fanOut(1, func(i int) (string, string) { return "", "" })
+	fanOut(1, func(i int) (string, string) { return "", "" })
+	if calls.Load() != 2 {
+		t.Fatal("the completed response is shared")
+	}
+
+	fmt.Println("\n[TestCoalesce] end")
+}
@@ -0,0 +1,257 @@
+package middlewares
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"github.com/andybalholm/brotli"
+	"github.com/dpwgc/easierweb"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type CompressOptions struct {
+	// minimum response size in bytes to compress, default 1024
+	MinSize int
+	// compressed content types (prefix match), default text/*, json, xml, yaml and javascript
+	ContentTypes []string
+	// gzip compression level, gzip.HuffmanOnly (-2) to gzip.BestCompression (9), default gzip.DefaultCompression
+	GzipLevel int
+	// brotli compression level, 1 to 11, default 4
+	BrotliLevel int
+	// only gzip is used
+	DisableBrotli bool
+}
+
+var defaultCompressContentTypes = []string{
+	"text/",
+	"application/json",
+	"application/problem+json",
+	"application/xml",
+	"application/yaml",
+	"application/x-yaml",
+	"application/javascript",
+	"image/svg+xml",
+}
+
+// Compress compress the response with brotli or gzip negotiated by the Accept-Encoding header
+// websocket and server-sent events requests are not affected
+func Compress(opts ...CompressOptions) easierweb.Handle {
+	opt := CompressOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.MinSize <= 0 {
+		opt.MinSize = 1024
+	}
+	if len(opt.ContentTypes) == 0 {
+		opt.ContentTypes = defaultCompressContentTypes
+	}
+	if opt.GzipLevel == 0 {
+		opt.GzipLevel = gzip.DefaultCompression
+	}
+	if opt.BrotliLevel == 0 {
+		opt.BrotliLevel = 4
+	}
+	if opt.GzipLevel < gzip.HuffmanOnly || opt.GzipLevel > gzip.BestCompression {
+		panic(fmt.Errorf("invalid gzip compression level: %d", opt.GzipLevel))
+	}
+	if opt.BrotliLevel < brotli.BestSpeed || opt.BrotliLevel > brotli.BestCompression {
+		panic(fmt.Errorf("invalid brotli compression level: %d", opt.BrotliLevel))
+	}
+	pools := map[string]*sync.Pool{
+		"gzip": {New: func() any {
+			w, _ := gzip.NewWriterLevel(io.Discard, opt.GzipLevel)
+			return w
+		}},
+		"br": {New: func() any {
+			return brotli.NewWriterLevel(io.Discard, opt.BrotliLevel)
+		}},
+	}
+	return func(ctx *easierweb.Context) {
+		if ctx.IsWebsocket() || ctx.Flusher != nil || ctx.Request.Method == http.MethodHead {
+			ctx.Next()
+			return
+		}
+		encoding := negotiateEncoding(ctx.Request.Header.Get("Accept-Encoding"), !opt.DisableBrotli)
+		ctx.AddHeader("Vary", "Accept-Encoding")
+		if encoding == "" {
+			ctx.Next()
+			return
+		}
+		res := ctx.ResponseWriter
+		cw := &compressWriter{
+			ResponseWriter: res,
+			opt:            &opt,
+			encoding:       encoding,
+			pool:           pools[encoding],
+		}
+		ctx.ResponseWriter = cw
+		defer func() {
+			ctx.ResponseWriter = res
+			err := cw.close()
+			if err != nil {
+				ctx.Logger.Error("compress response error: " + err.Error())
+			}
+		}()
+		ctx.Next()
+	}
+}
+
+// negotiateEncoding select the encoding with the highest q-value, brotli is preferred when the q-values are equal
+func negotiateEncoding(acceptEncoding string, brotliEnabled bool) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err == nil {
+				q = f
+			}
+		}
+		if q <= 0 || (name != "gzip" && !(name == "br" && brotliEnabled)) {
+			continue
+		}
+		if q > bestQ || (q == bestQ && name == "br") {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+type compressWriter struct {
+	http.ResponseWriter
+	opt      *CompressOptions
+	encoding string
+	pool     *sync.Pool
+	code     int
+	buf      []byte
+	// decided whether to compress, the status code has been written
+	decided bool
+	writer  io.WriteCloser
+}
+
+func (c *compressWriter) WriteHeader(code int) {
+	if c.code == 0 {
+		c.code = code
+	}
+}
+
+// Write buffer the data until it reaches the minimum size, then decide whether to compress
+func (c *compressWriter) Write(data []byte) (int, error) {
+	if c.code == 0 {
+		c.code = http.StatusOK
+	}
+	if !c.decided {
+		c.buf = append(c.buf, data...)
+		if len(c.buf) < c.opt.MinSize {
+			return len(data), nil
+		}
+		err := c.decide()
+		if err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if c.writer != nil {
+		return c.writer.Write(data)
+	}
+	return c.ResponseWriter.Write(data)
+}
+
+func (c *compressWriter) decide() error {
+	c.decided = true
+	header := c.ResponseWriter.Header()
+	if len(c.buf) >= c.opt.MinSize && c.compressible(header) {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", c.encoding)
+		w := c.pool.Get()
+		switch zw := w.(type) {
+		case *gzip.Writer:
+			zw.Reset(c.ResponseWriter)
+			c.writer = zw
+		case *brotli.Writer:
+			zw.Reset(c.ResponseWriter)
+			c.writer = zw
+		}
+	}
+	if c.code != 0 {
+		c.ResponseWriter.WriteHeader(c.code)
+	}
+	if len(c.buf) == 0 {
+		return nil
+	}
+	buf := c.buf
+	c.buf = nil
+	if c.writer != nil {
+		_, err := c.writer.Write(buf)
+		return err
+	}
+	_, err := c.ResponseWriter.Write(buf)
+	return err
+}
+
+func (c *compressWriter) compressible(header http.Header) bool {
+	if c.code < http.StatusOK || c.code == http.StatusNoContent || c.code == http.StatusNotModified || c.code == http.StatusPartialContent {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	if contentType == "" {
+		contentType = http.DetectContentType(c.buf)
+	}
+	for _, t := range c.opt.ContentTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// close write the remaining buffered data and release the compress writer
+func (c *compressWriter) close() error {
+	if !c.decided {
+		if c.code == 0 && len(c.buf) == 0 {
+			return nil
+		}
+		err := c.decide()
+		if err != nil {
+			return err
+		}
+	}
+	if c.writer == nil {
+		return nil
+	}
+	err := c.writer.Close()
+	c.pool.Put(c.writer)
+	c.writer = nil
+	return err
+}
+
+func (c *compressWriter) Flush() {
+	if !c.decided {
+		_ = c.decide()
+	}
+	if zw, ok := c.writer.(interface{ Flush() error }); ok {
+		_ = zw.Flush()
+	}
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (c *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := c.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("the response writer does not support hijacking")
+}
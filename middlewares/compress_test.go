@@ -0,0 +1,83 @@
+package middlewares
+
+import (
+	"compress/gzip"
+	"fmt"
+	"github.com/andybalholm/brotli"
+	"github.com/dpwgc/easierweb"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// compress test
+
+func TestCompress(t *testing.T) {
+
+	fmt.Println("\n[TestCompress] start")
+
+	large := strings.Repeat("hello world ", 100)
+	router := easierweb.New(easierweb.RouterOptions{CloseConsolePrint: true}).Use(Compress(CompressOptions{MinSize: 512}))
+	router.GET("/large", func(ctx *easierweb.Context) {
+		ctx.WriteString(http.StatusOK, large)
+	})
+	router.GET("/small", func(ctx *easierweb.Context) {
+		ctx.WriteString(http.StatusOK, "hello world")
+	})
+	router.GET("/image", func(ctx *easierweb.Context) {
+		ctx.SetHeader("Content-Type", "image/png")
+		ctx.Write(http.StatusOK, []byte(large))
+	})
+	// the body encoded by the handle is not compressed again
+	router.GET("/encoded", func(ctx *easierweb.Context) {
+		ctx.SetHeader("Content-Encoding", "identity")
+		ctx.WriteString(http.StatusOK, large)
+	})
+
+	cases := []struct {
+		path     string
+		accept   string
+		encoding string
+		body     string
+	}{
+		{"/large", "gzip", "gzip", large},
+		{"/large", "gzip, br", "br", large},
+		{"/large", "br;q=0.5, gzip", "gzip", large},
+		{"/large", "", "", large},
+		// smaller than MinSize
+		{"/small", "gzip", "", "hello world"},
+		{"/image", "gzip", "", large},
+		{"/encoded", "gzip", "identity", large},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, c.path, nil)
+		if c.accept != "" {
+			req.Header.Set("Accept-Encoding", c.accept)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		var reader io.Reader = rec.Body
+		switch rec.Header().Get("Content-Encoding") {
+		case "gzip":
+			gr, err := gzip.NewReader(rec.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reader = gr
+		case "br":
+			reader = brotli.NewReader(rec.Body)
+		}
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Println("[TestCompress] result ->", c.path, c.accept, rec.Header().Get("Content-Encoding"), rec.Header().Get("Vary"), len(body))
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Encoding") != c.encoding || string(body) != c.body {
+			t.Fatal(c.path + " " + c.accept + ": compression does not match")
+		}
+	}
+
+	fmt.Println("\n[TestCompress] end")
+}
@@ -0,0 +1,119 @@
+package middlewares
+
+import (
+	"errors"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type ConcurrencyLimitOptions struct {
+	// maximum in-flight requests of all routes, zero means no global limit
+	Max int
+	// maximum in-flight requests of each route, zero means no per-route limit
+	PerRoute int
+	// maximum in-flight requests of the routes (override PerRoute), the key is the route path, e.g. "/reports/:id"
+	Routes map[string]int
+	// maximum time to wait for a slot, the requests are rejected immediately if it is zero
+	MaxWait time.Duration
+	// status code of the rejected requests, default 503 (or 429)
+	Code int
+	// called when the request is rejected, default Code *easierweb.Error passed to the RouterOptions.ErrorHandle
+	ErrorHandle func(ctx *easierweb.Context, err error)
+}
+
+var ErrConcurrencyLimit = errors.New("too many concurrent requests")
+
+// ConcurrencyLimit cap the in-flight requests (global and per-route), the requests over the limit wait for a slot at most MaxWait,
+// then they are rejected with Retry-After, e.g. protect the backends from the stampedes after restarts
+// router.Use(middlewares.ConcurrencyLimit(middlewares.ConcurrencyLimitOptions{Max: 200, Routes: map[string]int{"/reports/:id": 5}, MaxWait: time.Second}))
+func ConcurrencyLimit(opts ...ConcurrencyLimitOptions) easierweb.Handle {
+	opt := ConcurrencyLimitOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Code == 0 {
+		opt.Code = http.StatusServiceUnavailable
+	}
+	if opt.ErrorHandle == nil {
+		opt.ErrorHandle = func(ctx *easierweb.Context, err error) {
+			ctx.HandleError(httpError(opt.Code, "CONCURRENCY_LIMIT", err))
+		}
+	}
+	var global chan struct{}
+	if opt.Max > 0 {
+		global = make(chan struct{}, opt.Max)
+	}
+	routes := make(map[string]chan struct{}, len(opt.Routes))
+	for route, n := range opt.Routes {
+		if n > 0 {
+			routes[route] = make(chan struct{}, n)
+		}
+	}
+	// the semaphores of the other routes are created on demand if PerRoute is set
+	var lock sync.Mutex
+	perRoute := make(map[string]chan struct{})
+	semaphore := func(route string) chan struct{} {
+		if _, ok := opt.Routes[route]; ok {
+			return routes[route]
+		}
+		if opt.PerRoute <= 0 {
+			return nil
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		sem, ok := perRoute[route]
+		if !ok {
+			sem = make(chan struct{}, opt.PerRoute)
+			perRoute[route] = sem
+		}
+		return sem
+	}
+	retryAfter := strconv.FormatInt(max(1, ceilSeconds(opt.MaxWait)), 10)
+	return func(ctx *easierweb.Context) {
+		var timeout <-chan time.Time
+		if opt.MaxWait > 0 {
+			timer := time.NewTimer(opt.MaxWait)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		// the route slot is taken first, so the requests waiting for a busy route do not hold the global slots
+		for _, sem := range []chan struct{}{semaphore(ctx.Route), global} {
+			if sem == nil {
+				continue
+			}
+			if !acquire(ctx, sem, timeout) {
+				ctx.SetHeader("Retry-After", retryAfter)
+				opt.ErrorHandle(ctx, ErrConcurrencyLimit)
+				ctx.Abort()
+				return
+			}
+			defer func(sem chan struct{}) {
+				<-sem
+			}(sem)
+		}
+		ctx.Next()
+	}
+}
+
+// acquire take a slot of the semaphore, wait until the timeout (nil means no waiting) or the request is canceled
+func acquire(ctx *easierweb.Context, sem chan struct{}, timeout <-chan time.Time) bool {
+	select {
+	case sem <- struct{}{}:
+		return true
+	default:
+	}
+	if timeout == nil {
+		return false
+	}
+	select {
+	case sem <- struct{}{}:
+		return true
+	case <-timeout:
+		return false
+	case <-ctx.Request.Context().Done():
+		return false
+	}
+}
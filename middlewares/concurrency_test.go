@@ -0,0 +1,88 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// concurrency limit test
+
+func TestConcurrencyLimit(t *testing.T) {
+
+	fmt.Println("\n[TestConcurrencyLimit] start")
+
+	release := make(chan struct{})
+	entered := make(chan struct{}, 10)
+	router := easierweb.New(easierweb.RouterOptions{CloseConsolePrint: true})
+	handle := func(ctx *easierweb.Context) {
+		entered <- struct{}{}
+		<-release
+		ctx.WriteString(http.StatusOK, "ok")
+	}
+	router.GET("/reject", handle, ConcurrencyLimit(ConcurrencyLimitOptions{Max: 1}))
+	router.GET("/wait", handle, ConcurrencyLimit(ConcurrencyLimitOptions{Max: 1, MaxWait: time.Second}))
+	router.GET("/timeout", handle, ConcurrencyLimit(ConcurrencyLimitOptions{Max: 1, MaxWait: 50 * time.Millisecond, Code: http.StatusTooManyRequests}))
+	do := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	// hold the only slot of the path, and run the second request
+	run := func(path string, second func() *httptest.ResponseRecorder) (int, int) {
+		var wg sync.WaitGroup
+		var first *httptest.ResponseRecorder
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			first = do(path)
+		}()
+		<-entered
+		rec := second()
+		wg.Wait()
+		return first.Code, rec.Code
+	}
+
+	// the request over the limit is rejected immediately
+	first, second := run("/reject", func() *httptest.ResponseRecorder {
+		rec := do("/reject")
+		release <- struct{}{}
+		return rec
+	})
+	fmt.Println("[TestConcurrencyLimit] reject ->", first, second)
+	if first != http.StatusOK || second != http.StatusServiceUnavailable {
+		t.Fatal("the request over the limit is not rejected")
+	}
+
+	// the request waits for the slot
+	first, second = run("/wait", func() *httptest.ResponseRecorder {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			release <- struct{}{}
+			<-entered
+			release <- struct{}{}
+		}()
+		return do("/wait")
+	})
+	fmt.Println("[TestConcurrencyLimit] wait ->", first, second)
+	if first != http.StatusOK || second != http.StatusOK {
+		t.Fatal("the request does not wait for the slot")
+	}
+
+	// the request is rejected after MaxWait
+	first, second = run("/timeout", func() *httptest.ResponseRecorder {
+		rec := do("/timeout")
+		release <- struct{}{}
+		return rec
+	})
+	fmt.Println("[TestConcurrencyLimit] timeout ->", first, second)
+	if first != http.StatusOK || second != http.StatusTooManyRequests {
+		t.Fatal("the request is not rejected after the max wait")
+	}
+
+	fmt.Println("\n[TestConcurrencyLimit] end")
+}
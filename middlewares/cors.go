@@ -1,23 +1,107 @@
 package middlewares
 
 import (
+	"errors"
 	"github.com/dpwgc/easierweb"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
-func CORS() easierweb.Handle {
+type CORSOptions struct {
+	// default all origins ("*"), or the exact origins and the wildcard subdomains, e.g. https://*.example.com
+	AllowOrigins  []string
+	AllowMethods  []string
+	AllowHeaders  []string
+	ExposeHeaders []string
+	// send Access-Control-Allow-Credentials (cookies and authorization), default false, the origins must be listed explicitly ("*" is not allowed)
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// CORS answer the preflight requests and set the CORS headers of the allowed origins, default all origins without credentials
+func CORS(opts ...CORSOptions) easierweb.Handle {
+	opt := CORSOptions{
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  []string{"POST", "GET", "OPTIONS", "PUT", "PATCH", "DELETE", "HEAD"},
+		AllowHeaders:  []string{"*"},
+		ExposeHeaders: []string{"*"},
+	}
+	if len(opts) > 0 {
+		opt = opts[0]
+		if len(opt.AllowOrigins) == 0 {
+			opt.AllowOrigins = []string{"*"}
+		}
+		if len(opt.AllowMethods) == 0 {
+			opt.AllowMethods = []string{"POST", "GET", "OPTIONS", "PUT", "PATCH", "DELETE", "HEAD"}
+		}
+	}
+	allowAll := false
+	for _, v := range opt.AllowOrigins {
+		if v == "*" {
+			allowAll = true
+		}
+	}
+	// any site could make the credentialed requests if all origins were echoed back
+	if allowAll && opt.AllowCredentials {
+		panic(errors.New("cors credentials cannot be allowed for all origins (*), set the allowed origins"))
+	}
+	methods := strings.Join(opt.AllowMethods, ", ")
+	headers := strings.Join(opt.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(opt.ExposeHeaders, ", ")
+	maxAge := ""
+	if opt.MaxAge > 0 {
+		maxAge = strconv.FormatInt(int64(opt.MaxAge/time.Second), 10)
+	}
 	return func(ctx *easierweb.Context) {
-		if ctx.Request.Header.Get("Origin") != "" {
-			ctx.SetHeader("Access-Control-Allow-Origin", "*")
-			ctx.SetHeader("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, PATCH, DELETE, HEAD")
-			ctx.SetHeader("Access-Control-Allow-Headers", "*")
-			ctx.SetHeader("Access-Control-Expose-Headers", "*")
-			ctx.SetHeader("Access-Control-Allow-Credentials", "true")
-		}
-		if ctx.Request.Method == "OPTIONS" {
-			ctx.WriteString(http.StatusOK, "Options Request!")
-		} else {
+		origin := ctx.Request.Header.Get("Origin")
+		allowed := origin != "" && (allowAll || corsOriginAllowed(opt.AllowOrigins, origin))
+		if allowed {
+			if allowAll {
+				ctx.SetHeader("Access-Control-Allow-Origin", "*")
+			} else {
+				ctx.SetHeader("Access-Control-Allow-Origin", origin)
+				ctx.AddHeader("Vary", "Origin")
+			}
+			if opt.AllowCredentials {
+				ctx.SetHeader("Access-Control-Allow-Credentials", "true")
+			}
+			if exposeHeaders != "" {
+				ctx.SetHeader("Access-Control-Expose-Headers", exposeHeaders)
+			}
+		}
+		if ctx.Request.Method != http.MethodOptions {
 			ctx.Next()
+			return
+		}
+		// answer the preflight request
+		if allowed {
+			ctx.SetHeader("Access-Control-Allow-Methods", methods)
+			if headers != "" {
+				ctx.SetHeader("Access-Control-Allow-Headers", headers)
+			} else if reqHeaders := ctx.Request.Header.Get("Access-Control-Request-Headers"); reqHeaders != "" {
+				ctx.SetHeader("Access-Control-Allow-Headers", reqHeaders)
+			}
+			if maxAge != "" {
+				ctx.SetHeader("Access-Control-Max-Age", maxAge)
+			}
+		}
+		ctx.NoContent(http.StatusNoContent)
+		ctx.Abort()
+	}
+}
+
+func corsOriginAllowed(origins []string, origin string) bool {
+	for _, v := range origins {
+		if strings.EqualFold(v, origin) {
+			return true
+		}
+		// wildcard subdomain, e.g. https://*.example.com
+		if i := strings.Index(v, "*"); i >= 0 && len(origin) >= len(v)-1 &&
+			strings.HasPrefix(origin, v[:i]) && strings.HasSuffix(origin, v[i+1:]) {
+			return true
 		}
 	}
+	return false
 }
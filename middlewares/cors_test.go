@@ -0,0 +1,69 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// cors test
+
+func TestCORS(t *testing.T) {
+
+	fmt.Println("\n[TestCORS] start")
+
+	cases := []struct {
+		name        string
+		opt         []CORSOptions
+		method      string
+		origin      string
+		code        int
+		origins     string
+		credentials string
+	}{
+		// the literal wildcard is sent, the browsers do not send the credentials with it
+		{"default foreign origin", nil, http.MethodGet, "https://evil.example", http.StatusOK, "*", ""},
+		{"default preflight", nil, http.MethodOptions, "https://evil.example", http.StatusNoContent, "*", ""},
+		{"credentials allowed origin", []CORSOptions{{AllowOrigins: []string{"https://app.example"}, AllowCredentials: true}},
+			http.MethodGet, "https://app.example", http.StatusOK, "https://app.example", "true"},
+		{"credentials foreign origin", []CORSOptions{{AllowOrigins: []string{"https://app.example"}, AllowCredentials: true}},
+			http.MethodGet, "https://evil.example", http.StatusOK, "", ""},
+		{"wildcard subdomain", []CORSOptions{{AllowOrigins: []string{"https://*.example.com"}, AllowCredentials: true}},
+			http.MethodGet, "https://a.example.com", http.StatusOK, "https://a.example.com", "true"},
+		{"wildcard subdomain foreign origin", []CORSOptions{{AllowOrigins: []string{"https://*.example.com"}, AllowCredentials: true}},
+			http.MethodGet, "https://example.com.evil.example", http.StatusOK, "", ""},
+		{"no origin", nil, http.MethodGet, "", http.StatusOK, "", ""},
+	}
+	for _, c := range cases {
+		router := easierweb.New(easierweb.RouterOptions{CloseConsolePrint: true}).Use(CORS(c.opt...))
+		router.GET("/test", func(ctx *easierweb.Context) {
+			ctx.WriteString(http.StatusOK, "ok")
+		})
+		router.OPTIONS("/test", func(ctx *easierweb.Context) {})
+		req := httptest.NewRequest(c.method, "/test", nil)
+		if c.origin != "" {
+			req.Header.Set("Origin", c.origin)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		origins, credentials := rec.Header().Get("Access-Control-Allow-Origin"), rec.Header().Get("Access-Control-Allow-Credentials")
+		fmt.Println("[TestCORS] result ->", c.name, rec.Code, origins, credentials)
+		if rec.Code != c.code || origins != c.origins || credentials != c.credentials {
+			t.Fatal(c.name + ": cors headers do not match")
+		}
+	}
+
+	// the credentials cannot be allowed for all origins
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("the credentials with all origins are not rejected")
+			}
+		}()
+		CORS(CORSOptions{AllowCredentials: true})
+	}()
+
+	fmt.Println("\n[TestCORS] end")
+}
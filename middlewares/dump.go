@@ -0,0 +1,155 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"github.com/dpwgc/easierweb"
+	"log/slog"
+	"net/http"
+)
+
+type DumpOptions struct {
+	// dump all requests, otherwise only the requests with the trigger header or query parameter (with the Secret) are dumped
+	Enabled bool
+	// the request header that triggers the dump, default X-Debug-Dump
+	TriggerHeader string
+	// the query parameter that triggers the dump, default debug_dump
+	TriggerQuery string
+	// the value of the trigger must be equal to the secret, the triggers are disabled if it is empty (Enabled or Secret is required)
+	Secret string
+	// maximum size in bytes of the dumped request and response bodies, default 4096
+	MaxBodySize int
+	// the headers that are redacted, in addition to the RouterOptions.Redact rules
+	RedactHeaders []string
+	// default ctx.Logger
+	Logger *slog.Logger
+	// level of the dump log, default slog.LevelInfo
+	Level slog.Level
+}
+
+// Dump log the full request and response (headers and bodies) for debugging, e.g. diagnose the serialization issues in staging
+// the secret headers and body fields are redacted (see RouterOptions.Redact), and the bodies are truncated to MaxBodySize
+func Dump(opts ...DumpOptions) easierweb.Handle {
+	opt := DumpOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.TriggerHeader == "" {
+		opt.TriggerHeader = "X-Debug-Dump"
+	}
+	if opt.TriggerQuery == "" {
+		opt.TriggerQuery = "debug_dump"
+	}
+	if opt.MaxBodySize <= 0 {
+		opt.MaxBodySize = 4096
+	}
+	// any client could trigger the dump of the bodies without the secret
+	if !opt.Enabled && opt.Secret == "" {
+		panic(errors.New("dump requires the secret of the triggers or enabled"))
+	}
+	redact := make(map[string]bool, len(opt.RedactHeaders))
+	for _, h := range opt.RedactHeaders {
+		redact[http.CanonicalHeaderKey(h)] = true
+	}
+	return func(ctx *easierweb.Context) {
+		if !opt.Enabled && !dumpTriggered(ctx.Request, opt) {
+			ctx.Next()
+			return
+		}
+		res := ctx.ResponseWriter
+		dw := &dumpWriter{countWriter: countWriter{ResponseWriter: res}, maxSize: opt.MaxBodySize}
+		ctx.ResponseWriter = dw
+		defer func() {
+			ctx.ResponseWriter = res
+		}()
+
+		ctx.Next()
+
+		status := dw.status
+		if status == 0 {
+			status = ctx.Code
+		}
+		if status == 0 {
+			status = http.StatusOK
+		}
+		logger := opt.Logger
+		if logger == nil {
+			logger = ctx.Logger
+		}
+		logger.LogAttrs(context.Background(), opt.Level, "dump",
+			slog.String("method", ctx.Request.Method),
+			slog.String("uri", ctx.Request.URL.RequestURI()),
+			slog.Any("request_headers", redactHeaders(ctx, ctx.Request.Header, redact)),
+			slog.String("request_body", dumpRequestBody(ctx, opt.MaxBodySize)),
+			slog.Int("status", status),
+			slog.Any("response_headers", redactHeaders(ctx, res.Header(), redact)),
+			slog.String("response_body", dumpResponseBody(ctx, res.Header(), dw, opt.MaxBodySize)),
+			slog.String("request_id", ctx.RequestID()))
+	}
+}
+
+func dumpTriggered(req *http.Request, opt DumpOptions) bool {
+	value := req.Header.Get(opt.TriggerHeader)
+	if value == "" {
+		value = req.URL.Query().Get(opt.TriggerQuery)
+	}
+	return opt.Secret != "" && subtle.ConstantTimeCompare([]byte(value), []byte(opt.Secret)) == 1
+}
+
+func redactHeaders(ctx *easierweb.Context, header http.Header, redact map[string]bool) map[string]string {
+	dumped := ctx.Redactor().Headers(header)
+	for k := range dumped {
+		if redact[k] {
+			dumped[k] = easierweb.Redacted
+		}
+	}
+	return dumped
+}
+
+// dumpRequestBody the body read by the router (ctx.Body), the multipart and streamed bodies are not dumped
+func dumpRequestBody(ctx *easierweb.Context, maxSize int) string {
+	if ctx.Request.MultipartForm != nil {
+		return "[multipart]"
+	}
+	if len(ctx.Body) == 0 && len(ctx.Request.PostForm) > 0 {
+		return dumpBody([]byte(ctx.Redactor().Form(ctx.Request.PostForm).Encode()), 0, maxSize)
+	}
+	// the whole body is redacted before it is truncated
+	body := ctx.Redactor().Body(ctx.Request.Header.Get("Content-Type"), ctx.Body)
+	return dumpBody(body, int64(len(body)), maxSize)
+}
+
+// dumpResponseBody only the first bytes of the response are kept, a truncated JSON body is replaced if there are field rules
+func dumpResponseBody(ctx *easierweb.Context, header http.Header, dw *dumpWriter, maxSize int) string {
+	body := ctx.Redactor().Body(header.Get("Content-Type"), dw.body)
+	if !bytes.Equal(body, dw.body) {
+		return dumpBody(body, int64(len(body)), maxSize)
+	}
+	return dumpBody(body, dw.bytes, maxSize)
+}
+
+func dumpBody(body []byte, size int64, maxSize int) string {
+	if len(body) > maxSize {
+		body = body[:maxSize]
+	}
+	if size > int64(len(body)) {
+		return string(body) + "...(truncated)"
+	}
+	return string(body)
+}
+
+// dumpWriter keep the first bytes of the response body
+type dumpWriter struct {
+	countWriter
+	maxSize int
+	body    []byte
+}
+
+func (d *dumpWriter) Write(data []byte) (int, error) {
+	if remaining := d.maxSize - len(d.body); remaining > 0 {
+		d.body = append(d.body, data[:min(remaining, len(data))]...)
+	}
+	return d.countWriter.Write(data)
+}
@@ -0,0 +1,157 @@
+package middlewares
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type ETagOptions struct {
+	// generate weak etags (W/"..."), e.g. the body may be re-encoded by the proxies
+	Weak bool
+	// maximum size in bytes of the buffered response, the larger responses are written without etag, default 1MB
+	MaxSize int
+}
+
+// ETag compute the etag of the successful GET and HEAD responses (the ETag header set by the handle is kept)
+// and answer If-None-Match and If-Modified-Since (with the Last-Modified header set by the handle) with 304
+// it should be used after Compress, so that the etag is computed from the uncompressed body
+func ETag(opts ...ETagOptions) easierweb.Handle {
+	opt := ETagOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.MaxSize <= 0 {
+		opt.MaxSize = 1 << 20
+	}
+	return func(ctx *easierweb.Context) {
+		method := ctx.Request.Method
+		if (method != http.MethodGet && method != http.MethodHead) || ctx.IsWebsocket() || ctx.Flusher != nil {
+			ctx.Next()
+			return
+		}
+		res := ctx.ResponseWriter
+		ew := &etagWriter{ResponseWriter: res, maxSize: opt.MaxSize}
+		ctx.ResponseWriter = ew
+		defer func() {
+			ctx.ResponseWriter = res
+		}()
+		ctx.Next()
+		if ew.passthrough {
+			return
+		}
+		code := ew.code
+		if code == 0 {
+			code = http.StatusOK
+		}
+		header := res.Header()
+		if code != http.StatusOK {
+			ew.flush(code)
+			return
+		}
+		etag := header.Get("ETag")
+		if etag == "" {
+			sum := sha256.Sum256(ew.buf)
+			etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+			if opt.Weak {
+				etag = "W/" + etag
+			}
+			header.Set("ETag", etag)
+		}
+		if notModified(ctx.Request, etag, header.Get("Last-Modified")) {
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			res.WriteHeader(http.StatusNotModified)
+			return
+		}
+		ew.flush(code)
+	}
+}
+
+// notModified If-None-Match takes precedence over If-Modified-Since (RFC 9110)
+func notModified(req *http.Request, etag, lastModified string) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		for _, v := range strings.Split(inm, ",") {
+			v = strings.TrimSpace(v)
+			// weak comparison
+			if v == "*" || strings.TrimPrefix(v, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	ims := req.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified == "" {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// etagWriter buffer the response to compute the etag, it writes directly if the response is too large or flushed
+type etagWriter struct {
+	http.ResponseWriter
+	maxSize     int
+	code        int
+	buf         []byte
+	passthrough bool
+}
+
+func (e *etagWriter) WriteHeader(code int) {
+	if e.passthrough {
+		e.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if e.code == 0 {
+		e.code = code
+	}
+}
+
+func (e *etagWriter) Write(data []byte) (int, error) {
+	if e.passthrough {
+		return e.ResponseWriter.Write(data)
+	}
+	if len(e.buf)+len(data) > e.maxSize {
+		e.startPassthrough()
+		return e.ResponseWriter.Write(data)
+	}
+	e.buf = append(e.buf, data...)
+	return len(data), nil
+}
+
+func (e *etagWriter) Flush() {
+	e.startPassthrough()
+	if f, ok := e.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (e *etagWriter) startPassthrough() {
+	if e.passthrough {
+		return
+	}
+	code := e.code
+	if code == 0 {
+		code = http.StatusOK
+	}
+	e.flush(code)
+	e.passthrough = true
+}
+
+func (e *etagWriter) flush(code int) {
+	e.ResponseWriter.WriteHeader(code)
+	if len(e.buf) > 0 {
+		_, _ = e.ResponseWriter.Write(e.buf)
+	}
+	e.buf = nil
+}
@@ -0,0 +1,67 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// etag test
+
+func TestETag(t *testing.T) {
+
+	fmt.Println("\n[TestETag] start")
+
+	router := easierweb.New(easierweb.RouterOptions{CloseConsolePrint: true}).Use(ETag())
+	router.GET("/hello", func(ctx *easierweb.Context) {
+		ctx.WriteString(http.StatusOK, "hello")
+	})
+	router.GET("/versioned", func(ctx *easierweb.Context) {
+		ctx.SetHeader("ETag", `"v1"`)
+		ctx.WriteString(http.StatusOK, "hello")
+	})
+	router.GET("/missing", func(ctx *easierweb.Context) {
+		ctx.WriteString(http.StatusNotFound, "missing")
+	})
+	do := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	etag := do("/hello", "").Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("the etag is not set")
+	}
+
+	cases := []struct {
+		name        string
+		path        string
+		ifNoneMatch string
+		code        int
+		body        string
+	}{
+		{"no condition", "/hello", "", http.StatusOK, "hello"},
+		{"matched", "/hello", etag, http.StatusNotModified, ""},
+		{"weak matched", "/hello", "W/" + etag, http.StatusNotModified, ""},
+		{"list matched", "/hello", `"other", ` + etag, http.StatusNotModified, ""},
+		{"any", "/hello", "*", http.StatusNotModified, ""},
+		{"changed", "/hello", `"other"`, http.StatusOK, "hello"},
+		{"handle etag", "/versioned", `"v1"`, http.StatusNotModified, ""},
+		{"not ok", "/missing", "*", http.StatusNotFound, "missing"},
+	}
+	for _, c := range cases {
+		rec := do(c.path, c.ifNoneMatch)
+		fmt.Println("[TestETag] result ->", c.name, rec.Code, rec.Header().Get("ETag"), rec.Body.String())
+		if rec.Code != c.code || rec.Body.String() != c.body {
+			t.Fatal(c.name + ": etag response does not match")
+		}
+	}
+
+	fmt.Println("\n[TestETag] end")
+}
@@ -0,0 +1,266 @@
+package middlewares
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// IdempotencyStore store the responses of the idempotency keys, it can be implemented with redis (SET NX) to share them between instances
+type IdempotencyStore interface {
+	// Reserve mark the key as in progress if it does not exist, returns false if it exists (in progress or completed)
+	Reserve(key string, ttl time.Duration) (bool, error)
+	// Get returns nil (without error) if the key is not found, expired or in progress
+	Get(key string) ([]byte, error)
+	// Set save the completed response of the key
+	Set(key string, value []byte, ttl time.Duration) error
+	// Delete release the key, so the request can be retried
+	Delete(key string) error
+}
+
+type IdempotencyOptions struct {
+	// request header of the key, default Idempotency-Key
+	Header string
+	// the requests without the key are rejected (400), otherwise they are passed
+	Required bool
+	// the methods that the keys apply to, default POST and PATCH
+	Methods []string
+	// time the responses are replayed, default 24 hours
+	TTL time.Duration
+	// time the key is reserved by the in-flight request (released early if it completes), default 1 minute
+	LockTimeout time.Duration
+	// default in-memory store
+	Store IdempotencyStore
+	// prefix of the store key, default "idempotency:"
+	KeyPrefix string
+	// maximum size in bytes of the stored response body, the larger responses are not stored (the key is released), default 1MB
+	MaxSize int
+	// called when the key is missing (400), in progress (409) or reused with a different request (422), default *easierweb.Error passed to the RouterOptions.ErrorHandle
+	ErrorHandle func(ctx *easierweb.Context, err error)
+}
+
+var (
+	ErrIdempotencyKeyMissing    = errors.New("idempotency key is missing")
+	ErrIdempotencyKeyInProgress = errors.New("a request with the same idempotency key is in progress")
+	ErrIdempotencyKeyMismatch   = errors.New("idempotency key is reused with a different request")
+)
+
+// idempotentResponse the stored response, it is encoded as json
+type idempotentResponse struct {
+	Fingerprint string      `json:"fingerprint"`
+	Code        int         `json:"code"`
+	Header      http.Header `json:"header"`
+	Body        []byte      `json:"body"`
+}
+
+// Idempotency replay the first response of the Idempotency-Key for the retries within the TTL, so the payment-style endpoints are safe to retry
+// the keys are scoped by the principal (ctx.Principal), the method and the path, and bound to the request body
+// the responses with the status code >= 500 are not stored, the key is released and the request can be retried
+// the replayed responses have the Idempotent-Replayed: true header
+func Idempotency(opts ...IdempotencyOptions) easierweb.Handle {
+	opt := IdempotencyOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Header == "" {
+		opt.Header = "Idempotency-Key"
+	}
+	if len(opt.Methods) == 0 {
+		opt.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+	if opt.TTL <= 0 {
+		opt.TTL = 24 * time.Hour
+	}
+	if opt.LockTimeout <= 0 {
+		opt.LockTimeout = time.Minute
+	}
+	if opt.Store == nil {
+		opt.Store = NewMemoryIdempotencyStore()
+	}
+	if opt.KeyPrefix == "" {
+		opt.KeyPrefix = "idempotency:"
+	}
+	if opt.MaxSize <= 0 {
+		opt.MaxSize = 1 << 20
+	}
+	if opt.ErrorHandle == nil {
+		opt.ErrorHandle = func(ctx *easierweb.Context, err error) {
+			switch {
+			case errors.Is(err, ErrIdempotencyKeyInProgress):
+				ctx.HandleError(httpError(http.StatusConflict, "IDEMPOTENCY_KEY_IN_PROGRESS", err))
+			case errors.Is(err, ErrIdempotencyKeyMismatch):
+				ctx.HandleError(httpError(http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_MISMATCH", err))
+			default:
+				ctx.HandleError(httpError(http.StatusBadRequest, "IDEMPOTENCY_KEY_MISSING", err))
+			}
+		}
+	}
+	methods := make(map[string]bool, len(opt.Methods))
+	for _, m := range opt.Methods {
+		methods[m] = true
+	}
+	return func(ctx *easierweb.Context) {
+		if !methods[ctx.Request.Method] {
+			ctx.Next()
+			return
+		}
+		idempotencyKey := ctx.Request.Header.Get(opt.Header)
+		if idempotencyKey == "" {
+			if opt.Required {
+				opt.ErrorHandle(ctx, ErrIdempotencyKeyMissing)
+				ctx.Abort()
+				return
+			}
+			ctx.Next()
+			return
+		}
+		key := opt.KeyPrefix + sha256Hex(ctx.Principal()+"\n"+ctx.Request.Method+" "+ctx.Request.URL.Path+"\n"+idempotencyKey)
+		fingerprint := sha256Hex(string(ctx.Body) + "\n" + ctx.Request.PostForm.Encode())
+		if replayIdempotentResponse(ctx, opt, key, fingerprint) {
+			return
+		}
+		reserved, err := opt.Store.Reserve(key, opt.LockTimeout)
+		if err != nil {
+			// the request is passed if the store is unavailable
+			ctx.Logger.Error("idempotency store error: " + err.Error())
+			ctx.Next()
+			return
+		}
+		if !reserved {
+			// completed after the first check, or in progress
+			if replayIdempotentResponse(ctx, opt, key, fingerprint) {
+				return
+			}
+			opt.ErrorHandle(ctx, ErrIdempotencyKeyInProgress)
+			ctx.Abort()
+			return
+		}
+
+		res := ctx.ResponseWriter
+		cw := &cacheWriter{ResponseWriter: res, maxSize: opt.MaxSize}
+		ctx.ResponseWriter = cw
+		stored := false
+		defer func() {
+			ctx.ResponseWriter = res
+			// release the key if the response is not stored (e.g. panic), so the request can be retried
+			if !stored {
+				err := opt.Store.Delete(key)
+				if err != nil {
+					ctx.Logger.Error("idempotency store error: " + err.Error())
+				}
+			}
+		}()
+		ctx.Next()
+
+		if cw.code == 0 || cw.code >= http.StatusInternalServerError || cw.tooLarge {
+			return
+		}
+		raw, err := json.Marshal(&idempotentResponse{Fingerprint: fingerprint, Code: cw.code, Header: cw.header, Body: cw.buf})
+		if err != nil {
+			ctx.Logger.Error("encode idempotent response error: " + err.Error())
+			return
+		}
+		err = opt.Store.Set(key, raw, opt.TTL)
+		if err != nil {
+			ctx.Logger.Error("idempotency store error: " + err.Error())
+			return
+		}
+		stored = true
+	}
+}
+
+// replayIdempotentResponse write the stored response of the key, returns false if it is not found
+func replayIdempotentResponse(ctx *easierweb.Context, opt IdempotencyOptions, key string, fingerprint string) bool {
+	raw, err := opt.Store.Get(key)
+	if err != nil {
+		ctx.Logger.Error("idempotency store error: " + err.Error())
+		return false
+	}
+	stored := &idempotentResponse{}
+	if len(raw) == 0 || json.Unmarshal(raw, stored) != nil {
+		return false
+	}
+	if stored.Fingerprint != fingerprint {
+		opt.ErrorHandle(ctx, ErrIdempotencyKeyMismatch)
+		ctx.Abort()
+		return true
+	}
+	header := ctx.ResponseWriter.Header()
+	for k, v := range stored.Header {
+		header[k] = v
+	}
+	header.Set("Idempotent-Replayed", "true")
+	ctx.Write(stored.Code, stored.Body)
+	ctx.Abort()
+	return true
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// MemoryIdempotencyStore the in-memory idempotency store, the expired keys are removed periodically
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	items   map[string]idempotencyEntry
+	sweptAt time.Time
+}
+
+type idempotencyEntry struct {
+	// nil if the request is in progress
+	value    []byte
+	expireAt time.Time
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{items: make(map[string]idempotencyEntry), sweptAt: time.Now()}
+}
+
+func (m *MemoryIdempotencyStore) Reserve(key string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if now.Sub(m.sweptAt) >= time.Minute {
+		m.sweptAt = now
+		for k, e := range m.items {
+			if now.After(e.expireAt) {
+				delete(m.items, k)
+			}
+		}
+	}
+	if e, ok := m.items[key]; ok && now.Before(e.expireAt) {
+		return false, nil
+	}
+	m.items[key] = idempotencyEntry{expireAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (m *MemoryIdempotencyStore) Get(key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.items[key]
+	if !ok || time.Now().After(e.expireAt) {
+		return nil, nil
+	}
+	return e.value, nil
+}
+
+func (m *MemoryIdempotencyStore) Set(key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items[key] = idempotencyEntry{value: value, expireAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (m *MemoryIdempotencyStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, key)
+	return nil
+}
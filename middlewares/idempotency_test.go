@@ -0,0 +1,89 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// idempotency test
+
+func TestIdempotency(t *testing.T) {
+
+	fmt.Println("\n[TestIdempotency] start")
+
+	calls := 0
+	block := make(chan struct{})
+	entered := make(chan struct{})
+	router := easierweb.New(easierweb.RouterOptions{CloseConsolePrint: true})
+	router.POST("/payments", func(ctx *easierweb.Context) {
+		calls++
+		if string(ctx.Body) == "fail" {
+			ctx.WriteString(http.StatusInternalServerError, fmt.Sprint("failed ", calls))
+			return
+		}
+		if string(ctx.Body) == "block" {
+			entered <- struct{}{}
+			<-block
+		}
+		ctx.SetHeader("X-Payment", fmt.Sprint(calls))
+		ctx.WriteString(http.StatusCreated, fmt.Sprint("payment ", calls))
+	}, Idempotency(IdempotencyOptions{Required: true}))
+	do := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	cases := []struct {
+		name     string
+		key      string
+		body     string
+		code     int
+		expect   string
+		replayed string
+	}{
+		{"first", "k1", "a", http.StatusCreated, "payment 1", ""},
+		{"replay", "k1", "a", http.StatusCreated, "payment 1", "true"},
+		{"replay again", "k1", "a", http.StatusCreated, "payment 1", "true"},
+		{"reused with a different body", "k1", "b", http.StatusUnprocessableEntity, `"code":"IDEMPOTENCY_KEY_MISMATCH"`, ""},
+		{"other key", "k2", "a", http.StatusCreated, "payment 2", ""},
+		{"missing key", "", "a", http.StatusBadRequest, `"code":"IDEMPOTENCY_KEY_MISSING"`, ""},
+		// the failed responses are not stored, the key can be retried
+		{"failed", "k3", "fail", http.StatusInternalServerError, "failed 3", ""},
+		{"failed retry", "k3", "fail", http.StatusInternalServerError, "failed 4", ""},
+	}
+	for _, c := range cases {
+		rec := do(c.key, c.body)
+		fmt.Println("[TestIdempotency] result ->", c.name, rec.Code, rec.Header().Get("Idempotent-Replayed"), rec.Body.String())
+		if rec.Code != c.code || !strings.Contains(rec.Body.String(), c.expect) || rec.Header().Get("Idempotent-Replayed") != c.replayed {
+			t.Fatal(c.name + ": idempotency does not match")
+		}
+		if c.replayed != "" && rec.Header().Get("X-Payment") != "1" {
+			t.Fatal(c.name + ": the headers are not replayed")
+		}
+	}
+
+	// the key is reserved by the in-flight request
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- do("k4", "block")
+	}()
+	<-entered
+	rec := do("k4", "block")
+	close(block)
+	first := <-done
+	fmt.Println("[TestIdempotency] in progress ->", first.Code, rec.Code)
+	if first.Code != http.StatusCreated || rec.Code != http.StatusConflict {
+		t.Fatal("the in-flight key is not reserved")
+	}
+
+	fmt.Println("\n[TestIdempotency] end")
+}
@@ -0,0 +1,83 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"net/netip"
+)
+
+type IPFilterRules struct {
+	// IPs or CIDRs that are allowed, if not empty, the other clients are blocked, e.g. "10.8.0.0/16"
+	Allow []string
+	// IPs or CIDRs that are blocked, it takes precedence over Allow
+	Deny []string
+}
+
+type IPFilterOptions struct {
+	// rules of the routes that are not in Routes
+	Rules IPFilterRules
+	// rules of the routes (override Rules), the key is the route path, e.g. "/admin/*path" or "/users/:id"
+	Routes map[string]IPFilterRules
+	// called when the client is blocked, default 403 *easierweb.Error passed to the RouterOptions.ErrorHandle
+	ErrorHandle func(ctx *easierweb.Context, err error)
+}
+
+var ErrIPForbidden = errors.New("ip is not allowed")
+
+// IPFilter allow or block the requests by the client ip (ctx.ClientIP, see RouterOptions.TrustedProxies), e.g.
+// router.Use(middlewares.IPFilter(middlewares.IPFilterOptions{Routes: map[string]middlewares.IPFilterRules{"/admin/*path": {Allow: []string{"10.8.0.0/16"}}}}))
+// it panics if the IP or CIDR is invalid
+func IPFilter(opts ...IPFilterOptions) easierweb.Handle {
+	opt := IPFilterOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.ErrorHandle == nil {
+		opt.ErrorHandle = func(ctx *easierweb.Context, err error) {
+			ctx.HandleError(httpError(http.StatusForbidden, "FORBIDDEN", err))
+		}
+	}
+	rules := newIPFilter(opt.Rules)
+	routes := make(map[string]*ipFilter, len(opt.Routes))
+	for route, v := range opt.Routes {
+		routes[route] = newIPFilter(v)
+	}
+	return func(ctx *easierweb.Context) {
+		f, ok := routes[ctx.Route]
+		if !ok {
+			f = rules
+		}
+		if !f.allowed(ctx.ClientIP()) {
+			opt.ErrorHandle(ctx, ErrIPForbidden)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
+
+type ipFilter struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+func newIPFilter(rules IPFilterRules) *ipFilter {
+	allow, err := easierweb.ParseIPPrefixes(rules.Allow)
+	if err != nil {
+		panic(fmt.Errorf("invalid ip filter allow rule: %w", err))
+	}
+	deny, err := easierweb.ParseIPPrefixes(rules.Deny)
+	if err != nil {
+		panic(fmt.Errorf("invalid ip filter deny rule: %w", err))
+	}
+	return &ipFilter{allow: allow, deny: deny}
+}
+
+func (f *ipFilter) allowed(ip string) bool {
+	if easierweb.ContainsIP(f.deny, ip) {
+		return false
+	}
+	return len(f.allow) == 0 || easierweb.ContainsIP(f.allow, ip)
+}
@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// ip filter test
+
+func TestIPFilter(t *testing.T) {
+
+	fmt.Println("\n[TestIPFilter] start")
+
+	router := easierweb.New(easierweb.RouterOptions{CloseConsolePrint: true}).Use(IPFilter(IPFilterOptions{
+		Rules: IPFilterRules{Deny: []string{"203.0.113.0/24", "2001:db8::1"}},
+		Routes: map[string]IPFilterRules{
+			"/admin/*path": {Allow: []string{"10.8.0.0/16", "192.168.1.10"}, Deny: []string{"10.8.1.0/24"}},
+		},
+	}))
+	handle := func(ctx *easierweb.Context) {
+		ctx.WriteString(http.StatusOK, "ok")
+	}
+	router.GET("/public", handle)
+	router.GET("/admin/*path", handle)
+
+	cases := []struct {
+		path       string
+		remoteAddr string
+		code       int
+	}{
+		{"/public", "198.51.100.1:1000", http.StatusOK},
+		{"/public", "203.0.113.7:1000", http.StatusForbidden},
+		{"/public", "[2001:db8::1]:1000", http.StatusForbidden},
+		{"/public", "[2001:db8::2]:1000", http.StatusOK},
+		{"/admin/users", "10.8.2.3:1000", http.StatusOK},
+		{"/admin/users", "192.168.1.10:1000", http.StatusOK},
+		{"/admin/users", "192.168.1.11:1000", http.StatusForbidden},
+		// deny takes precedence over allow
+		{"/admin/users", "10.8.1.5:1000", http.StatusForbidden},
+		// the route rules override the global rules
+		{"/admin/users", "203.0.113.7:1000", http.StatusForbidden},
+		{"/admin/users", "198.51.100.1:1000", http.StatusForbidden},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, c.path, nil)
+		req.RemoteAddr = c.remoteAddr
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		fmt.Println("[TestIPFilter] result ->", c.path, c.remoteAddr, rec.Code)
+		if rec.Code != c.code {
+			t.Fatal(c.path + " " + c.remoteAddr + ": ip filter does not match")
+		}
+		if c.code == http.StatusForbidden && !strings.Contains(rec.Body.String(), `"code":"FORBIDDEN"`) {
+			t.Fatal("the rejected response does not match: " + rec.Body.String())
+		}
+	}
+
+	fmt.Println("\n[TestIPFilter] end")
+}
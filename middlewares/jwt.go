@@ -0,0 +1,422 @@
+package middlewares
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type JWTOptions struct {
+	// secret of the HMAC algorithms (HS256, HS384, HS512)
+	Secret []byte
+	// public key (*rsa.PublicKey or *ecdsa.PublicKey) of the RSA and ECDSA algorithms (RS*, PS*, ES*)
+	PublicKey crypto.PublicKey
+	// the JWKS url, the key is selected by the kid of the token header
+	JWKSURL string
+	// interval for refreshing the JWKS, default 1 hour
+	JWKSRefreshInterval time.Duration
+	// if not empty, the iss claim must match
+	Issuer string
+	// if not empty, the aud claim must contain it
+	Audience string
+	// tolerance of the exp and nbf claims
+	Leeway time.Duration
+	// get the token from the request, default "Authorization: Bearer <token>"
+	TokenLookup func(ctx *easierweb.Context) string
+	// called when the authentication fails, default 401 *easierweb.Error passed to the RouterOptions.ErrorHandle
+	ErrorHandle func(ctx *easierweb.Context, err error)
+}
+
+var (
+	ErrJWTMissing          = errors.New("missing token")
+	ErrJWTMalformed        = errors.New("malformed token")
+	ErrJWTUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrJWTInvalidSignature = errors.New("invalid token signature")
+	ErrJWTExpired          = errors.New("token is expired")
+	ErrJWTNotValidYet      = errors.New("token is not valid yet")
+	ErrJWTInvalidIssuer    = errors.New("invalid token issuer")
+	ErrJWTInvalidAudience  = errors.New("invalid token audience")
+)
+
+// JWT validate the bearer token, the claims can be read by ctx.Claims(), the principal (ctx.Principal()) is the sub claim
+func JWT(opts ...JWTOptions) easierweb.Handle {
+	opt := JWTOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.TokenLookup == nil {
+		opt.TokenLookup = bearerToken
+	}
+	if opt.ErrorHandle == nil {
+		opt.ErrorHandle = func(ctx *easierweb.Context, err error) {
+			ctx.SetHeader("WWW-Authenticate", "Bearer")
+			ctx.HandleError(unauthorized(err))
+		}
+	}
+	parse := JWTParser(opt)
+	return func(ctx *easierweb.Context) {
+		claims, err := parse(opt.TokenLookup(ctx))
+		if err != nil {
+			opt.ErrorHandle(ctx, err)
+			ctx.Abort()
+			return
+		}
+		ctx.SetClaims(claims)
+		if sub, ok := claims["sub"].(string); ok {
+			ctx.SetPrincipal(sub)
+		}
+		ctx.Next()
+	}
+}
+
+// JWTParser returns the function that validates the token by the options (the key, issuer, audience, etc.) and returns the claims,
+// e.g. the ID tokens of OpenID Connect, the JWKS is cached by the function
+func JWTParser(opt JWTOptions) func(token string) (map[string]any, error) {
+	var jwks *jwksCache
+	if opt.JWKSURL != "" {
+		jwks = newJWKSCache(opt.JWKSURL, opt.JWKSRefreshInterval)
+	}
+	return func(token string) (map[string]any, error) {
+		return parseJWT(token, opt, jwks)
+	}
+}
+
+func bearerToken(ctx *easierweb.Context) string {
+	auth := ctx.Request.Header.Get("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+func parseJWT(token string, opt JWTOptions, jwks *jwksCache) (map[string]any, error) {
+	if token == "" {
+		return nil, ErrJWTMissing
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrJWTMalformed
+	}
+	var header jwtHeader
+	err := decodeJWTSegment(parts[0], &header)
+	if err != nil {
+		return nil, ErrJWTMalformed
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrJWTMalformed
+	}
+
+	key, err := jwtKey(header, opt, jwks)
+	if err != nil {
+		return nil, err
+	}
+	err = verifyJWT(header.Alg, key, parts[0]+"."+parts[1], signature)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := make(map[string]any)
+	err = decodeJWTSegment(parts[1], &claims)
+	if err != nil {
+		return nil, ErrJWTMalformed
+	}
+	return claims, validateClaims(claims, opt)
+}
+
+func decodeJWTSegment(seg string, obj any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, obj)
+}
+
+// jwtKey select the key by the algorithm, the algorithm family must match the type of the key
+func jwtKey(header jwtHeader, opt JWTOptions, jwks *jwksCache) (any, error) {
+	if strings.HasPrefix(header.Alg, "HS") {
+		if len(opt.Secret) == 0 {
+			return nil, ErrJWTUnsupportedAlg
+		}
+		return opt.Secret, nil
+	}
+	if opt.PublicKey != nil {
+		return opt.PublicKey, nil
+	}
+	if jwks != nil {
+		return jwks.get(header.Kid)
+	}
+	return nil, ErrJWTUnsupportedAlg
+}
+
+func verifyJWT(alg string, key any, signingInput string, signature []byte) error {
+	if len(alg) != 5 {
+		return ErrJWTUnsupportedAlg
+	}
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return ErrJWTUnsupportedAlg
+	}
+
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return ErrJWTUnsupportedAlg
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrJWTInvalidSignature
+		}
+		return nil
+	}
+
+	h := hash.New()
+	h.Write([]byte(signingInput))
+	digest := h.Sum(nil)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrJWTUnsupportedAlg
+		}
+		var err error
+		if alg[0] == 'R' {
+			err = rsa.VerifyPKCS1v15(pub, hash, digest, signature)
+		} else {
+			err = rsa.VerifyPSS(pub, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		if err != nil {
+			return ErrJWTInvalidSignature
+		}
+		return nil
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrJWTUnsupportedAlg
+		}
+		// the signature is r || s
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return ErrJWTInvalidSignature
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return ErrJWTInvalidSignature
+		}
+		return nil
+	}
+	return ErrJWTUnsupportedAlg
+}
+
+func validateClaims(claims map[string]any, opt JWTOptions) error {
+	now := time.Now()
+	if exp, ok := claims["exp"].(float64); ok && now.After(time.Unix(int64(exp), 0).Add(opt.Leeway)) {
+		return ErrJWTExpired
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Before(time.Unix(int64(nbf), 0).Add(-opt.Leeway)) {
+		return ErrJWTNotValidYet
+	}
+	if opt.Issuer != "" && claims["iss"] != opt.Issuer {
+		return ErrJWTInvalidIssuer
+	}
+	if opt.Audience != "" {
+		match := false
+		switch aud := claims["aud"].(type) {
+		case string:
+			match = aud == opt.Audience
+		case []any:
+			for _, v := range aud {
+				if v == opt.Audience {
+					match = true
+					break
+				}
+			}
+		}
+		if !match {
+			return ErrJWTInvalidAudience
+		}
+	}
+	return nil
+}
+
+// JWKS
+
+type jwksCache struct {
+	url      string
+	interval time.Duration
+	client   *http.Client
+	mu       sync.RWMutex
+	keys     map[string]crypto.PublicKey
+	fetched  time.Time
+	// the in-flight refresh, closed when it is completed, nil if there is none
+	refreshing chan struct{}
+	refreshErr error
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func newJWKSCache(url string, interval time.Duration) *jwksCache {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &jwksCache{
+		url:      url,
+		interval: interval,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// get the key of the kid, the keys are refreshed when expired, or when the kid is unknown (key rotation) at most once every 10 seconds,
+// the known keys are returned without waiting for the refresh, the JWKS is fetched without holding the lock
+func (j *jwksCache) get(kid string) (crypto.PublicKey, error) {
+	j.mu.RLock()
+	key, ok := j.keys[kid]
+	since := time.Since(j.fetched)
+	j.mu.RUnlock()
+	if ok {
+		if since > j.interval {
+			j.refresh()
+		}
+		return key, nil
+	}
+	if since > 10*time.Second {
+		<-j.refresh()
+	}
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	key, ok = j.keys[kid]
+	if !ok {
+		if j.keys == nil && j.refreshErr != nil {
+			return nil, j.refreshErr
+		}
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+	return key, nil
+}
+
+// refresh start fetching the JWKS if it is not in progress, the returned channel is closed when it is completed
+func (j *jwksCache) refresh() <-chan struct{} {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.refreshing != nil {
+		return j.refreshing
+	}
+	done := make(chan struct{})
+	j.refreshing = done
+	// the failed fetches are not retried within 10 seconds
+	j.fetched = time.Now()
+	go func() {
+		keys, err := j.fetch()
+		j.mu.Lock()
+		if err == nil {
+			j.keys = keys
+		}
+		j.refreshErr = err
+		j.refreshing = nil
+		j.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+func (j *jwksCache) fetch() (map[string]crypto.PublicKey, error) {
+	res, err := j.client.Get(j.url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks failed: %s", res.Status)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&set)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		pub, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+}
@@ -0,0 +1,213 @@
+package middlewares
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// jwt test
+
+func TestJWT(t *testing.T) {
+
+	fmt.Println("\n[TestJWT] start")
+
+	secret := []byte("secret")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	now := time.Now().Unix()
+
+	cases := []struct {
+		name   string
+		opt    JWTOptions
+		token  string
+		expect error
+	}{
+		{"hmac", JWTOptions{Secret: secret}, jwtTestHS256(secret, map[string]any{"sub": "1"}), nil},
+		{"rsa", JWTOptions{PublicKey: &rsaKey.PublicKey}, jwtTestRS256(rsaKey, "", map[string]any{"sub": "1"}), nil},
+		{"invalid signature", JWTOptions{Secret: secret}, jwtTestHS256([]byte("other"), map[string]any{}), ErrJWTInvalidSignature},
+		{"missing", JWTOptions{Secret: secret}, "", ErrJWTMissing},
+		{"malformed", JWTOptions{Secret: secret}, "a.b", ErrJWTMalformed},
+		// alg confusion, the HMAC token signed with the public key is rejected
+		{"alg confusion", JWTOptions{PublicKey: &rsaKey.PublicKey}, jwtTestHS256(publicPEM, map[string]any{"sub": "1"}), ErrJWTUnsupportedAlg},
+		{"alg confusion der", JWTOptions{PublicKey: &rsaKey.PublicKey}, jwtTestHS256(der, map[string]any{"sub": "1"}), ErrJWTUnsupportedAlg},
+		{"rsa token with secret", JWTOptions{Secret: secret}, jwtTestRS256(rsaKey, "", map[string]any{}), ErrJWTUnsupportedAlg},
+		{"alg none", JWTOptions{Secret: secret}, jwtTestEncode(map[string]any{"alg": "none"}, map[string]any{}) + ".", ErrJWTUnsupportedAlg},
+		// expiry and leeway
+		{"expired", JWTOptions{Secret: secret}, jwtTestHS256(secret, map[string]any{"exp": now - 5}), ErrJWTExpired},
+		{"expired within leeway", JWTOptions{Secret: secret, Leeway: 10 * time.Second}, jwtTestHS256(secret, map[string]any{"exp": now - 5}), nil},
+		{"expired beyond leeway", JWTOptions{Secret: secret, Leeway: 10 * time.Second}, jwtTestHS256(secret, map[string]any{"exp": now - 30}), ErrJWTExpired},
+		{"not valid yet", JWTOptions{Secret: secret}, jwtTestHS256(secret, map[string]any{"nbf": now + 30}), ErrJWTNotValidYet},
+		{"not valid yet within leeway", JWTOptions{Secret: secret, Leeway: time.Minute}, jwtTestHS256(secret, map[string]any{"nbf": now + 30}), nil},
+		// issuer and audience
+		{"issuer", JWTOptions{Secret: secret, Issuer: "iss"}, jwtTestHS256(secret, map[string]any{"iss": "other"}), ErrJWTInvalidIssuer},
+		{"audience", JWTOptions{Secret: secret, Audience: "api"}, jwtTestHS256(secret, map[string]any{"aud": "api"}), nil},
+		{"audience array", JWTOptions{Secret: secret, Audience: "api"}, jwtTestHS256(secret, map[string]any{"aud": []string{"web", "api"}}), nil},
+		{"audience mismatch", JWTOptions{Secret: secret, Audience: "api"}, jwtTestHS256(secret, map[string]any{"aud": []string{"web"}}), ErrJWTInvalidAudience},
+		{"audience missing", JWTOptions{Secret: secret, Audience: "api"}, jwtTestHS256(secret, map[string]any{}), ErrJWTInvalidAudience},
+	}
+	for _, c := range cases {
+		_, err := JWTParser(c.opt)(c.token)
+		fmt.Println("[TestJWT] result ->", c.name, err)
+		if !errors.Is(err, c.expect) {
+			t.Fatalf("%s: expect %v, got %v", c.name, c.expect, err)
+		}
+	}
+
+	fmt.Println("\n[TestJWT] end")
+}
+
+// jwt middleware test
+
+func TestJWTMiddleware(t *testing.T) {
+
+	fmt.Println("\n[TestJWTMiddleware] start")
+
+	secret := []byte("secret")
+	router := easierweb.New(easierweb.RouterOptions{CloseConsolePrint: true}).Use(JWT(JWTOptions{Secret: secret}))
+	router.GET("/me", func(ctx *easierweb.Context) {
+		ctx.WriteString(http.StatusOK, ctx.Principal())
+	})
+	cases := []struct {
+		name  string
+		token string
+		code  int
+		body  string
+	}{
+		{"valid", jwtTestHS256(secret, map[string]any{"sub": "1"}), http.StatusOK, "1"},
+		// the failures are the problem details of the RouterOptions.ErrorHandle
+		{"missing", "", http.StatusUnauthorized, `"code":"UNAUTHORIZED"`},
+		{"invalid signature", jwtTestHS256([]byte("other"), map[string]any{"sub": "1"}), http.StatusUnauthorized, `"detail":"invalid token signature"`},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		fmt.Println("[TestJWTMiddleware] result ->", c.name, rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+		if rec.Code != c.code || !strings.Contains(rec.Body.String(), c.body) {
+			t.Fatal(c.name + ": jwt response does not match")
+		}
+		if c.code == http.StatusUnauthorized && (rec.Header().Get("Content-Type") != easierweb.MediaTypeProblemJSON || rec.Header().Get("WWW-Authenticate") != "Bearer") {
+			t.Fatal(c.name + ": jwt error is not the problem details")
+		}
+	}
+
+	fmt.Println("\n[TestJWTMiddleware] end")
+}
+
+// jwks test
+
+func TestJWTJWKS(t *testing.T) {
+
+	fmt.Println("\n[TestJWTJWKS] start")
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fetches atomic.Int32
+	var delay atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		time.Sleep(time.Duration(delay.Load()))
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kid": "k1",
+			"kty": "RSA",
+			"n":   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+		}}})
+	}))
+	defer server.Close()
+
+	parse := JWTParser(JWTOptions{JWKSURL: server.URL, JWKSRefreshInterval: 100 * time.Millisecond})
+	claims, err := parse(jwtTestRS256(rsaKey, "k1", map[string]any{"sub": "1"}))
+	fmt.Println("[TestJWTJWKS] known kid ->", claims, err)
+	if err != nil || claims["sub"] != "1" {
+		t.Fatal("the token signed by the jwks key is not valid")
+	}
+
+	// the unknown kids are rejected, the jwks is refetched at most once every 10 seconds
+	for _, kid := range []string{"k2", "k3"} {
+		_, err = parse(jwtTestRS256(rsaKey, kid, map[string]any{}))
+		fmt.Println("[TestJWTJWKS] unknown kid ->", kid, err)
+		if err == nil || !strings.Contains(err.Error(), "unknown key id") {
+			t.Fatal("the unknown kid is not rejected")
+		}
+	}
+	if fetches.Load() != 1 {
+		t.Fatal("the jwks is refetched for the unknown kids")
+	}
+
+	// the expired keys are refreshed in the background, the known kids do not wait for the slow jwks endpoint
+	time.Sleep(150 * time.Millisecond)
+	delay.Store(int64(500 * time.Millisecond))
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		_, err = parse(jwtTestRS256(rsaKey, "k1", map[string]any{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	elapsed := time.Since(start)
+	fmt.Println("[TestJWTJWKS] known kid during refresh ->", elapsed)
+	if elapsed > 300*time.Millisecond {
+		t.Fatal("the known kids wait for the jwks refresh")
+	}
+	for i := 0; i < 100 && fetches.Load() < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if fetches.Load() != 2 {
+		t.Fatal("the expired jwks is not refreshed once")
+	}
+
+	fmt.Println("\n[TestJWTJWKS] end")
+}
+
+func jwtTestEncode(header, claims map[string]any) string {
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	return base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+}
+
+func jwtTestHS256(secret []byte, claims map[string]any) string {
+	input := jwtTestEncode(map[string]any{"alg": "HS256", "typ": "JWT"}, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func jwtTestRS256(key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	input := jwtTestEncode(map[string]any{"alg": "RS256", "typ": "JWT", "kid": kid}, claims)
+	digest := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
@@ -0,0 +1,94 @@
+package middlewares
+
+import (
+	"crypto/x509"
+	"errors"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+)
+
+type ClientCertOptions struct {
+	// identities of the client certificates, the key is the URI SAN, the DNS SAN or the subject common name (matched in this order),
+	// e.g. {"spiffe://cluster.local/ns/default/sa/orders": "orders-service"}, if empty, the identity is the first of them
+	Identities map[string]string
+	// get the identity of the certificate, overrides Identities
+	Identity func(cert *x509.Certificate) (string, error)
+	// called when the authentication fails, default 401 *easierweb.Error passed to the RouterOptions.ErrorHandle if the certificate is missing, 403 otherwise
+	ErrorHandle func(ctx *easierweb.Context, err error)
+}
+
+var (
+	ErrClientCertMissing = errors.New("missing client certificate")
+	ErrClientCertUnknown = errors.New("unknown client certificate")
+)
+
+// ClientCert authenticate the services by the client certificates verified by the TLS server (mTLS, see ServerOptions.ClientCAFile)
+// the principal (ctx.Principal()) is the identity, the claims can be read by ctx.Claims(), e.g. {"sub": "orders-service", "cn": "orders", "dns": [...], "uri": [...]}
+func ClientCert(opts ...ClientCertOptions) easierweb.Handle {
+	opt := ClientCertOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Identity == nil {
+		identities := opt.Identities
+		opt.Identity = func(cert *x509.Certificate) (string, error) {
+			return certIdentity(cert, identities)
+		}
+	}
+	if opt.ErrorHandle == nil {
+		opt.ErrorHandle = func(ctx *easierweb.Context, err error) {
+			if errors.Is(err, ErrClientCertMissing) {
+				ctx.HandleError(unauthorized(err))
+				return
+			}
+			ctx.HandleError(httpError(http.StatusForbidden, "FORBIDDEN", err))
+		}
+	}
+	return func(ctx *easierweb.Context) {
+		cert := ctx.ClientCert()
+		if cert == nil {
+			opt.ErrorHandle(ctx, ErrClientCertMissing)
+			ctx.Abort()
+			return
+		}
+		identity, err := opt.Identity(cert)
+		if err != nil {
+			opt.ErrorHandle(ctx, err)
+			ctx.Abort()
+			return
+		}
+		uris := make([]string, 0, len(cert.URIs))
+		for _, u := range cert.URIs {
+			uris = append(uris, u.String())
+		}
+		ctx.SetClaims(map[string]any{
+			"sub": identity,
+			"cn":  cert.Subject.CommonName,
+			"dns": cert.DNSNames,
+			"uri": uris,
+		})
+		ctx.SetPrincipal(identity)
+		ctx.Next()
+	}
+}
+
+// certIdentity the first URI SAN, DNS SAN or common name of the certificate that is in the identities (or the first of them if identities is empty)
+func certIdentity(cert *x509.Certificate, identities map[string]string) (string, error) {
+	names := make([]string, 0, len(cert.URIs)+len(cert.DNSNames)+1)
+	for _, u := range cert.URIs {
+		names = append(names, u.String())
+	}
+	names = append(names, cert.DNSNames...)
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	for _, name := range names {
+		if len(identities) == 0 {
+			return name, nil
+		}
+		if identity, ok := identities[name]; ok {
+			return identity, nil
+		}
+	}
+	return "", ErrClientCertUnknown
+}
@@ -0,0 +1,158 @@
+package middlewares
+
+import (
+	"errors"
+	"github.com/dpwgc/easierweb"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type RateLimitOptions struct {
+	// tokens added to the bucket per second
+	Rate float64
+	// capacity of the bucket, default max(1, Rate)
+	Burst int
+	// get the key of the bucket, default ctx.ClientIP()
+	KeyFunc func(ctx *easierweb.Context) string
+	// store of the buckets, default in-memory store
+	Store RateLimitStore
+	// called when the limit is exceeded, default 429 *easierweb.Error (ErrTooManyRequests) passed to the RouterOptions.ErrorHandle
+	ErrorHandle func(ctx *easierweb.Context, result RateLimitResult)
+}
+
+var ErrTooManyRequests = errors.New("too many requests")
+
+// RateLimitStore store the token buckets, it can be implemented with redis to share the limit between instances
+type RateLimitStore interface {
+	// Take take a token from the bucket of the key
+	Take(key string, rate float64, burst int) (RateLimitResult, error)
+}
+
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// time to wait for the next token, zero if allowed
+	RetryAfter time.Duration
+	// time until the bucket is full
+	Reset time.Duration
+}
+
+// RateLimit token bucket rate limiting, the X-RateLimit-* headers are set, and Retry-After is set when the limit is exceeded
+func RateLimit(opts ...RateLimitOptions) easierweb.Handle {
+	opt := RateLimitOptions{Rate: 10}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Burst <= 0 {
+		opt.Burst = int(math.Max(1, opt.Rate))
+	}
+	if opt.KeyFunc == nil {
+		opt.KeyFunc = func(ctx *easierweb.Context) string {
+			return ctx.ClientIP()
+		}
+	}
+	if opt.Store == nil {
+		opt.Store = NewMemoryRateLimitStore()
+	}
+	if opt.ErrorHandle == nil {
+		opt.ErrorHandle = func(ctx *easierweb.Context, result RateLimitResult) {
+			ctx.HandleError(httpError(http.StatusTooManyRequests, "TOO_MANY_REQUESTS", ErrTooManyRequests))
+		}
+	}
+	limit := strconv.Itoa(opt.Burst)
+	return func(ctx *easierweb.Context) {
+		result, err := opt.Store.Take(opt.KeyFunc(ctx), opt.Rate, opt.Burst)
+		if err != nil {
+			// the request is allowed if the store is unavailable
+			ctx.Logger.Error("rate limit store error: " + err.Error())
+			ctx.Next()
+			return
+		}
+		ctx.SetHeader("X-RateLimit-Limit", limit)
+		ctx.SetHeader("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		ctx.SetHeader("X-RateLimit-Reset", strconv.FormatInt(ceilSeconds(result.Reset), 10))
+		if !result.Allowed {
+			ctx.SetHeader("Retry-After", strconv.FormatInt(ceilSeconds(result.RetryAfter), 10))
+			opt.ErrorHandle(ctx, result)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// RateLimitByHeader use the header value as the key, e.g. RateLimitByHeader("X-API-Key")
+func RateLimitByHeader(name string) func(ctx *easierweb.Context) string {
+	return func(ctx *easierweb.Context) string {
+		return ctx.Request.Header.Get(name)
+	}
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+// MemoryRateLimitStore the in-memory token buckets, idle buckets are removed periodically
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (m *MemoryRateLimitStore) Take(key string, rate float64, burst int) (RateLimitResult, error) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) > time.Minute {
+		m.sweep(now, rate, burst)
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(burst), last: now}
+		m.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	result := RateLimitResult{}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else if rate > 0 {
+		result.RetryAfter = time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	} else {
+		result.RetryAfter = time.Hour
+	}
+	result.Remaining = int(b.tokens)
+	if rate > 0 {
+		result.Reset = time.Duration((float64(burst) - b.tokens) / rate * float64(time.Second))
+	}
+	return result, nil
+}
+
+// sweep remove the buckets that have been refilled
+func (m *MemoryRateLimitStore) sweep(now time.Time, rate float64, burst int) {
+	m.lastSweep = now
+	for k, b := range m.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*rate >= float64(burst) {
+			delete(m.buckets, k)
+		}
+	}
+}
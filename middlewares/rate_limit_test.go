@@ -0,0 +1,58 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rate limit test
+
+func TestRateLimit(t *testing.T) {
+
+	fmt.Println("\n[TestRateLimit] start")
+
+	router := easierweb.New(easierweb.RouterOptions{CloseConsolePrint: true}).Use(RateLimit(RateLimitOptions{Rate: 10, Burst: 3}))
+	router.GET("/test", func(ctx *easierweb.Context) {
+		ctx.WriteString(http.StatusOK, "ok")
+	})
+	do := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// the burst is allowed, then the bucket is exhausted
+	for i, expect := range []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		rec := do("10.0.0.1:1000")
+		fmt.Println("[TestRateLimit] result ->", i, rec.Code, rec.Header().Get("X-RateLimit-Remaining"), rec.Header().Get("Retry-After"))
+		if rec.Code != expect {
+			t.Fatal("rate limit does not match")
+		}
+		if expect == http.StatusTooManyRequests && (rec.Header().Get("Retry-After") != "1" || !strings.Contains(rec.Body.String(), `"code":"TOO_MANY_REQUESTS"`)) {
+			t.Fatal("the rejected response does not match: " + rec.Body.String())
+		}
+	}
+	if rec := do("10.0.0.1:1000"); rec.Header().Get("X-RateLimit-Limit") != "3" || rec.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatal("rate limit headers do not match")
+	}
+
+	// the buckets are per client ip
+	if rec := do("10.0.0.2:1000"); rec.Code != http.StatusOK {
+		t.Fatal("the bucket is shared between the clients")
+	}
+
+	// a token is added every 100ms
+	time.Sleep(150 * time.Millisecond)
+	if rec := do("10.0.0.1:1000"); rec.Code != http.StatusOK {
+		t.Fatal("the bucket is not refilled")
+	}
+
+	fmt.Println("\n[TestRateLimit] end")
+}
@@ -0,0 +1,71 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/dpwgc/easierweb"
+)
+
+type RequestIDOptions struct {
+	// the request and response header, default X-Request-ID
+	Header string
+	// generate the request id when the request header is empty or invalid, default uuid v4
+	Generator func() string
+}
+
+// RequestID read the request id from the request header (or generate one), store it in the context and set the response header
+// it can be read by ctx.RequestID() or easierweb.RequestIDFromContext(ctx.Request.Context())
+func RequestID(opts ...RequestIDOptions) easierweb.Handle {
+	opt := RequestIDOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Header == "" {
+		opt.Header = "X-Request-ID"
+	}
+	if opt.Generator == nil {
+		opt.Generator = uuid
+	}
+	return func(ctx *easierweb.Context) {
+		id := ctx.Request.Header.Get(opt.Header)
+		// the id from the client is limited to avoid log injection (e.g. CR/LF), a new one is generated if it is invalid
+		if !validRequestID(id) {
+			id = opt.Generator()
+		}
+		ctx.SetRequestID(id)
+		ctx.SetHeader(opt.Header, id)
+		ctx.Next()
+	}
+}
+
+// validRequestID the id is not empty, at most 128 characters, and only contains the printable ASCII characters (no spaces)
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// uuid generate a random uuid (version 4)
+func uuid() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf[:])
+}
@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// request id test
+
+func TestRequestID(t *testing.T) {
+
+	fmt.Println("\n[TestRequestID] start")
+
+	router := easierweb.New(easierweb.RouterOptions{CloseConsolePrint: true}).Use(RequestID(RequestIDOptions{
+		Generator: func() string { return "generated" },
+	}))
+	router.GET("/test", func(ctx *easierweb.Context) {
+		ctx.WriteString(http.StatusOK, ctx.RequestID())
+	})
+
+	cases := []struct {
+		name string
+		id   string
+		want string
+	}{
+		{"valid", "abc-123_XYZ.1", "abc-123_XYZ.1"},
+		{"empty", "", "generated"},
+		{"too long", strings.Repeat("a", 129), "generated"},
+		// the ids with control characters or spaces are replaced to avoid log injection
+		{"line feed", "abc\nforged log line", "generated"},
+		{"tab", "abc\tdef", "generated"},
+		{"space", "abc def", "generated"},
+		{"non-ascii", "abcé", "generated"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		if c.id != "" {
+			req.Header["X-Request-Id"] = []string{c.id}
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		fmt.Println("[TestRequestID] result ->", c.name, rec.Code, rec.Body.String())
+		if rec.Code != http.StatusOK || rec.Body.String() != c.want || rec.Header().Get("X-Request-ID") != c.want {
+			t.Fatal(c.name + ": request id does not match")
+		}
+	}
+
+	fmt.Println("\n[TestRequestID] end")
+}
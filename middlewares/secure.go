@@ -0,0 +1,90 @@
+package middlewares
+
+import (
+	"github.com/dpwgc/easierweb"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SecureOptions struct {
+	// max-age of the Strict-Transport-Security header, default 365 days, negative disables it
+	// the header is only sent over HTTPS (ctx.IsTLS, X-Forwarded-Proto is only trusted from the RouterOptions.TrustedProxies),
+	// browsers ignore it over HTTP
+	HSTSMaxAge time.Duration
+	// add includeSubDomains to the Strict-Transport-Security header
+	HSTSIncludeSubdomains bool
+	// add preload to the Strict-Transport-Security header
+	HSTSPreload bool
+	// X-Content-Type-Options header, default "nosniff", "-" disables it
+	ContentTypeOptions string
+	// X-Frame-Options header, default "DENY", "-" disables it
+	FrameOptions string
+	// Referrer-Policy header, default "strict-origin-when-cross-origin", "-" disables it
+	ReferrerPolicy string
+	// Content-Security-Policy header, not sent if empty, "{nonce}" is replaced with ctx.CSPNonce(),
+	// e.g. "default-src 'self'; script-src 'self' 'nonce-{nonce}'"
+	ContentSecurityPolicy string
+	// send the policy as Content-Security-Policy-Report-Only (violations are reported but not blocked)
+	CSPReportOnly bool
+}
+
+// Secure set the security headers of the responses, e.g. HSTS, X-Frame-Options and Content-Security-Policy
+func Secure(opts ...SecureOptions) easierweb.Handle {
+	opt := SecureOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.HSTSMaxAge == 0 {
+		opt.HSTSMaxAge = 365 * 24 * time.Hour
+	}
+	hsts := ""
+	if opt.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(opt.HSTSMaxAge/time.Second), 10)
+		if opt.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if opt.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+	headers := [][2]string{
+		{"X-Content-Type-Options", secureHeaderValue(opt.ContentTypeOptions, "nosniff")},
+		{"X-Frame-Options", secureHeaderValue(opt.FrameOptions, "DENY")},
+		{"Referrer-Policy", secureHeaderValue(opt.ReferrerPolicy, "strict-origin-when-cross-origin")},
+	}
+	cspHeader := "Content-Security-Policy"
+	if opt.CSPReportOnly {
+		cspHeader = "Content-Security-Policy-Report-Only"
+	}
+	nonce := strings.Contains(opt.ContentSecurityPolicy, "{nonce}")
+	return func(ctx *easierweb.Context) {
+		for _, h := range headers {
+			if h[1] != "" {
+				ctx.SetHeader(h[0], h[1])
+			}
+		}
+		if hsts != "" && ctx.IsTLS() {
+			ctx.SetHeader("Strict-Transport-Security", hsts)
+		}
+		if opt.ContentSecurityPolicy != "" {
+			csp := opt.ContentSecurityPolicy
+			if nonce {
+				csp = strings.ReplaceAll(csp, "{nonce}", ctx.CSPNonce())
+			}
+			ctx.SetHeader(cspHeader, csp)
+		}
+		ctx.Next()
+	}
+}
+
+// secureHeaderValue the default value if it is empty, "-" means the header is disabled
+func secureHeaderValue(value string, def string) string {
+	if value == "-" {
+		return ""
+	}
+	if value == "" {
+		return def
+	}
+	return value
+}
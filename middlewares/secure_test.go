@@ -0,0 +1,56 @@
+package middlewares
+
+import (
+	"crypto/tls"
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// secure test
+
+func TestSecureHSTS(t *testing.T) {
+
+	fmt.Println("\n[TestSecureHSTS] start")
+
+	cases := []struct {
+		name    string
+		proxies []string
+		tls     bool
+		proto   string
+		hsts    string
+	}{
+		{"tls", nil, true, "", "max-age=31536000"},
+		{"http", nil, false, "", ""},
+		// the forwarded header of the untrusted clients can be forged
+		{"untrusted forwarded", nil, false, "https", ""},
+		{"untrusted proxy forwarded", []string{"10.0.0.1"}, false, "https", ""},
+		{"trusted proxy forwarded", []string{"192.0.2.0/24"}, false, "https", "max-age=31536000"},
+		{"trusted proxy http", []string{"192.0.2.0/24"}, false, "http", ""},
+	}
+	for _, c := range cases {
+		router := easierweb.New(easierweb.RouterOptions{CloseConsolePrint: true, TrustedProxies: c.proxies}).Use(Secure())
+		router.GET("/test", func(ctx *easierweb.Context) {
+			ctx.WriteString(http.StatusOK, "ok")
+		})
+		// the remote address of the test request is 192.0.2.1:1234
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		if c.tls {
+			req.TLS = &tls.ConnectionState{}
+		}
+		if c.proto != "" {
+			req.Header.Set("X-Forwarded-Proto", c.proto)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		hsts := rec.Header().Get("Strict-Transport-Security")
+		fmt.Println("[TestSecureHSTS] result ->", c.name, rec.Code, hsts)
+		if rec.Code != http.StatusOK || hsts != c.hsts || rec.Header().Get("X-Frame-Options") != "DENY" {
+			t.Fatal(c.name + ": secure headers do not match")
+		}
+	}
+
+	fmt.Println("\n[TestSecureHSTS] end")
+}
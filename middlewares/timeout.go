@@ -0,0 +1,184 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type TimeoutOptions struct {
+	// deadline of the request, default 30 seconds
+	Timeout time.Duration
+	// status code written when the deadline is exceeded, default 503
+	Code int
+	// body written when the deadline is exceeded, default {"msg":"request timeout"}
+	Body        []byte
+	ContentType string
+}
+
+// Timeout cancel the request context when the deadline is exceeded, and write the timeout response
+// the response of the handle is buffered, writes after the deadline are discarded
+// the context is pooled, so the middleware still waits for the handle to return (the handle should respect ctx.Request.Context())
+// websocket and server-sent events requests are not affected
+func Timeout(opts ...TimeoutOptions) easierweb.Handle {
+	opt := TimeoutOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Timeout <= 0 {
+		opt.Timeout = 30 * time.Second
+	}
+	if opt.Code == 0 {
+		opt.Code = http.StatusServiceUnavailable
+	}
+	if opt.Body == nil {
+		opt.Body = []byte(`{"msg":"request timeout"}`)
+		opt.ContentType = "application/json; charset=utf-8"
+	}
+	return func(ctx *easierweb.Context) {
+		if ctx.IsWebsocket() || ctx.Flusher != nil {
+			ctx.Next()
+			return
+		}
+
+		tc, cancel := context.WithTimeout(ctx.Request.Context(), opt.Timeout)
+		defer cancel()
+
+		req, res := ctx.Request, ctx.ResponseWriter
+		tw := &timeoutWriter{header: make(http.Header)}
+		ctx.Request = req.WithContext(tc)
+		ctx.ResponseWriter = tw
+
+		done := make(chan struct{})
+		var p any
+		go func() {
+			defer func() {
+				p = recover()
+				tw.finish()
+				close(done)
+			}()
+			ctx.Next()
+		}()
+
+		// the writer is marked as timed out as soon as the deadline is exceeded
+		expired := make(chan struct{})
+		stop := context.AfterFunc(tc, func() {
+			if errors.Is(tc.Err(), context.DeadlineExceeded) && tw.timeout() {
+				close(expired)
+			}
+		})
+		defer stop()
+
+		select {
+		case <-done:
+		case <-expired:
+		}
+		// the handle returned after the deadline (e.g. it stopped on the cancellation), the buffered response is discarded
+		timedOut := tw.isTimedOut() || errors.Is(tc.Err(), context.DeadlineExceeded)
+		if timedOut {
+			if opt.ContentType != "" {
+				res.Header().Set("Content-Type", opt.ContentType)
+			}
+			res.Header().Set("Content-Length", strconv.Itoa(len(opt.Body)))
+			res.WriteHeader(opt.Code)
+			_, _ = res.Write(opt.Body)
+			if f, ok := res.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+		<-done
+
+		ctx.Request, ctx.ResponseWriter = req, res
+		if timedOut {
+			// the timeout response has been written, the panic is only logged
+			if p != nil {
+				ctx.Logger.Error(fmt.Sprintf("panic after request timeout: %v", p))
+			}
+			ctx.Code = opt.Code
+			ctx.Result = opt.Body
+			return
+		}
+		if p != nil {
+			panic(p)
+		}
+		tw.flushTo(res)
+	}
+}
+
+// timeoutWriter buffer the response until the handle returns
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	code     int
+	buf      bytes.Buffer
+	timedOut bool
+	finished bool
+}
+
+func (t *timeoutWriter) Header() http.Header {
+	return t.header
+}
+
+func (t *timeoutWriter) WriteHeader(code int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.code == 0 {
+		t.code = code
+	}
+}
+
+func (t *timeoutWriter) Write(data []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// discarded silently, the timeout response has been written
+	if t.timedOut {
+		return len(data), nil
+	}
+	if t.code == 0 {
+		t.code = http.StatusOK
+	}
+	return t.buf.Write(data)
+}
+
+// timeout mark the writer as timed out, returns false if the handle has already returned
+func (t *timeoutWriter) timeout() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.finished {
+		return false
+	}
+	t.timedOut = true
+	return true
+}
+
+func (t *timeoutWriter) isTimedOut() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.timedOut
+}
+
+func (t *timeoutWriter) finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.finished = true
+}
+
+func (t *timeoutWriter) flushTo(res http.ResponseWriter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for k, v := range t.header {
+		res.Header()[k] = v
+	}
+	if t.code != 0 {
+		res.WriteHeader(t.code)
+	}
+	if t.buf.Len() > 0 {
+		_, _ = res.Write(t.buf.Bytes())
+	}
+}
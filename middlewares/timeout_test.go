@@ -0,0 +1,56 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// timeout test
+
+func TestTimeout(t *testing.T) {
+
+	fmt.Println("\n[TestTimeout] start")
+
+	router := easierweb.New(easierweb.RouterOptions{CloseConsolePrint: true}).Use(Timeout(TimeoutOptions{Timeout: 100 * time.Millisecond}))
+	router.GET("/fast", func(ctx *easierweb.Context) {
+		ctx.SetHeader("X-Handle", "fast")
+		ctx.WriteString(http.StatusCreated, "fast")
+	})
+	// the handle ignores the cancellation and writes after the deadline
+	router.GET("/slow", func(ctx *easierweb.Context) {
+		time.Sleep(200 * time.Millisecond)
+		ctx.SetHeader("X-Handle", "slow")
+		ctx.WriteString(http.StatusOK, "slow")
+	})
+	// the handle stops on the cancellation
+	router.GET("/cancel", func(ctx *easierweb.Context) {
+		<-ctx.Request.Context().Done()
+		ctx.WriteString(http.StatusOK, ctx.Request.Context().Err().Error())
+	})
+
+	cases := []struct {
+		path   string
+		code   int
+		body   string
+		handle string
+	}{
+		{"/fast", http.StatusCreated, "fast", "fast"},
+		{"/slow", http.StatusServiceUnavailable, `{"msg":"request timeout"}`, ""},
+		{"/cancel", http.StatusServiceUnavailable, `{"msg":"request timeout"}`, ""},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		start := time.Now()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.path, nil))
+		fmt.Println("[TestTimeout] result ->", c.path, rec.Code, rec.Body.String(), time.Since(start))
+		if rec.Code != c.code || rec.Body.String() != c.body || rec.Header().Get("X-Handle") != c.handle {
+			t.Fatal(c.path + ": timeout response does not match")
+		}
+	}
+
+	fmt.Println("\n[TestTimeout] end")
+}
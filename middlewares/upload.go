@@ -0,0 +1,17 @@
+package middlewares
+
+import (
+	"github.com/dpwgc/easierweb"
+)
+
+// Upload validate the uploaded files of the multipart form, the request is aborted with 413 or 415 (problem+json) if the limits are exceeded
+func Upload(opt easierweb.UploadOptions) easierweb.Handle {
+	return func(ctx *easierweb.Context) {
+		err := ctx.ValidateUploads(opt)
+		if err != nil {
+			ctx.AbortWithError(err)
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,99 @@
+package easierweb
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ServeHTTP the router can be used as a http.Handler
+func (r *Router) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if r.caseInsensitive {
+		if handle, _, _ := r.router.Lookup(req.Method, req.URL.Path); handle == nil {
+			// the path of the other methods is also fixed, so that the request is answered with 405 (and Allow)
+			path, ok := r.caseInsensitivePath(req.Method, req.URL.Path)
+			if !ok {
+				path, ok = r.caseInsensitivePath("", req.URL.Path)
+			}
+			if ok {
+				req.URL.Path = path
+				req.URL.RawPath = ""
+			}
+		}
+	}
+	if r.autoHEAD && req.Method == MethodHEAD && r.serveHEAD(res, req) {
+		return
+	}
+	r.router.ServeHTTP(res, req)
+}
+
+// caseInsensitivePath find the route that matches the path case-insensitively, and returns the path with the static segments of the route
+// the routes of all methods are matched if the method is empty
+func (r *Router) caseInsensitivePath(method, path string) (string, bool) {
+	segments := strings.Split(path, "/")
+	for _, rt := range r.routes {
+		if method != "" && rt.method != method && !(method == MethodHEAD && (rt.kind == routeKindStatic || (r.autoHEAD && rt.method == MethodGET))) {
+			continue
+		}
+		patterns := strings.Split(rt.path, "/")
+		fixed := make([]string, 0, len(segments))
+		matched := true
+		for i, p := range patterns {
+			if strings.HasPrefix(p, "*") {
+				// the catch-all segment matches the rest of the path
+				fixed = append(fixed, segments[min(i, len(segments)):]...)
+				break
+			}
+			if i >= len(segments) || (strings.HasPrefix(p, ":") && segments[i] == "") ||
+				(!strings.HasPrefix(p, ":") && !strings.EqualFold(p, segments[i])) {
+				matched = false
+				break
+			}
+			if strings.HasPrefix(p, ":") {
+				fixed = append(fixed, segments[i])
+			} else {
+				fixed = append(fixed, p)
+			}
+		}
+		if matched && (len(fixed) == len(segments) || strings.HasPrefix(patterns[len(patterns)-1], "*")) {
+			return strings.Join(fixed, "/"), true
+		}
+	}
+	return "", false
+}
+
+// Handle register a http.Handler for all methods, the router middlewares are executed before the handler
+// e.g. router.Handle("/legacy/*path", legacyHandler)
+func (r *Router) Handle(path string, handler http.Handler, middlewares ...Handle) *Router {
+	return r.Any(path, WrapHTTPHandler(handler), middlewares...)
+}
+
+// Mount mount a http.Handler (e.g. another router) at the path prefix, the prefix is stripped from the request path
+// e.g. router.Mount("/admin", adminRouter)
+func (r *Router) Mount(prefix string, handler http.Handler, middlewares ...Handle) *Router {
+	prefix = strings.TrimSuffix(prefix, "/")
+	handle := stripPrefix(r.rootPath+prefix, handler)
+	r.lastRoutes = nil
+	for _, method := range methodNames {
+		if prefix != "" {
+			r.api(method, prefix, handle, nil, middlewares)
+		}
+		r.api(method, prefix+"/*mountpath", handle, nil, middlewares)
+	}
+	return r
+}
+
+func stripPrefix(prefix string, handler http.Handler) Handle {
+	return func(ctx *Context) {
+		req := ctx.Request
+		r2 := new(http.Request)
+		*r2 = *req
+		r2.URL = new(url.URL)
+		*r2.URL = *req.URL
+		r2.URL.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(req.URL.Path, prefix), "/")
+		if req.URL.RawPath != "" {
+			r2.URL.RawPath = "/" + strings.TrimPrefix(strings.TrimPrefix(req.URL.RawPath, prefix), "/")
+		}
+		handler.ServeHTTP(ctx.ResponseWriter, r2)
+	}
+}
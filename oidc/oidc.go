@@ -0,0 +1,442 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"github.com/dpwgc/easierweb/middlewares"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options settings of the OpenID Connect client
+type Options struct {
+	// the issuer url, the endpoints are discovered from {Issuer}/.well-known/openid-configuration
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// the callback url registered at the provider, e.g. "https://app.example.com/auth/callback"
+	RedirectURL string
+	// default ["openid", "profile", "email"]
+	Scopes []string
+	// redirected to after the login if the login url has no return_to parameter, default "/"
+	AfterLogin string
+	// redirected to after the logout, default "/"
+	AfterLogout string
+	// end the session of the provider on logout (the end_session_endpoint), AfterLogout must be an absolute url registered at the provider
+	ProviderLogout bool
+	// tolerance of the exp, nbf and iat claims of the ID token, default 1 minute
+	Leeway time.Duration
+	// client of the discovery and token requests, default timeout 10 seconds
+	HTTPClient *http.Client
+	// called when the login fails, default the *easierweb.Error passed to the RouterOptions.ErrorHandle
+	ErrorHandle func(ctx *easierweb.Context, err error)
+}
+
+// Identity the user logged in, it is stored in the session
+type Identity struct {
+	Subject string
+	Email   string
+	Name    string
+	// claims of the ID token
+	Claims map[string]any
+}
+
+// Client the OpenID Connect client, it logs in the users by the authorization code flow with PKCE
+// the session middleware (easierweb.SessionManager) is required, and the SameSite of the session cookie must not be Strict
+type Client struct {
+	opt       Options
+	loginPath string
+	lock      sync.Mutex
+	provider  *provider
+	// the in-flight discovery, closed when it is completed
+	discovering chan struct{}
+	// the last failed discovery, it is not retried within discoverRetry
+	discoverErr error
+	failedAt    time.Time
+}
+
+// discoverRetry the minimum interval of retrying the failed discovery
+const discoverRetry = 5 * time.Second
+
+type provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+	parse                 func(token string) (map[string]any, error)
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// session keys
+const (
+	keyState    = "oidc.state"
+	keyNonce    = "oidc.nonce"
+	keyVerifier = "oidc.verifier"
+	keyReturnTo = "oidc.return_to"
+	keySubject  = "oidc.sub"
+	keyEmail    = "oidc.email"
+	keyName     = "oidc.name"
+	keyClaims   = "oidc.claims"
+	keyIDToken  = "oidc.id_token"
+)
+
+var (
+	ErrSessionRequired = errors.New("the session middleware is required")
+	ErrInvalidState    = errors.New("invalid login state")
+	ErrInvalidNonce    = errors.New("invalid ID token nonce")
+	ErrMissingIDToken  = errors.New("missing ID token")
+)
+
+func NewClient(opt Options) *Client {
+	opt.Issuer = strings.TrimSuffix(opt.Issuer, "/")
+	if len(opt.Scopes) == 0 {
+		opt.Scopes = []string{"openid", "profile", "email"}
+	}
+	if opt.AfterLogin == "" {
+		opt.AfterLogin = "/"
+	}
+	if opt.AfterLogout == "" {
+		opt.AfterLogout = "/"
+	}
+	if opt.Leeway <= 0 {
+		opt.Leeway = time.Minute
+	}
+	if opt.HTTPClient == nil {
+		opt.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opt.ErrorHandle == nil {
+		opt.ErrorHandle = func(ctx *easierweb.Context, err error) {
+			ctx.HandleError(err)
+		}
+	}
+	return &Client{opt: opt, loginPath: "/login"}
+}
+
+// Register register the /login, /callback and /logout routes to the group, e.g. client.Register(router.Group("/auth", sessions.Middleware()))
+// the login url accepts the return_to parameter (a local path), e.g. /auth/login?return_to=/dashboard
+func (c *Client) Register(group *easierweb.Group) {
+	c.loginPath = group.Path() + "/login"
+	group.GET("/login", c.Login)
+	group.GET("/callback", c.Callback)
+	group.GET("/logout", c.Logout)
+}
+
+// Middleware require the login, the GET requests are redirected to the login url, others are 401
+// the principal (ctx.Principal()) is the subject, the claims of the ID token can be read by ctx.Claims()
+func (c *Client) Middleware() easierweb.Handle {
+	return func(ctx *easierweb.Context) {
+		identity := CurrentIdentity(ctx)
+		if identity == nil {
+			if ctx.Request.Method == http.MethodGet {
+				ctx.Redirect(http.StatusFound, c.loginPath+"?return_to="+url.QueryEscape(ctx.Request.URL.RequestURI()))
+			} else {
+				ctx.HandleError(easierweb.NewError(http.StatusUnauthorized, "login required").WithCode("UNAUTHORIZED"))
+			}
+			ctx.Abort()
+			return
+		}
+		ctx.SetPrincipal(identity.Subject)
+		ctx.SetClaims(identity.Claims)
+		ctx.Next()
+	}
+}
+
+// CurrentIdentity get the identity of the session, returns nil if not logged in
+func CurrentIdentity(ctx *easierweb.Context) *Identity {
+	session := ctx.Session()
+	if session == nil {
+		return nil
+	}
+	sub, _ := session.Get(keySubject).(string)
+	if sub == "" {
+		return nil
+	}
+	identity := &Identity{Subject: sub}
+	identity.Email, _ = session.Get(keyEmail).(string)
+	identity.Name, _ = session.Get(keyName).(string)
+	identity.Claims, _ = session.Get(keyClaims).(map[string]any)
+	return identity
+}
+
+// Login redirect to the authorization endpoint of the provider
+func (c *Client) Login(ctx *easierweb.Context) {
+	session := ctx.Session()
+	if session == nil {
+		c.opt.ErrorHandle(ctx, easierweb.NewError(http.StatusInternalServerError, "internal server error").Wrap(ErrSessionRequired))
+		return
+	}
+	p, err := c.discover()
+	if err != nil {
+		c.opt.ErrorHandle(ctx, easierweb.NewError(http.StatusBadGateway, "openid provider is unavailable").Wrap(err))
+		return
+	}
+	state, nonce, verifier := randomString(), randomString(), randomString()
+	session.Set(keyState, state)
+	session.Set(keyNonce, nonce)
+	session.Set(keyVerifier, verifier)
+	session.Set(keyReturnTo, c.returnTo(ctx.Query.Get("return_to")))
+	err = session.Save()
+	if err != nil {
+		c.opt.ErrorHandle(ctx, easierweb.NewError(http.StatusInternalServerError, "save session failed").Wrap(err))
+		return
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.opt.ClientID},
+		"redirect_uri":          {c.opt.RedirectURL},
+		"scope":                 {strings.Join(c.opt.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	ctx.Redirect(http.StatusFound, p.AuthorizationEndpoint+querySeparator(p.AuthorizationEndpoint)+query.Encode())
+}
+
+// Callback exchange the authorization code for the ID token, validate it and store the identity in the session
+func (c *Client) Callback(ctx *easierweb.Context) {
+	returnTo, err := c.callback(ctx)
+	if err != nil {
+		var e *easierweb.Error
+		if !errors.As(err, &e) {
+			e = easierweb.NewError(http.StatusUnauthorized, "login failed").WithCode("LOGIN_FAILED").Wrap(err)
+		}
+		// the state is removed even if the login fails
+		if session := ctx.Session(); session != nil {
+			_ = session.Save()
+		}
+		c.opt.ErrorHandle(ctx, e)
+		return
+	}
+	ctx.Redirect(http.StatusFound, returnTo)
+}
+
+func (c *Client) callback(ctx *easierweb.Context) (string, error) {
+	session := ctx.Session()
+	if session == nil {
+		return "", easierweb.NewError(http.StatusInternalServerError, "internal server error").Wrap(ErrSessionRequired)
+	}
+	if e := ctx.Query.Get("error"); e != "" {
+		return "", fmt.Errorf("%s: %s", e, ctx.Query.Get("error_description"))
+	}
+	state, _ := session.Get(keyState).(string)
+	nonce, _ := session.Get(keyNonce).(string)
+	verifier, _ := session.Get(keyVerifier).(string)
+	returnTo, _ := session.Get(keyReturnTo).(string)
+	// the state is used once
+	session.Delete(keyState)
+	session.Delete(keyNonce)
+	session.Delete(keyVerifier)
+	session.Delete(keyReturnTo)
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(ctx.Query.Get("state"))) != 1 {
+		return "", ErrInvalidState
+	}
+	p, err := c.discover()
+	if err != nil {
+		return "", easierweb.NewError(http.StatusBadGateway, "openid provider is unavailable").Wrap(err)
+	}
+	idToken, err := c.exchange(ctx, p, ctx.Query.Get("code"), verifier)
+	if err != nil {
+		return "", err
+	}
+	claims, err := p.parse(idToken)
+	if err != nil {
+		return "", err
+	}
+	if claimNonce, _ := claims["nonce"].(string); subtle.ConstantTimeCompare([]byte(claimNonce), []byte(nonce)) != 1 {
+		return "", ErrInvalidNonce
+	}
+	identity := &Identity{Claims: claims}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	if identity.Subject == "" {
+		return "", errors.New("missing sub claim")
+	}
+	// change the session id on login (session fixation)
+	session.Regenerate()
+	session.Set(keySubject, identity.Subject)
+	session.Set(keyEmail, identity.Email)
+	session.Set(keyName, identity.Name)
+	session.Set(keyClaims, claims)
+	session.Set(keyIDToken, idToken)
+	err = session.Save()
+	if err != nil {
+		return "", easierweb.NewError(http.StatusInternalServerError, "save session failed").Wrap(err)
+	}
+	if returnTo == "" {
+		returnTo = c.opt.AfterLogin
+	}
+	return returnTo, nil
+}
+
+// Logout remove the identity from the session, and end the session of the provider if ProviderLogout is enabled
+func (c *Client) Logout(ctx *easierweb.Context) {
+	redirect := c.opt.AfterLogout
+	session := ctx.Session()
+	if session != nil {
+		idToken, _ := session.Get(keyIDToken).(string)
+		session.Destroy()
+		err := session.Save()
+		if err != nil {
+			c.opt.ErrorHandle(ctx, easierweb.NewError(http.StatusInternalServerError, "save session failed").Wrap(err))
+			return
+		}
+		if c.opt.ProviderLogout && idToken != "" {
+			p, err := c.discover()
+			if err == nil && p.EndSessionEndpoint != "" {
+				query := url.Values{
+					"id_token_hint":            {idToken},
+					"post_logout_redirect_uri": {c.opt.AfterLogout},
+					"client_id":                {c.opt.ClientID},
+				}
+				redirect = p.EndSessionEndpoint + querySeparator(p.EndSessionEndpoint) + query.Encode()
+			}
+		}
+	}
+	ctx.Redirect(http.StatusFound, redirect)
+}
+
+// exchange the authorization code for the tokens (client_secret_basic)
+func (c *Client) exchange(ctx *easierweb.Context, p *provider, code string, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.opt.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx.Request.Context(), http.MethodPost, p.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.opt.ClientID), url.QueryEscape(c.opt.ClientSecret))
+	res, err := c.opt.HTTPClient.Do(req)
+	if err != nil {
+		return "", easierweb.NewError(http.StatusBadGateway, "openid provider is unavailable").Wrap(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	var token tokenResponse
+	err = json.Unmarshal(body, &token)
+	if err != nil {
+		return "", fmt.Errorf("token response: %s", res.Status)
+	}
+	if token.Error != "" {
+		return "", fmt.Errorf("%s: %s", token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return "", ErrMissingIDToken
+	}
+	return token.IDToken, nil
+}
+
+// discover fetch the provider metadata, it is cached after the first success
+// the metadata is fetched without holding the lock, the concurrent requests wait for the same fetch, the failures are retried at most once every 5 seconds
+func (c *Client) discover() (*provider, error) {
+	c.lock.Lock()
+	if c.provider != nil {
+		defer c.lock.Unlock()
+		return c.provider, nil
+	}
+	if done := c.discovering; done != nil {
+		c.lock.Unlock()
+		<-done
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		if c.provider != nil {
+			return c.provider, nil
+		}
+		return nil, c.discoverErr
+	}
+	if c.discoverErr != nil && time.Since(c.failedAt) < discoverRetry {
+		defer c.lock.Unlock()
+		return nil, c.discoverErr
+	}
+	done := make(chan struct{})
+	c.discovering = done
+	c.lock.Unlock()
+
+	p, err := c.fetchProvider()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err != nil {
+		c.discoverErr, c.failedAt = err, time.Now()
+	} else {
+		c.provider, c.discoverErr = p, nil
+	}
+	c.discovering = nil
+	close(done)
+	return p, err
+}
+
+func (c *Client) fetchProvider() (*provider, error) {
+	res, err := c.opt.HTTPClient.Get(c.opt.Issuer + "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch openid configuration failed: %s", res.Status)
+	}
+	p := &provider{}
+	err = json.NewDecoder(res.Body).Decode(p)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(p.Issuer, "/") != c.opt.Issuer {
+		return nil, fmt.Errorf("issuer mismatch: %s", p.Issuer)
+	}
+	if p.AuthorizationEndpoint == "" || p.TokenEndpoint == "" || p.JWKSURI == "" {
+		return nil, errors.New("incomplete openid configuration")
+	}
+	p.parse = middlewares.JWTParser(middlewares.JWTOptions{
+		JWKSURL:  p.JWKSURI,
+		Issuer:   p.Issuer,
+		Audience: c.opt.ClientID,
+		Leeway:   c.opt.Leeway,
+	})
+	return p, nil
+}
+
+// returnTo only the local paths are allowed (open redirect)
+func (c *Client) returnTo(value string) string {
+	if !strings.HasPrefix(value, "/") || strings.HasPrefix(value, "//") || strings.HasPrefix(value, "/\\") {
+		return c.opt.AfterLogin
+	}
+	return value
+}
+
+func randomString() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func querySeparator(endpoint string) string {
+	if strings.Contains(endpoint, "?") {
+		return "&"
+	}
+	return "?"
+}
@@ -0,0 +1,341 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// oidc test
+
+func TestOIDC(t *testing.T) {
+
+	fmt.Println("\n[TestOIDC] start")
+
+	p := newTestProvider(t)
+	defer p.server.Close()
+	router := newTestRouter(p.server.URL)
+
+	// login, the state, nonce and PKCE challenge are sent to the provider
+	b := &testBrowser{router: router, cookies: map[string]*http.Cookie{}}
+	res := b.get("/auth/login?return_to=/dashboard?tab=1")
+	authorize := b.location(t, res)
+	fmt.Println("[TestOIDC] authorize ->", authorize.String())
+	query := authorize.Query()
+	if query.Get("state") == "" || query.Get("nonce") == "" || query.Get("code_challenge_method") != "S256" || query.Get("client_id") != "client" {
+		t.Fatal("authorization request does not match")
+	}
+	sessionBefore := b.cookies["session_id"].Value
+
+	// callback, the code is exchanged with the verifier and the ID token is validated
+	code := p.issue(query.Get("nonce"), query.Get("code_challenge"))
+	res = b.get("/auth/callback?code=" + code + "&state=" + query.Get("state"))
+	if location := b.location(t, res); location.String() != "/dashboard?tab=1" {
+		t.Fatal("return_to does not match: " + location.String())
+	}
+	if b.cookies["session_id"].Value == sessionBefore {
+		t.Fatal("the session id is not changed on login")
+	}
+	res = b.get("/me")
+	fmt.Println("[TestOIDC] me ->", res.Code, res.Body.String())
+	if res.Code != http.StatusOK || res.Body.String() != "user-1" {
+		t.Fatal("the identity is not stored in the session")
+	}
+
+	// the state is used once
+	res = b.get("/auth/callback?code=" + code + "&state=" + query.Get("state"))
+	fmt.Println("[TestOIDC] replay ->", res.Code)
+	if res.Code != http.StatusUnauthorized {
+		t.Fatal("the state is replayed")
+	}
+
+	// logout
+	b.get("/auth/logout")
+	if res = b.get("/me"); res.Code != http.StatusFound {
+		t.Fatal("the identity is not removed on logout")
+	}
+
+	fmt.Println("\n[TestOIDC] end")
+}
+
+// oidc callback validation test
+
+func TestOIDCCallbackRejected(t *testing.T) {
+
+	fmt.Println("\n[TestOIDCCallbackRejected] start")
+
+	p := newTestProvider(t)
+	defer p.server.Close()
+	router := newTestRouter(p.server.URL)
+
+	cases := []struct {
+		name     string
+		callback func(query url.Values) string
+	}{
+		{"invalid state", func(query url.Values) string {
+			return "/auth/callback?code=" + p.issue(query.Get("nonce"), query.Get("code_challenge")) + "&state=forged"
+		}},
+		{"missing state", func(query url.Values) string {
+			return "/auth/callback?code=" + p.issue(query.Get("nonce"), query.Get("code_challenge"))
+		}},
+		{"invalid nonce", func(query url.Values) string {
+			return "/auth/callback?code=" + p.issue("other", query.Get("code_challenge")) + "&state=" + query.Get("state")
+		}},
+		// the code is bound to the challenge of another login (PKCE)
+		{"invalid verifier", func(query url.Values) string {
+			return "/auth/callback?code=" + p.issue(query.Get("nonce"), "other") + "&state=" + query.Get("state")
+		}},
+		{"provider error", func(query url.Values) string {
+			return "/auth/callback?error=access_denied&state=" + query.Get("state")
+		}},
+	}
+	for _, c := range cases {
+		b := &testBrowser{router: router, cookies: map[string]*http.Cookie{}}
+		query := b.location(t, b.get("/auth/login")).Query()
+		res := b.get(c.callback(query))
+		fmt.Println("[TestOIDCCallbackRejected] result ->", c.name, res.Code, res.Body.String())
+		if res.Code != http.StatusUnauthorized {
+			t.Fatal(c.name + ": the login is not rejected")
+		}
+		if b.get("/me").Code != http.StatusFound {
+			t.Fatal(c.name + ": the identity is stored")
+		}
+	}
+
+	fmt.Println("\n[TestOIDCCallbackRejected] end")
+}
+
+// oidc return_to test
+
+func TestOIDCReturnTo(t *testing.T) {
+
+	fmt.Println("\n[TestOIDCReturnTo] start")
+
+	client := NewClient(Options{AfterLogin: "/home"})
+	cases := map[string]string{
+		"/dashboard":             "/dashboard",
+		"/a?b=c":                 "/a?b=c",
+		"":                       "/home",
+		"https://evil.com":       "/home",
+		"//evil.com":             "/home",
+		"/\\evil.com":            "/home",
+		"javascript:alert(1)":    "/home",
+		"evil.com/path":          "/home",
+		"\\\\evil.com":           "/home",
+		"https:/\\evil.com/path": "/home",
+	}
+	for value, expect := range cases {
+		result := client.returnTo(value)
+		fmt.Println("[TestOIDCReturnTo] result ->", value, result)
+		if result != expect {
+			t.Fatal("return_to does not match: " + value)
+		}
+	}
+
+	fmt.Println("\n[TestOIDCReturnTo] end")
+}
+
+// oidc discovery test
+
+func TestOIDCDiscovery(t *testing.T) {
+
+	fmt.Println("\n[TestOIDCDiscovery] start")
+
+	var fetches atomic.Int32
+	var down atomic.Bool
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 "http://" + r.Host,
+			"authorization_endpoint": "http://" + r.Host + "/authorize",
+			"token_endpoint":         "http://" + r.Host + "/token",
+			"jwks_uri":               "http://" + r.Host + "/jwks",
+		})
+	}))
+	defer server.Close()
+
+	// the concurrent requests wait for the same fetch
+	client := NewClient(Options{Issuer: server.URL, ClientID: "client"})
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.discover()
+			errs <- err
+		}()
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	fmt.Println("[TestOIDCDiscovery] fetches ->", fetches.Load())
+	if fetches.Load() != 1 {
+		t.Fatal("the discovery is fetched more than once")
+	}
+
+	// the failed discovery is not retried on every request
+	down.Store(true)
+	fetches.Store(0)
+	client = NewClient(Options{Issuer: server.URL, ClientID: "client"})
+	for i := 0; i < 5; i++ {
+		if _, err := client.discover(); err == nil {
+			t.Fatal("the discovery error is not reported")
+		}
+	}
+	fmt.Println("[TestOIDCDiscovery] failed fetches ->", fetches.Load())
+	if fetches.Load() != 1 {
+		t.Fatal("the failed discovery is retried immediately")
+	}
+
+	fmt.Println("\n[TestOIDCDiscovery] end")
+}
+
+func newTestRouter(issuer string) *easierweb.Router {
+	client := NewClient(Options{
+		Issuer:       issuer,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://app.example.com/auth/callback",
+	})
+	sessions := easierweb.NewSessionManager(easierweb.SessionOptions{CookieSameSite: http.SameSiteLaxMode})
+	router := easierweb.New(easierweb.RouterOptions{CloseConsolePrint: true}).Use(sessions.Middleware())
+	client.Register(router.Group("/auth"))
+	router.GET("/me", func(ctx *easierweb.Context) {
+		ctx.WriteString(http.StatusOK, ctx.Principal())
+	}, client.Middleware())
+	return router
+}
+
+// testProvider the OpenID provider that issues the codes bound to the nonce and the PKCE challenge
+type testProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	mu     sync.Mutex
+	codes  map[string][2]string
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &testProvider{key: key, codes: map[string][2]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kid": "k1",
+			"kty": "RSA",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		p.mu.Lock()
+		issued, ok := p.codes[r.PostFormValue("code")]
+		delete(p.codes, r.PostFormValue("code"))
+		p.mu.Unlock()
+		verifier := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if id != "client" || secret != "secret" || !ok || base64.RawURLEncoding.EncodeToString(verifier[:]) != issued[1] {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": p.sign(map[string]any{
+			"iss":   p.server.URL,
+			"aud":   "client",
+			"sub":   "user-1",
+			"nonce": issued[0],
+			"exp":   time.Now().Add(time.Minute).Unix(),
+		})})
+	})
+	p.server = httptest.NewServer(mux)
+	return p
+}
+
+func (p *testProvider) issue(nonce, challenge string) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	code := base64.RawURLEncoding.EncodeToString(b)
+	p.mu.Lock()
+	p.codes[code] = [2]string{nonce, challenge}
+	p.mu.Unlock()
+	return code
+}
+
+func (p *testProvider) sign(claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// testBrowser send the requests to the router with the cookies
+type testBrowser struct {
+	router  *easierweb.Router
+	cookies map[string]*http.Cookie
+}
+
+func (b *testBrowser) get(uri string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, uri, nil)
+	for _, c := range b.cookies {
+		req.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+	b.router.ServeHTTP(rec, req)
+	for _, c := range rec.Result().Cookies() {
+		if c.MaxAge < 0 {
+			delete(b.cookies, c.Name)
+			continue
+		}
+		b.cookies[c.Name] = c
+	}
+	return rec
+}
+
+func (b *testBrowser) location(t *testing.T, res *httptest.ResponseRecorder) *url.URL {
+	if res.Code != http.StatusFound {
+		t.Fatalf("expect redirect, got %d %s", res.Code, res.Body.String())
+	}
+	u, err := url.Parse(res.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
@@ -0,0 +1,311 @@
+package easierweb
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type OpenAPISpec struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                       `json:"components"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]Schema `json:"schemas,omitempty"`
+}
+
+type OpenAPIOperation struct {
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	OperationID string                      `json:"operationId,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+	Parameters  []OpenAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+type OpenAPIParameter struct {
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required,omitempty"`
+	Schema   Schema `json:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required,omitempty"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema Schema `json:"schema"`
+}
+
+// Schema the json schema object
+type Schema map[string]any
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// OpenAPI generate the OpenAPI 3 document from the registered routes
+// the request and response schemas are generated from the parameter and return types of easy handles
+func (r *Router) OpenAPI(info ...OpenAPIInfo) *OpenAPISpec {
+	spec := &OpenAPISpec{
+		OpenAPI: "3.0.3",
+		Info:    OpenAPIInfo{Title: "API", Version: "1.0.0"},
+		Paths:   make(map[string]map[string]*OpenAPIOperation),
+		Components: OpenAPIComponents{
+			Schemas: make(map[string]Schema),
+		},
+	}
+	if len(info) > 0 {
+		spec.Info = info[0]
+	}
+	for _, rt := range r.routes {
+		if rt.kind != "" {
+			continue
+		}
+		path, params := openAPIPath(rt.path)
+		op := &OpenAPIOperation{
+			Summary:     rt.doc.Summary,
+			Description: rt.doc.Description,
+			OperationID: rt.doc.OperationID,
+			Tags:        rt.doc.Tags,
+			Deprecated:  rt.doc.Deprecated,
+			Parameters:  params,
+			Responses: map[string]*OpenAPIResponse{
+				"200": {Description: "OK"},
+			},
+		}
+		if rt.easyHandle != nil {
+			spec.describeEasyHandle(op, rt.method, rt.easyHandle)
+		}
+		if spec.Paths[path] == nil {
+			spec.Paths[path] = make(map[string]*OpenAPIOperation)
+		}
+		spec.Paths[path][strings.ToLower(rt.method)] = op
+	}
+	return spec
+}
+
+// ServeOpenAPI serve the OpenAPI document (json) on the specPath and the swagger ui page on the docsPath
+func (r *Router) ServeOpenAPI(specPath, docsPath string, info ...OpenAPIInfo) *Router {
+	r.GET(specPath, func(ctx *Context) {
+		ctx.WriteJSON(http.StatusOK, r.OpenAPI(info...))
+	})
+	if docsPath != "" {
+		page := fmt.Sprintf(swaggerUIPage, r.rootPath+specPath)
+		r.GET(docsPath, func(ctx *Context) {
+			ctx.WriteHTML(http.StatusOK, page)
+		})
+	}
+	return r
+}
+
+func (s *OpenAPISpec) describeEasyHandle(op *OpenAPIOperation, method string, easyHandle any) {
+	funcType := reflect.TypeOf(easyHandle)
+	if funcType.Kind() != reflect.Func {
+		return
+	}
+	if reqType := easyRequestType(funcType); reqType != nil {
+		if method == MethodPOST || method == MethodPUT || method == MethodPATCH {
+			op.RequestBody = &OpenAPIRequestBody{
+				Required: true,
+				Content: map[string]OpenAPIMediaType{
+					MediaTypeJSON: {Schema: s.schema(reqType)},
+				},
+			}
+		} else {
+			op.Parameters = append(op.Parameters, s.queryParameters(reqType)...)
+		}
+	}
+	errType := reflect.TypeOf((*error)(nil)).Elem()
+	if funcType.NumOut() == 0 || funcType.Out(0).Implements(errType) {
+		op.Responses = map[string]*OpenAPIResponse{
+			"204": {Description: "No Content"},
+		}
+		return
+	}
+	op.Responses["200"].Content = map[string]OpenAPIMediaType{
+		MediaTypeJSON: {Schema: s.schema(funcType.Out(0))},
+	}
+}
+
+// queryParameters the struct fields as the query parameters (query, header tag name first, then mapstructure)
+func (s *OpenAPISpec) queryParameters(t reflect.Type) []OpenAPIParameter {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var params []OpenAPIParameter
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			params = append(params, s.queryParameters(sf.Type)...)
+			continue
+		}
+		// the path parameters are generated from the route path
+		if sf.Tag.Get("param") != "" {
+			continue
+		}
+		in := "query"
+		name := sf.Tag.Get("query")
+		if header := sf.Tag.Get("header"); header != "" {
+			in, name = "header", header
+		}
+		if name == "" {
+			name, _, _ = strings.Cut(sf.Tag.Get("mapstructure"), ",")
+		}
+		if name == "" {
+			name = fieldName(sf)
+		}
+		if name == "-" {
+			continue
+		}
+		params = append(params, OpenAPIParameter{
+			Name:     name,
+			In:       in,
+			Required: strings.Contains(sf.Tag.Get("validate"), "required"),
+			Schema:   s.schema(sf.Type),
+		})
+	}
+	return params
+}
+
+func (s *OpenAPISpec) schema(t reflect.Type) Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return Schema{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return Schema{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return Schema{"type": "integer", "format": "int64"}
+	case reflect.Float32:
+		return Schema{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return Schema{"type": "number", "format": "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "format": "byte"}
+		}
+		return Schema{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		name := t.Name()
+		if _, ok := s.Components.Schemas[name]; !ok {
+			// placeholder, avoid infinite recursion of self-referencing types
+			s.Components.Schemas[name] = Schema{}
+			s.Components.Schemas[name] = s.structSchema(t)
+		}
+		return Schema{"$ref": "#/components/schemas/" + name}
+	}
+	return Schema{}
+}
+
+func (s *OpenAPISpec) structSchema(t reflect.Type) Schema {
+	properties := make(map[string]Schema)
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		if sf.Tag.Get("json") == "-" {
+			continue
+		}
+		name := fieldName(sf)
+		// embedded struct fields are flattened
+		if sf.Anonymous && sf.Tag.Get("json") == "" {
+			embedded := sf.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				sub := s.structSchema(embedded)
+				for k, v := range sub["properties"].(map[string]Schema) {
+					properties[k] = v
+				}
+				if r, ok := sub["required"].([]string); ok {
+					required = append(required, r...)
+				}
+				continue
+			}
+		}
+		properties[name] = s.schema(sf.Type)
+		if strings.Contains(sf.Tag.Get("validate"), "required") {
+			required = append(required, name)
+		}
+	}
+	schema := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// openAPIPath convert the httprouter path to the OpenAPI path, e.g. /users/:id -> /users/{id}
+func openAPIPath(path string) (string, []OpenAPIParameter) {
+	var params []OpenAPIParameter
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if len(seg) > 1 && (seg[0] == ':' || seg[0] == '*') {
+			params = append(params, OpenAPIParameter{
+				Name:     seg[1:],
+				In:       "path",
+				Required: true,
+				Schema:   Schema{"type": "string"},
+			})
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8"/>
+  <title>API Docs</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css"/>
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>
+  window.ui = SwaggerUIBundle({url: "%s", dom_id: "#swagger-ui"});
+</script>
+</body>
+</html>
+`
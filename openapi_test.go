@@ -0,0 +1,49 @@
+package easierweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// openapi test
+
+func TestOpenAPI(t *testing.T) {
+
+	fmt.Println("\n[TestOpenAPI] start")
+
+	router := New(RouterOptions{
+		RootPath: "/test/openapi",
+	})
+	router.EasyGET("/get/:id", routerTestEasyQueryAPI).Describe(APIDoc{
+		Summary: "query",
+		Tags:    []string{"test"},
+	})
+	router.EasyPOST("/post", routerTestEasySaveAPI)
+	router.EasyDELETE("/delete/:id", routerTestEasyDelAPI)
+
+	spec := router.OpenAPI(OpenAPIInfo{Title: "test", Version: "1.0.0"})
+	marshal, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println("[TestOpenAPI] spec ->", string(marshal))
+
+	get := spec.Paths["/test/openapi/get/{id}"]["get"]
+	if get == nil || get.Summary != "query" || len(get.Parameters) != 7 {
+		t.Fatal("get operation does not match")
+	}
+	post := spec.Paths["/test/openapi/post"]["post"]
+	if post == nil || post.RequestBody == nil || post.Responses["200"].Content == nil {
+		t.Fatal("post operation does not match")
+	}
+	del := spec.Paths["/test/openapi/delete/{id}"]["delete"]
+	if del == nil || del.Responses["204"] == nil {
+		t.Fatal("delete operation does not match")
+	}
+	if _, ok := spec.Components.Schemas["routerTestDTO"]; !ok {
+		t.Fatal("schema is not generated")
+	}
+
+	fmt.Println("\n[TestOpenAPI] end")
+}
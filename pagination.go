@@ -0,0 +1,155 @@
+package easierweb
+
+import (
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type PaginationOptions struct {
+	// the limit if it is not requested, default 20
+	DefaultLimit int
+	// the larger limits are capped, default 100
+	MaxLimit int
+	// the query parameters, default "page", "limit" and "cursor"
+	PageQuery   string
+	LimitQuery  string
+	CursorQuery string
+}
+
+// Pagination the requested page, see ctx.BindPagination
+type Pagination struct {
+	// the page number starts from 1, zero if the cursor is requested
+	Page  int
+	Limit int
+	// the number of the skipped items, (Page - 1) * Limit
+	Offset int
+	// the opaque cursor of the cursor-based pagination, e.g. the last id of the previous page
+	Cursor string
+}
+
+// Page the list response, the Link header (first, prev, next and last) and the X-Total-Count header are set by the response handles
+// e.g. return easierweb.NewPage(users, p, total), nil
+type Page[T any] struct {
+	Items []T `json:"items"`
+	// zero if the cursor is used
+	Page  int `json:"page,omitempty"`
+	Limit int `json:"limit"`
+	// nil if the total is unknown
+	Total      *int64 `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// NewPage create the page of the page-based pagination with the total number of the items
+func NewPage[T any](items []T, p Pagination, total int64) *Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return &Page[T]{Items: items, Page: p.Page, Limit: p.Limit, Total: &total, HasMore: int64(p.Offset+len(items)) < total}
+}
+
+// NewCursorPage create the page of the cursor-based pagination, the next cursor is empty on the last page
+func NewCursorPage[T any](items []T, p Pagination, nextCursor string) *Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return &Page[T]{Items: items, Limit: p.Limit, NextCursor: nextCursor, HasMore: nextCursor != ""}
+}
+
+// paginated the page of any item type
+type paginated interface {
+	pageInfo() (page int, limit int, total *int64, nextCursor string, hasMore bool)
+}
+
+func (p Page[T]) pageInfo() (int, int, *int64, string, bool) {
+	return p.Page, p.Limit, p.Total, p.NextCursor, p.HasMore
+}
+
+// BindPagination parse the page, limit and cursor query parameters (see RouterOptions.Pagination), the limit is capped by MaxLimit
+// it returns a 400 *Error if the page or the limit is not a positive integer, the page is ignored if the cursor is requested
+func (c *Context) BindPagination() (Pagination, error) {
+	opt := c.router.pagination
+	query := c.Request.URL.Query()
+	p := Pagination{Page: 1, Limit: opt.DefaultLimit, Cursor: query.Get(opt.CursorQuery)}
+	if value := query.Get(opt.LimitQuery); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return Pagination{}, NewError(http.StatusBadRequest, "invalid "+opt.LimitQuery+": "+value).WithCode("INVALID_PAGINATION")
+		}
+		p.Limit = min(limit, opt.MaxLimit)
+	}
+	if p.Cursor != "" {
+		p.Page = 0
+		return p, nil
+	}
+	if value := query.Get(opt.PageQuery); value != "" {
+		page, err := strconv.Atoi(value)
+		// the offset must not overflow
+		if err != nil || page < 1 || page > math.MaxInt32/p.Limit {
+			return Pagination{}, NewError(http.StatusBadRequest, "invalid "+opt.PageQuery+": "+value).WithCode("INVALID_PAGINATION")
+		}
+		p.Page = page
+	}
+	p.Offset = (p.Page - 1) * p.Limit
+	return p, nil
+}
+
+// SetPageLinks set the RFC 5988 Link header (first, prev, next and last) and the X-Total-Count header if the result is a Page,
+// the links are the request uri with the page, limit and cursor query parameters replaced, it is used by the response handles
+func (c *Context) SetPageLinks(result any) {
+	p, ok := result.(paginated)
+	if !ok || isNilPointer(result) {
+		return
+	}
+	page, limit, total, nextCursor, hasMore := p.pageInfo()
+	opt := c.router.pagination
+	header := c.ResponseWriter.Header()
+	if total != nil {
+		header.Set("X-Total-Count", strconv.FormatInt(*total, 10))
+	}
+	if limit <= 0 {
+		return
+	}
+	link := func(rel string, page int, cursor string) string {
+		query := c.Request.URL.Query()
+		query.Del(opt.PageQuery)
+		query.Del(opt.CursorQuery)
+		query.Set(opt.LimitQuery, strconv.Itoa(limit))
+		if page > 0 {
+			query.Set(opt.PageQuery, strconv.Itoa(page))
+		}
+		if cursor != "" {
+			query.Set(opt.CursorQuery, cursor)
+		}
+		return "<" + c.Request.URL.EscapedPath() + "?" + query.Encode() + ">; rel=\"" + rel + "\""
+	}
+	var links []string
+	if page == 0 {
+		// the cursor-based pagination, the first page has no cursor
+		links = append(links, link("first", 0, ""))
+		if nextCursor != "" {
+			links = append(links, link("next", 0, nextCursor))
+		}
+	} else {
+		links = append(links, link("first", 1, ""))
+		if page > 1 {
+			links = append(links, link("prev", page-1, ""))
+		}
+		if hasMore {
+			links = append(links, link("next", page+1, ""))
+		}
+		if total != nil {
+			last := max(1, int((*total+int64(limit)-1)/int64(limit)))
+			links = append(links, link("last", last, ""))
+		}
+	}
+	header.Set("Link", strings.Join(links, ", "))
+}
+
+func isNilPointer(v any) bool {
+	value := reflect.ValueOf(v)
+	return value.Kind() == reflect.Pointer && value.IsNil()
+}
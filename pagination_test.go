@@ -0,0 +1,89 @@
+package easierweb
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// pagination test
+
+type paginationTestUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestPagination(t *testing.T) {
+
+	fmt.Println("\n[TestPagination] start")
+
+	users := make([]paginationTestUser, 45)
+	for i := range users {
+		users[i] = paginationTestUser{ID: i + 1, Name: fmt.Sprint("user-", i+1)}
+	}
+	router := New(RouterOptions{
+		CloseConsolePrint: true,
+		Pagination:        PaginationOptions{DefaultLimit: 20, MaxLimit: 30},
+		Fields:            FieldsOptions{Enabled: true},
+	})
+	router.EasyGET("/users", func(ctx *Context) (*Page[paginationTestUser], error) {
+		p, err := ctx.BindPagination()
+		if err != nil {
+			return nil, err
+		}
+		if p.Cursor != "" {
+			return NewCursorPage(users[40:], p, ""), nil
+		}
+		end := min(p.Offset+p.Limit, len(users))
+		return NewPage(users[min(p.Offset, end):end], p, int64(len(users))), nil
+	})
+
+	cases := []struct {
+		name  string
+		uri   string
+		code  int
+		body  string
+		total string
+		link  string
+	}{
+		{"first page", "/users?sort=name", http.StatusOK,
+			`"page":1,"limit":20,"total":45,"has_more":true`, "45",
+			`</users?limit=20&page=1&sort=name>; rel="first", </users?limit=20&page=2&sort=name>; rel="next", </users?limit=20&page=3&sort=name>; rel="last"`},
+		{"middle page", "/users?page=2&limit=20", http.StatusOK,
+			`"page":2,"limit":20,"total":45,"has_more":true`, "45",
+			`</users?limit=20&page=1>; rel="first", </users?limit=20&page=1>; rel="prev", </users?limit=20&page=3>; rel="next", </users?limit=20&page=3>; rel="last"`},
+		{"last page", "/users?page=3", http.StatusOK,
+			`"page":3,"limit":20,"total":45,"has_more":false`, "45",
+			`</users?limit=20&page=1>; rel="first", </users?limit=20&page=2>; rel="prev", </users?limit=20&page=3>; rel="last"`},
+		// the limit is capped by MaxLimit
+		{"max limit", "/users?limit=100", http.StatusOK,
+			`"page":1,"limit":30,"total":45,"has_more":true`, "45",
+			`</users?limit=30&page=1>; rel="first", </users?limit=30&page=2>; rel="next", </users?limit=30&page=2>; rel="last"`},
+		{"cursor", "/users?cursor=40", http.StatusOK,
+			`"limit":20,"has_more":false`, "",
+			`</users?limit=20>; rel="first"`},
+		{"invalid page", "/users?page=0", http.StatusBadRequest, "", "", ""},
+		{"invalid limit", "/users?limit=a", http.StatusBadRequest, "", "", ""},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.uri, nil))
+		fmt.Println("[TestPagination] result ->", c.name, rec.Code, rec.Header().Get("X-Total-Count"), rec.Header().Get("Link"))
+		if rec.Code != c.code || rec.Header().Get("X-Total-Count") != c.total || rec.Header().Get("Link") != c.link ||
+			!strings.Contains(rec.Body.String(), c.body) {
+			t.Fatal(c.name + ": page does not match: " + rec.Body.String())
+		}
+	}
+
+	// the fields apply to the items
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?page=3&fields=id", nil))
+	fmt.Println("[TestPagination] fields ->", rec.Body.String())
+	if !strings.Contains(rec.Body.String(), `{"has_more":false,"items":[{"id":41},{"id":42},{"id":43},{"id":44},{"id":45}],"limit":20,"page":3,"total":45}`) {
+		t.Fatal("the fields of the page items do not match")
+	}
+
+	fmt.Println("\n[TestPagination] end")
+}
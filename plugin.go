@@ -0,0 +1,98 @@
+package easierweb
+
+import (
+	"sync"
+)
+
+// RequestPlugin executed around the request handle of the easy handles, e.g. decrypt the body before calling next
+// return without calling next to short-circuit the chain, the returned error is passed to the response chain
+type RequestPlugin func(ctx *Context, reqObj any, next RequestHandle) error
+
+// ResponsePlugin executed around the response handle of the easy handles, e.g. wrap the result in an envelope or mask the fields before calling next
+// return without calling next to short-circuit the chain (the plugin writes the response itself)
+type ResponsePlugin func(ctx *Context, result any, err error, next ResponseHandle)
+
+// routePlugins the plugins of an easy handle, shared by all methods if registered by EasyAny
+type routePlugins struct {
+	request  []RequestPlugin
+	response []ResponsePlugin
+	once     sync.Once
+	// the composed chains, built on the first request
+	requestHandle  RequestHandle
+	responseHandle ResponseHandle
+}
+
+// UseRequestPlugins add the global request plugins, they are executed before the route-level plugins, in the order they are added
+func (r *Router) UseRequestPlugins(plugins ...RequestPlugin) *Router {
+	r.requestPlugins = append(r.requestPlugins, plugins...)
+	return r
+}
+
+// UseResponsePlugins add the global response plugins, they are executed before the route-level plugins, in the order they are added
+func (r *Router) UseResponsePlugins(plugins ...ResponsePlugin) *Router {
+	r.responsePlugins = append(r.responsePlugins, plugins...)
+	return r
+}
+
+// RequestPlugins add the request plugins of the last registered easy route
+// e.g. router.EasyPOST("/orders", createOrder).RequestPlugins(decrypt)
+func (r *Router) RequestPlugins(plugins ...RequestPlugin) *Router {
+	for _, p := range r.lastPlugins() {
+		p.request = append(p.request, plugins...)
+	}
+	return r
+}
+
+// ResponsePlugins add the response plugins of the last registered easy route
+// e.g. router.EasyGET("/users/:id", getUser).ResponsePlugins(maskPhone)
+func (r *Router) ResponsePlugins(plugins ...ResponsePlugin) *Router {
+	for _, p := range r.lastPlugins() {
+		p.response = append(p.response, plugins...)
+	}
+	return r
+}
+
+// lastPlugins the distinct plugins of the last registered routes (EasyAny routes share the same plugins)
+func (r *Router) lastPlugins() []*routePlugins {
+	var list []*routePlugins
+	for _, rt := range r.lastRoutes {
+		if rt.plugins == nil {
+			continue
+		}
+		exists := false
+		for _, p := range list {
+			if p == rt.plugins {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			list = append(list, rt.plugins)
+		}
+	}
+	return list
+}
+
+// chains compose the global and route-level plugins with the request and response handles
+func (r *Router) chains(p *routePlugins) (RequestHandle, ResponseHandle) {
+	p.once.Do(func() {
+		requestPlugins := append(append([]RequestPlugin{}, r.requestPlugins...), p.request...)
+		request := r.requestHandle
+		for i := len(requestPlugins) - 1; i >= 0; i-- {
+			plugin, next := requestPlugins[i], request
+			request = func(ctx *Context, reqObj any) error {
+				return plugin(ctx, reqObj, next)
+			}
+		}
+		responsePlugins := append(append([]ResponsePlugin{}, r.responsePlugins...), p.response...)
+		response := r.responseHandle
+		for i := len(responsePlugins) - 1; i >= 0; i-- {
+			plugin, next := responsePlugins[i], response
+			response = func(ctx *Context, result any, err error) {
+				plugin(ctx, result, err, next)
+			}
+		}
+		p.requestHandle, p.responseHandle = request, response
+	})
+	return p.requestHandle, p.responseHandle
+}
@@ -0,0 +1,106 @@
+package easierweb
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// request and response plugins test
+
+type pluginTestRequest struct {
+	Name string `json:"name"`
+}
+
+type pluginTestResult struct {
+	Name string `json:"name"`
+}
+
+func TestPlugins(t *testing.T) {
+
+	fmt.Println("\n[TestPlugins] start")
+
+	var trace []string
+	requestPlugin := func(name string) RequestPlugin {
+		return func(ctx *Context, reqObj any, next RequestHandle) error {
+			trace = append(trace, name+">")
+			if ctx.Request.Header.Get("X-Stop-Request") == name {
+				return NewError(http.StatusForbidden, "stopped by "+name)
+			}
+			err := next(ctx, reqObj)
+			trace = append(trace, "<"+name)
+			return err
+		}
+	}
+	responsePlugin := func(name string) ResponsePlugin {
+		return func(ctx *Context, result any, err error, next ResponseHandle) {
+			trace = append(trace, "res:"+name)
+			if ctx.Request.Header.Get("X-Stop-Response") == name {
+				ctx.WriteString(http.StatusAccepted, "raw")
+				return
+			}
+			next(ctx, result, err)
+		}
+	}
+	handle := func(ctx *Context, req pluginTestRequest) (*pluginTestResult, error) {
+		trace = append(trace, "handle")
+		return &pluginTestResult{Name: req.Name}, nil
+	}
+
+	router := New(RouterOptions{
+		CloseConsolePrint: true,
+		RequestPlugins:    []RequestPlugin{requestPlugin("option")},
+		ResponsePlugins:   []ResponsePlugin{responsePlugin("option")},
+	})
+	// the global plugins are executed before the route-level plugins, even if they are added later
+	router.EasyPOST("/route", handle).RequestPlugins(requestPlugin("route")).ResponsePlugins(responsePlugin("route"))
+	router.EasyPOST("/global", handle)
+	router.EasyAny("/any", handle).RequestPlugins(requestPlugin("any"))
+	router.UseRequestPlugins(requestPlugin("use1"), requestPlugin("use2"))
+	router.UseResponsePlugins(responsePlugin("use"))
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		value  string
+		code   int
+		body   string
+		trace  string
+	}{
+		{"order", http.MethodPost, "/route", "", "", http.StatusOK, `{"name":"a"}`,
+			"option> use1> use2> route> <route <use2 <use1 <option handle res:option res:use res:route"},
+		{"global only", http.MethodPost, "/global", "", "", http.StatusOK, `{"name":"a"}`,
+			"option> use1> use2> <use2 <use1 <option handle res:option res:use"},
+		{"shared by any", http.MethodPut, "/any", "", "", http.StatusOK, `{"name":"a"}`,
+			"option> use1> use2> any> <any <use2 <use1 <option handle res:option res:use"},
+		// the handle and the inner request plugins are skipped, the error is returned to the outer plugins and passed to the response chain
+		{"request short-circuit", http.MethodPost, "/route", "X-Stop-Request", "use1", http.StatusForbidden, "",
+			"option> use1> <option res:option res:use res:route"},
+		{"route request short-circuit", http.MethodPost, "/route", "X-Stop-Request", "route", http.StatusForbidden, "",
+			"option> use1> use2> route> <use2 <use1 <option res:option res:use res:route"},
+		// the remaining response plugins and the response handle are skipped
+		{"response short-circuit", http.MethodPost, "/route", "X-Stop-Response", "use", http.StatusAccepted, "raw",
+			"option> use1> use2> route> <route <use2 <use1 <option handle res:option res:use"},
+	}
+	for _, c := range cases {
+		trace = nil
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(`{"name":"a"}`))
+		req.Header.Set("Content-Type", MediaTypeJSON)
+		if c.header != "" {
+			req.Header.Set(c.header, c.value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		result := strings.Join(trace, " ")
+		fmt.Println("[TestPlugins] result ->", c.name, rec.Code, strings.TrimSpace(rec.Body.String()), result)
+		if rec.Code != c.code || (c.body != "" && strings.TrimSpace(rec.Body.String()) != c.body) || result != c.trace {
+			t.Fatal(c.name + ": plugins do not match")
+		}
+	}
+
+	fmt.Println("\n[TestPlugins] end")
+}
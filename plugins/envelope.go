@@ -0,0 +1,86 @@
+package plugins
+
+import (
+	"errors"
+	"github.com/dpwgc/easierweb"
+	"net/http"
+)
+
+// Envelope the response body of EnvelopeResponseHandle
+type Envelope struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data"`
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+type EnvelopeOptions struct {
+	// code of the successful response, default 0
+	SuccessCode int
+	// message of the successful response, default "ok"
+	SuccessMessage string
+	// write all responses with 200, the error is only described by the code (the http status) and the message
+	AlwaysOK bool
+	// customize the envelope, e.g. set the data to nil for errors or add fields, it returns the body to write
+	Build func(ctx *easierweb.Context, envelope Envelope, err error) any
+}
+
+// EnvelopeResponseHandle write the result (or the error) in the envelope, e.g. {"code":0,"message":"ok","data":{},"trace_id":"..."}
+// the code of the error is its http status, the trace id is ctx.RequestID()
+// use RawResponse on the routes that must return the raw payload
+func EnvelopeResponseHandle(opts ...EnvelopeOptions) easierweb.ResponseHandle {
+	opt := EnvelopeOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.SuccessMessage == "" {
+		opt.SuccessMessage = "ok"
+	}
+	return func(ctx *easierweb.Context, result any, err error) {
+		status := http.StatusOK
+		envelope := Envelope{Code: opt.SuccessCode, Message: opt.SuccessMessage, Data: result, TraceID: ctx.RequestID()}
+		if err != nil {
+			var validationErrors easierweb.ValidationErrors
+			var e *easierweb.Error
+			if errors.As(err, &validationErrors) {
+				status = http.StatusBadRequest
+				envelope.Message = "validation failed"
+				envelope.Data = validationErrors
+			} else if errors.As(err, &e) {
+				status = e.Status
+				envelope.Message = e.Message
+				envelope.Data = e.Details
+			} else if result != nil {
+				status = http.StatusBadRequest
+				envelope.Message = err.Error()
+			} else {
+				panic(err)
+			}
+			envelope.Code = status
+		} else {
+			ctx.SetPageLinks(result)
+			envelope.Data = ctx.SelectFields(result)
+		}
+		if opt.AlwaysOK {
+			status = http.StatusOK
+		}
+		var body any = envelope
+		if opt.Build != nil {
+			body = opt.Build(ctx, envelope, err)
+		}
+		ctx.WriteJSON(status, body)
+	}
+}
+
+// RawResponse the route-level response plugin that skips the rest of the chain, i.e. the later route-level plugins and the router ResponseHandle (e.g. the envelope)
+// the global response plugins are still executed before it, the result is written by the handle, default JSONResponseHandle
+// e.g. router.EasyPOST("/webhook", webhook).ResponsePlugins(plugins.RawResponse())
+func RawResponse(handle ...easierweb.ResponseHandle) easierweb.ResponsePlugin {
+	h := JSONResponseHandle()
+	if len(handle) > 0 {
+		h = handle[0]
+	}
+	return func(ctx *easierweb.Context, result any, err error, next easierweb.ResponseHandle) {
+		h(ctx, result, err)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"github.com/dpwgc/easierweb"
 	"log/slog"
 	"net/http"
-	"runtime/debug"
 )
 
 type ErrorHandleOptions struct {
@@ -69,7 +68,7 @@ func StringErrorHandle(opts ...ErrorHandleOptions) easierweb.ErrorHandle {
 
 func logError(ctx *easierweb.Context, err any, opts ...ErrorHandleOptions) {
 	if len(opts) > 0 && opts[0].OutputStack {
-		ctx.Logger.Error(fmt.Sprintf("%s\n%s", err, string(debug.Stack())), slog.String("method", ctx.Request.Method), slog.String("route", ctx.Route))
+		ctx.Logger.Error(fmt.Sprintf("%s\n%s", err, string(ctx.Stack())), slog.String("method", ctx.Request.Method), slog.String("route", ctx.Route))
 	} else {
 		ctx.Logger.Error(fmt.Sprintf("%s", err), slog.String("method", ctx.Request.Method), slog.String("route", ctx.Route))
 	}
@@ -72,3 +72,21 @@ func XMLRequestHandle() easierweb.RequestHandle {
 		return nil
 	}
 }
+
+func ProtobufRequestHandle() easierweb.RequestHandle {
+	return func(ctx *easierweb.Context, reqObj any) error {
+		if len(ctx.Body) > 0 {
+			err := ctx.BindProtobuf(reqObj)
+			if err != nil {
+				return err
+			}
+		}
+		if len(ctx.Query) > 0 {
+			err := ctx.BindQuery(reqObj)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -1,6 +1,7 @@
 package plugins
 
 import (
+	"errors"
 	"github.com/dpwgc/easierweb"
 	"net/http"
 )
@@ -8,6 +9,16 @@ import (
 func JSONResponseHandle() easierweb.ResponseHandle {
 	return func(ctx *easierweb.Context, result any, err error) {
 		if err != nil {
+			var validationErrors easierweb.ValidationErrors
+			if errors.As(err, &validationErrors) {
+				ctx.WriteJSON(http.StatusBadRequest, validationErrors)
+				return
+			}
+			var e *easierweb.Error
+			if errors.As(err, &e) {
+				ctx.WriteError(e)
+				return
+			}
 			if result != nil {
 				ctx.WriteJSON(http.StatusBadRequest, result)
 				return
@@ -18,13 +29,25 @@ func JSONResponseHandle() easierweb.ResponseHandle {
 			ctx.NoContent(http.StatusNoContent)
 			return
 		}
-		ctx.WriteJSON(http.StatusOK, result)
+		ctx.SetPageLinks(result)
+		ctx.WriteJSON(http.StatusOK, ctx.SelectFields(result))
 	}
 }
 
 func YAMLResponseHandle() easierweb.ResponseHandle {
 	return func(ctx *easierweb.Context, result any, err error) {
 		if err != nil {
+			var validationErrors easierweb.ValidationErrors
+			if errors.As(err, &validationErrors) {
+				ctx.WriteYAML(http.StatusBadRequest, validationErrors)
+				return
+			}
+			var e *easierweb.Error
+			if errors.As(err, &e) {
+				problem := e.Problem(ctx.Request.URL.Path)
+				ctx.WriteYAML(problem.Status, problem)
+				return
+			}
 			if result != nil {
 				ctx.WriteYAML(http.StatusBadRequest, result)
 				return
@@ -42,6 +65,17 @@ func YAMLResponseHandle() easierweb.ResponseHandle {
 func XMLResponseHandle() easierweb.ResponseHandle {
 	return func(ctx *easierweb.Context, result any, err error) {
 		if err != nil {
+			var validationErrors easierweb.ValidationErrors
+			if errors.As(err, &validationErrors) {
+				ctx.WriteXML(http.StatusBadRequest, validationErrors)
+				return
+			}
+			var e *easierweb.Error
+			if errors.As(err, &e) {
+				problem := e.Problem(ctx.Request.URL.Path)
+				ctx.WriteXML(problem.Status, problem)
+				return
+			}
 			if result != nil {
 				ctx.WriteXML(http.StatusBadRequest, result)
 				return
@@ -72,3 +106,27 @@ func BytesResponseHandle() easierweb.ResponseHandle {
 		ctx.Write(http.StatusOK, result.([]byte))
 	}
 }
+
+// ProtobufResponseHandle write the proto messages as protobuf, the errors are written as the problem details (json)
+func ProtobufResponseHandle() easierweb.ResponseHandle {
+	return func(ctx *easierweb.Context, result any, err error) {
+		if err != nil {
+			var validationErrors easierweb.ValidationErrors
+			if errors.As(err, &validationErrors) {
+				ctx.WriteJSON(http.StatusBadRequest, validationErrors)
+				return
+			}
+			var e *easierweb.Error
+			if errors.As(err, &e) {
+				ctx.WriteError(e)
+				return
+			}
+			panic(err)
+		}
+		if result == nil {
+			ctx.NoContent(http.StatusNoContent)
+			return
+		}
+		ctx.WriteProtobuf(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,35 @@
+package easierweb
+
+import (
+	"net/http"
+	"time"
+)
+
+// pollInterval the interval of calling the function of ctx.Poll
+const pollInterval = 100 * time.Millisecond
+
+// Poll hold the request (long polling) until the function returns true or the timeout elapses, the function is called every 100 milliseconds
+// the result is written as json (200), 204 if the timeout elapses, nothing is written if the client disconnects
+// returns whether the result is written, e.g.
+// ctx.Poll(30*time.Second, func() (any, bool) { msgs := inbox.Since(ctx.Query.Get("after")); return msgs, len(msgs) > 0 })
+func (c *Context) Poll(timeout time.Duration, fn func() (any, bool)) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		result, ok := fn()
+		if ok {
+			c.WriteJSON(http.StatusOK, result)
+			return true
+		}
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			c.NoContent(http.StatusNoContent)
+			return false
+		case <-c.Done():
+			return false
+		}
+	}
+}
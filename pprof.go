@@ -0,0 +1,38 @@
+package easierweb
+
+import (
+	"expvar"
+	"net/http/pprof"
+	"strings"
+)
+
+// EnablePprof expose the pprof profiles on prefix/pprof/ and the expvar variables on prefix/vars
+// the middlewares (e.g. basic auth) should be set in production
+// e.g. router.EnablePprof("/debug", auth) then go tool pprof http://host/debug/pprof/profile?seconds=30
+func (r *Router) EnablePprof(prefix string, middlewares ...Handle) *Router {
+	prefix = strings.TrimSuffix(prefix, "/")
+	handle := func(ctx *Context) {
+		name := strings.TrimPrefix(ctx.Path.Get("name"), "/")
+		switch name {
+		case "":
+			// the index page resolves the profile name from the fixed path /debug/pprof/
+			req := ctx.Request.Clone(ctx.Request.Context())
+			req.URL.Path = "/debug/pprof/"
+			pprof.Index(ctx.ResponseWriter, req)
+		case "cmdline":
+			pprof.Cmdline(ctx.ResponseWriter, ctx.Request)
+		case "profile":
+			pprof.Profile(ctx.ResponseWriter, ctx.Request)
+		case "symbol":
+			pprof.Symbol(ctx.ResponseWriter, ctx.Request)
+		case "trace":
+			pprof.Trace(ctx.ResponseWriter, ctx.Request)
+		default:
+			pprof.Handler(name).ServeHTTP(ctx.ResponseWriter, ctx.Request)
+		}
+	}
+	r.GET(prefix+"/pprof/*name", handle, middlewares...)
+	// symbol lookups are posted by go tool pprof
+	r.POST(prefix+"/pprof/*name", handle, middlewares...)
+	return r.GET(prefix+"/vars", WrapHTTPHandler(expvar.Handler()), middlewares...)
+}
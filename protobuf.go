@@ -0,0 +1,108 @@
+package easierweb
+
+import (
+	"errors"
+	"google.golang.org/protobuf/proto"
+	"mime"
+	"reflect"
+	"strings"
+)
+
+var ErrNotProtoMessage = errors.New("object is not a proto message")
+
+// BindProtobuf bind the protobuf body, obj is a proto message (or a pointer to it)
+func (c *Context) BindProtobuf(obj any) error {
+	return unmarshalProtobuf(c.Body, obj)
+}
+
+// WriteProtobuf write the proto message (or the struct value of a proto message)
+func (c *Context) WriteProtobuf(code int, obj any) {
+	if c.skipWrite() {
+		return
+	}
+	msg, ok := asProtoMessage(obj)
+	if !ok {
+		panic(ErrNotProtoMessage)
+	}
+	marshal, err := proto.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	c.AddContentType(MediaTypeXProtobuf)
+	c.Write(code, marshal)
+}
+
+// WantsProtobuf whether the response should be protobuf: the Accept or Content-Type header is protobuf, or the path is in RouterOptions.ProtobufPaths (without Accept)
+func (c *Context) WantsProtobuf() bool {
+	accept := c.Request.Header.Get("Accept")
+	if accept != "" {
+		for _, v := range strings.Split(accept, ",") {
+			if isProtobufMediaType(v) {
+				return true
+			}
+		}
+		if !strings.Contains(accept, "*/*") {
+			return false
+		}
+	}
+	if isProtobufMediaType(c.Request.Header.Get("Content-Type")) {
+		return true
+	}
+	return c.router.protobufDefault(c.Request.URL.Path)
+}
+
+// protobufDefault whether protobuf is the default codec of the path
+func (r *Router) protobufDefault(path string) bool {
+	for _, prefix := range r.protobufPaths {
+		if strings.HasPrefix(path, r.rootPath+prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isProtobufMediaType(v string) bool {
+	mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(v))
+	if err != nil {
+		return false
+	}
+	mediaType = strings.ToLower(mediaType)
+	return mediaType == MediaTypeProtobuf || mediaType == MediaTypeXProtobuf
+}
+
+func unmarshalProtobuf(data []byte, obj any) error {
+	// the easy handle parameter of a pointer type is bound as **T
+	v := reflect.ValueOf(obj)
+	if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Ptr {
+		if v.Elem().IsNil() {
+			v.Elem().Set(reflect.New(v.Elem().Type().Elem()))
+		}
+		obj = v.Elem().Interface()
+	}
+	msg, ok := obj.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+// asProtoMessage get the proto message, the result of the easy handle is dereferenced, so the struct value is addressed again
+func asProtoMessage(obj any) (proto.Message, bool) {
+	if msg, ok := obj.(proto.Message); ok {
+		return msg, true
+	}
+	v := reflect.ValueOf(obj)
+	if !v.IsValid() || v.Kind() != reflect.Struct {
+		return nil, false
+	}
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	msg, ok := ptr.Interface().(proto.Message)
+	return msg, ok
+}
+
+// IsProtoMessage whether the object is a proto message (or the struct value of a proto message)
+func IsProtoMessage(obj any) bool {
+	_, ok := asProtoMessage(obj)
+	return ok
+}
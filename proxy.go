@@ -0,0 +1,112 @@
+package easierweb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"time"
+)
+
+type ProxyOptions struct {
+	// strip the path prefix before forwarding, e.g. /api/users -> /users
+	StripPrefix bool
+	// keep the Host header of the client, by default it is the host of the target
+	PreserveHost bool
+	// set the request headers sent to the target
+	RequestHeaders map[string]string
+	// remove the request headers sent to the target, e.g. Cookie
+	RemoveRequestHeaders []string
+	// set the response headers sent to the client
+	ResponseHeaders map[string]string
+	// remove the response headers sent to the client, e.g. Server
+	RemoveResponseHeaders []string
+	// the other upstream targets, the requests are balanced between them and the target
+	Targets []string
+	// the strategy of selecting the upstream, ProxyRoundRobin (default) or ProxyLeastConn
+	Strategy string
+	// the upstream is considered down for the FailTimeout after MaxFails consecutive failures, default 3 and 10 seconds
+	MaxFails    int
+	FailTimeout time.Duration
+	// retry with another upstream if the connection fails, default the number of the other upstreams, -1 means no retry
+	Retries int
+	// default http.DefaultTransport
+	Transport http.RoundTripper
+	// flush interval of the response body, negative means flushing after each write, e.g. streaming responses
+	FlushInterval time.Duration
+	// modify the response of the target before it is written
+	ModifyResponse func(res *http.Response) error
+	// the middlewares of the proxy routes, e.g. authentication
+	Middlewares []Handle
+}
+
+type proxyContextKey struct{}
+
+// Proxy forward the requests of the path prefix to the target (and the other targets), the websocket connections are passed through
+// the X-Forwarded-* headers are set, and the errors (e.g. the target is unreachable) are handled by the ErrorHandle as a 502 *Error
+// e.g. router.Proxy("/legacy", "http://127.0.0.1:8080", easierweb.ProxyOptions{StripPrefix: true})
+func (r *Router) Proxy(prefix, target string, opts ...ProxyOptions) *Router {
+	opt := ProxyOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	balancer, err := newUpstreamBalancer(append([]string{target}, opt.Targets...), opt)
+	if err != nil {
+		panic(err)
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	fullPrefix := r.rootPath + prefix
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			if opt.StripPrefix {
+				pr.Out.URL.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(pr.In.URL.Path, fullPrefix), "/")
+				pr.Out.URL.RawPath = ""
+			}
+			// the upstream is selected by the balancer
+			pr.SetXForwarded()
+			if !opt.PreserveHost {
+				pr.Out.Host = ""
+			}
+			for _, k := range opt.RemoveRequestHeaders {
+				pr.Out.Header.Del(k)
+			}
+			for k, v := range opt.RequestHeaders {
+				pr.Out.Header.Set(k, v)
+			}
+		},
+		Transport:     balancer,
+		FlushInterval: opt.FlushInterval,
+		ModifyResponse: func(res *http.Response) error {
+			for _, k := range opt.RemoveResponseHeaders {
+				res.Header.Del(k)
+			}
+			for k, v := range opt.ResponseHeaders {
+				res.Header.Set(k, v)
+			}
+			if opt.ModifyResponse != nil {
+				return opt.ModifyResponse(res)
+			}
+			return nil
+		},
+		ErrorHandler: func(res http.ResponseWriter, req *http.Request, err error) {
+			ctx, ok := req.Context().Value(proxyContextKey{}).(*Context)
+			if !ok {
+				res.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			ctx.HandleError(NewError(http.StatusBadGateway, "bad gateway").Wrap(err))
+		},
+	}
+	handle := func(ctx *Context) {
+		proxy.ServeHTTP(ctx.ResponseWriter, ctx.Request.WithContext(context.WithValue(ctx.Request.Context(), proxyContextKey{}, ctx)))
+	}
+	r.lastRoutes = nil
+	for _, method := range methodNames {
+		// the body is forwarded as it is, it is not parsed
+		if prefix != "" {
+			r.api(method, prefix, handle, nil, opt.Middlewares).streamBody = true
+		}
+		r.api(method, prefix+"/*proxypath", handle, nil, opt.Middlewares).streamBody = true
+	}
+	return r
+}
@@ -0,0 +1,67 @@
+package easierweb
+
+import (
+	"crypto/tls"
+	"errors"
+	"github.com/quic-go/quic-go/http3"
+	"net/http"
+)
+
+// RunQUIC start the HTTP/3 (QUIC, UDP) server and the HTTPS (TCP) server on the address, the responses of the HTTPS server
+// advertise HTTP/3 by the Alt-Svc header, so the clients can switch to it, e.g. router.RunQUIC(":443", "cert.pem", "private.key")
+// websocket is only served by the HTTPS server (the HTTP/3 streams cannot be hijacked)
+func (r *Router) RunQUIC(addr string, certFile string, keyFile string) error {
+	config, certFile, keyFile, err := r.tlsConfig(nil, certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		if config == nil {
+			config = &tls.Config{}
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	quicServer := &http3.Server{
+		Addr:           addr,
+		Handler:        r,
+		MaxHeaderBytes: r.serverOptions.MaxHeaderBytes,
+		TLSConfig:      config,
+	}
+	server := r.newServer(addr)
+	server.TLSConfig = config
+	server.Handler = http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		_ = quicServer.SetQuicHeaders(res.Header())
+		r.ServeHTTP(res, req)
+	})
+	r.addServer(server)
+	r.addQUICServer(quicServer)
+	r.consoleStartPrint(addr)
+	return r.serve(func() error {
+		errs := make(chan error, 2)
+		go func() {
+			// the certificate is in the TLSConfig
+			errs <- server.ListenAndServeTLS("", "")
+		}()
+		go func() {
+			errs <- quicServer.ListenAndServe()
+		}()
+		// if any of them fails (e.g. the address is in use), the other is closed
+		first := <-errs
+		if !errors.Is(first, http.ErrServerClosed) {
+			_ = r.Close()
+		}
+		<-errs
+		return first
+	})
+}
+
+// addQUICServer track the HTTP/3 server to be closed by Close
+func (r *Router) addQUICServer(server *http3.Server) {
+	r.serversLock.Lock()
+	defer r.serversLock.Unlock()
+	r.quicServers = append(r.quicServers, server)
+}
@@ -0,0 +1,164 @@
+package easierweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redacted the value that replaces the redacted headers and fields
+const Redacted = "[REDACTED]"
+
+// RedactOptions the rules of hiding the secrets in the logs, they are applied by the access log, the dump and the error log
+type RedactOptions struct {
+	// header names, in addition to Authorization, Proxy-Authorization, Cookie, Set-Cookie and X-Api-Key
+	Headers []string
+	// field paths of the JSON and form bodies, e.g. "password" (matches the field at any depth), "card.number" (from the root),
+	// "items.*.token" (* matches any field), the arrays are transparent, e.g. "items.token" matches each item of the items array
+	Fields []string
+}
+
+// Redactor hide the secret headers and fields by the RedactOptions, e.g. ctx.Redactor().Headers(ctx.Request.Header)
+type Redactor struct {
+	headers map[string]bool
+	fields  [][]string
+}
+
+var defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+func NewRedactor(opts ...RedactOptions) *Redactor {
+	r := &Redactor{headers: make(map[string]bool)}
+	for _, h := range defaultRedactHeaders {
+		r.headers[h] = true
+	}
+	for _, opt := range opts {
+		for _, h := range opt.Headers {
+			r.headers[http.CanonicalHeaderKey(h)] = true
+		}
+		for _, f := range opt.Fields {
+			if f != "" {
+				r.fields = append(r.fields, strings.Split(f, "."))
+			}
+		}
+	}
+	return r
+}
+
+// Redactor get the redactor of the RouterOptions.Redact rules
+func (c *Context) Redactor() *Redactor {
+	return c.router.redactor
+}
+
+// Header whether the header is redacted
+func (r *Redactor) Header(name string) bool {
+	return r.headers[http.CanonicalHeaderKey(name)]
+}
+
+// Headers the headers to log, the values of multiple lines are joined with ", "
+func (r *Redactor) Headers(header http.Header) map[string]string {
+	redacted := make(map[string]string, len(header))
+	for k, v := range header {
+		if r.Header(k) {
+			redacted[k] = Redacted
+			continue
+		}
+		redacted[k] = strings.Join(v, ", ")
+	}
+	return redacted
+}
+
+// Form the form values to log, only the field rules of one segment are applied (e.g. "password")
+func (r *Redactor) Form(values url.Values) url.Values {
+	redacted := make(url.Values, len(values))
+	for k, v := range values {
+		if r.matchField([]string{k}) {
+			redacted[k] = []string{Redacted}
+			continue
+		}
+		redacted[k] = v
+	}
+	return redacted
+}
+
+// JSON the JSON body to log, it is returned as it is if there are no field rules,
+// and Redacted if it cannot be parsed (e.g. truncated), so the secrets are never leaked
+func (r *Redactor) JSON(data []byte) []byte {
+	if len(r.fields) == 0 || len(bytes.TrimSpace(data)) == 0 {
+		return data
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v any
+	if decoder.Decode(&v) != nil {
+		return []byte(Redacted)
+	}
+	marshal, err := json.Marshal(r.redactValue(v, nil))
+	if err != nil {
+		return []byte(Redacted)
+	}
+	return marshal
+}
+
+// Body the request or response body to log by its Content-Type (JSON or form), other types are returned as they are
+func (r *Redactor) Body(contentType string, data []byte) []byte {
+	contentType = strings.ToLower(contentType)
+	switch {
+	case strings.Contains(contentType, "json"):
+		return r.JSON(data)
+	case strings.HasPrefix(contentType, MediaTypeForm) && len(r.fields) > 0:
+		values, err := url.ParseQuery(string(data))
+		if err != nil {
+			return []byte(Redacted)
+		}
+		return []byte(r.Form(values).Encode())
+	}
+	return data
+}
+
+func (r *Redactor) redactValue(v any, path []string) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for k, field := range value {
+			fieldPath := append(path[:len(path):len(path)], k)
+			if r.matchField(fieldPath) {
+				value[k] = Redacted
+				continue
+			}
+			value[k] = r.redactValue(field, fieldPath)
+		}
+	case []any:
+		// the arrays are transparent, the items have the same path as the array
+		for i, item := range value {
+			value[i] = r.redactValue(item, path)
+		}
+	}
+	return v
+}
+
+// matchField the rules of one segment match the last segment of the path, others match the whole path
+func (r *Redactor) matchField(path []string) bool {
+	for _, rule := range r.fields {
+		if len(rule) == 1 {
+			if rule[0] == "*" || rule[0] == path[len(path)-1] {
+				return true
+			}
+			continue
+		}
+		if len(rule) != len(path) {
+			continue
+		}
+		match := true
+		for i, seg := range rule {
+			if seg != "*" && seg != path[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type ConsulOptions struct {
+	// address of the consul agent, default "http://127.0.0.1:8500"
+	Address string
+	// the ACL token
+	Token string
+	// the critical instances are removed after the duration, default 1 minute
+	DeregisterAfter time.Duration
+	// default timeout 10 seconds
+	HTTPClient *http.Client
+}
+
+// Consul register the instances to the local consul agent with the TTL health checks
+type Consul struct {
+	opt ConsulOptions
+}
+
+func NewConsul(opts ...ConsulOptions) *Consul {
+	opt := ConsulOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Address == "" {
+		opt.Address = "http://127.0.0.1:8500"
+	}
+	opt.Address = strings.TrimSuffix(opt.Address, "/")
+	if opt.DeregisterAfter <= 0 {
+		opt.DeregisterAfter = time.Minute
+	}
+	if opt.HTTPClient == nil {
+		opt.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Consul{opt: opt}
+}
+
+func (c *Consul) Register(ctx context.Context, instance Instance) error {
+	body := map[string]any{
+		"ID":      instance.ID,
+		"Name":    instance.Service,
+		"Address": instance.Host,
+		"Port":    instance.Port,
+		"Tags":    instance.Tags,
+		"Meta":    instance.Metadata,
+		"Check": map[string]any{
+			"CheckID":                        c.checkID(instance),
+			"TTL":                            instance.TTL.String(),
+			"DeregisterCriticalServiceAfter": c.opt.DeregisterAfter.String(),
+		},
+	}
+	err := call(ctx, c.opt.HTTPClient, http.MethodPut, c.opt.Address+"/v1/agent/service/register", c.header(), body, nil)
+	if err != nil {
+		return err
+	}
+	// the check is critical until the first pass
+	return c.Heartbeat(ctx, instance)
+}
+
+func (c *Consul) Heartbeat(ctx context.Context, instance Instance) error {
+	return call(ctx, c.opt.HTTPClient, http.MethodPut, c.opt.Address+"/v1/agent/check/pass/"+url.PathEscape(c.checkID(instance)), c.header(), nil, nil)
+}
+
+func (c *Consul) Deregister(ctx context.Context, instance Instance) error {
+	return call(ctx, c.opt.HTTPClient, http.MethodPut, c.opt.Address+"/v1/agent/service/deregister/"+url.PathEscape(instance.ID), c.header(), nil, nil)
+}
+
+func (c *Consul) checkID(instance Instance) string {
+	return "service:" + instance.ID
+}
+
+func (c *Consul) header() http.Header {
+	header := http.Header{}
+	if c.opt.Token != "" {
+		header.Set("X-Consul-Token", c.opt.Token)
+	}
+	return header
+}
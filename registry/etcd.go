@@ -0,0 +1,130 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type EtcdOptions struct {
+	// address of the etcd v3 JSON gateway, default "http://127.0.0.1:2379"
+	Endpoint string
+	// prefix of the keys, the key is {prefix}{service}/{id}, default "/services/"
+	Prefix string
+	// the auth token (sent as the Authorization header), optional
+	Token string
+	// default timeout 10 seconds
+	HTTPClient *http.Client
+}
+
+// Etcd register the instances as the keys bound to the leases, the value is the JSON of the instance
+type Etcd struct {
+	opt    EtcdOptions
+	lock   sync.Mutex
+	leases map[string]string
+}
+
+var errLeaseExpired = errors.New("etcd lease is expired")
+
+func NewEtcd(opts ...EtcdOptions) *Etcd {
+	opt := EtcdOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Endpoint == "" {
+		opt.Endpoint = "http://127.0.0.1:2379"
+	}
+	opt.Endpoint = strings.TrimSuffix(opt.Endpoint, "/")
+	if opt.Prefix == "" {
+		opt.Prefix = "/services/"
+	}
+	if opt.HTTPClient == nil {
+		opt.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Etcd{opt: opt, leases: make(map[string]string)}
+}
+
+func (e *Etcd) Register(ctx context.Context, instance Instance) error {
+	var grant struct {
+		ID string `json:"ID"`
+	}
+	err := call(ctx, e.opt.HTTPClient, http.MethodPost, e.opt.Endpoint+"/v3/lease/grant", e.header(), map[string]any{"TTL": max(int64(instance.TTL/time.Second), 1)}, &grant)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(map[string]any{
+		"id":       instance.ID,
+		"service":  instance.Service,
+		"host":     instance.Host,
+		"port":     instance.Port,
+		"tags":     instance.Tags,
+		"metadata": instance.Metadata,
+	})
+	if err != nil {
+		return err
+	}
+	err = call(ctx, e.opt.HTTPClient, http.MethodPost, e.opt.Endpoint+"/v3/kv/put", e.header(), map[string]any{
+		"key":   base64.StdEncoding.EncodeToString([]byte(e.opt.Prefix + instance.Service + "/" + instance.ID)),
+		"value": base64.StdEncoding.EncodeToString(value),
+		"lease": grant.ID,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	e.lock.Lock()
+	e.leases[instance.ID] = grant.ID
+	e.lock.Unlock()
+	return nil
+}
+
+func (e *Etcd) Heartbeat(ctx context.Context, instance Instance) error {
+	lease := e.lease(instance)
+	if lease == "" {
+		return errLeaseExpired
+	}
+	var res struct {
+		Result struct {
+			TTL string `json:"TTL"`
+		} `json:"result"`
+	}
+	err := call(ctx, e.opt.HTTPClient, http.MethodPost, e.opt.Endpoint+"/v3/lease/keepalive", e.header(), map[string]any{"ID": lease}, &res)
+	if err != nil {
+		return err
+	}
+	// the TTL is absent (zero) if the lease is expired
+	if res.Result.TTL == "" || res.Result.TTL == "0" {
+		return errLeaseExpired
+	}
+	return nil
+}
+
+func (e *Etcd) Deregister(ctx context.Context, instance Instance) error {
+	lease := e.lease(instance)
+	if lease == "" {
+		return nil
+	}
+	e.lock.Lock()
+	delete(e.leases, instance.ID)
+	e.lock.Unlock()
+	// the key is deleted with the lease
+	return call(ctx, e.opt.HTTPClient, http.MethodPost, e.opt.Endpoint+"/v3/lease/revoke", e.header(), map[string]any{"ID": lease}, nil)
+}
+
+func (e *Etcd) lease(instance Instance) string {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.leases[instance.ID]
+}
+
+func (e *Etcd) header() http.Header {
+	header := http.Header{}
+	if e.opt.Token != "" {
+		header.Set("Authorization", e.opt.Token)
+	}
+	return header
+}
@@ -0,0 +1,121 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type NacosOptions struct {
+	// address of the nacos server, default "http://127.0.0.1:8848"
+	Address string
+	// id of the namespace, default the public namespace
+	Namespace string
+	// default "DEFAULT_GROUP"
+	Group string
+	// the access token, optional
+	AccessToken string
+	// default timeout 10 seconds
+	HTTPClient *http.Client
+}
+
+// Nacos register the instances as the ephemeral instances by the open API (v1), the tags are stored in the metadata
+type Nacos struct {
+	opt NacosOptions
+}
+
+// resourceNotFound the code of the beat response if the instance is not registered
+const resourceNotFound = 20404
+
+func NewNacos(opts ...NacosOptions) *Nacos {
+	opt := NacosOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Address == "" {
+		opt.Address = "http://127.0.0.1:8848"
+	}
+	opt.Address = strings.TrimSuffix(opt.Address, "/")
+	if opt.Group == "" {
+		opt.Group = "DEFAULT_GROUP"
+	}
+	if opt.HTTPClient == nil {
+		opt.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Nacos{opt: opt}
+}
+
+func (n *Nacos) Register(ctx context.Context, instance Instance) error {
+	query := n.query(instance)
+	metadata, err := json.Marshal(n.metadata(instance))
+	if err != nil {
+		return err
+	}
+	query.Set("metadata", string(metadata))
+	query.Set("healthy", "true")
+	query.Set("enabled", "true")
+	return call(ctx, n.opt.HTTPClient, http.MethodPost, n.opt.Address+"/nacos/v1/ns/instance?"+query.Encode(), nil, nil, nil)
+}
+
+func (n *Nacos) Heartbeat(ctx context.Context, instance Instance) error {
+	beat, err := json.Marshal(map[string]any{
+		"serviceName": n.opt.Group + "@@" + instance.Service,
+		"ip":          instance.Host,
+		"port":        instance.Port,
+		"metadata":    n.metadata(instance),
+	})
+	if err != nil {
+		return err
+	}
+	query := n.query(instance)
+	query.Set("beat", string(beat))
+	var res struct {
+		Code int `json:"code"`
+	}
+	err = call(ctx, n.opt.HTTPClient, http.MethodPut, n.opt.Address+"/nacos/v1/ns/instance/beat?"+query.Encode(), nil, nil, &res)
+	if err != nil {
+		return err
+	}
+	if res.Code == resourceNotFound {
+		return fmt.Errorf("nacos instance %s is not found", instance.ID)
+	}
+	return nil
+}
+
+func (n *Nacos) Deregister(ctx context.Context, instance Instance) error {
+	return call(ctx, n.opt.HTTPClient, http.MethodDelete, n.opt.Address+"/nacos/v1/ns/instance?"+n.query(instance).Encode(), nil, nil, nil)
+}
+
+func (n *Nacos) query(instance Instance) url.Values {
+	query := url.Values{
+		"serviceName": {instance.Service},
+		"groupName":   {n.opt.Group},
+		"ip":          {instance.Host},
+		"port":        {strconv.Itoa(instance.Port)},
+		"ephemeral":   {"true"},
+	}
+	if n.opt.Namespace != "" {
+		query.Set("namespaceId", n.opt.Namespace)
+	}
+	if n.opt.AccessToken != "" {
+		query.Set("accessToken", n.opt.AccessToken)
+	}
+	return query
+}
+
+func (n *Nacos) metadata(instance Instance) map[string]string {
+	metadata := make(map[string]string, len(instance.Metadata)+2)
+	for k, v := range instance.Metadata {
+		metadata[k] = v
+	}
+	metadata["id"] = instance.ID
+	if len(instance.Tags) > 0 {
+		metadata["tags"] = strings.Join(instance.Tags, ",")
+	}
+	return metadata
+}
@@ -0,0 +1,178 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/dpwgc/easierweb"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Instance the service instance registered to the service discovery
+type Instance struct {
+	// name of the service
+	Service string
+	// unique id of the instance, default "service-host-port"
+	ID string
+	// the advertised address, default the first non-loopback IPv4 address of the host
+	Host string
+	Port int
+	Tags []string
+	// the metadata, e.g. version and zone
+	Metadata map[string]string
+	// the registration expires if no heartbeat is received within the TTL, the heartbeat is sent every TTL/3, default 15 seconds
+	TTL time.Duration
+}
+
+// Registrar register the instances to the service discovery, e.g. NewConsul, NewEtcd, NewNacos
+type Registrar interface {
+	Register(ctx context.Context, instance Instance) error
+	// Heartbeat renew the registration, it returns an error if the registration is lost (the instance is registered again)
+	Heartbeat(ctx context.Context, instance Instance) error
+	Deregister(ctx context.Context, instance Instance) error
+}
+
+type Options struct {
+	// default slog.Default()
+	Logger *slog.Logger
+}
+
+// Attach register the instance when the router starts (OnStart), send the heartbeats, and deregister it when the router is closed,
+// before the servers drain the in-flight requests (OnPreShutdown), so the discovery stops routing the new requests to the closing instance
+// the registration is retried every heartbeat interval if it fails, e.g.
+// registry.Attach(router, registry.NewConsul(), registry.Instance{Service: "orders", Port: 8080})
+func Attach(router *easierweb.Router, registrar Registrar, instance Instance, opts ...Options) {
+	opt := Options{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Logger == nil {
+		opt.Logger = slog.Default()
+	}
+	if instance.Host == "" {
+		instance.Host = localIP()
+	}
+	if instance.ID == "" {
+		instance.ID = fmt.Sprintf("%s-%s-%d", instance.Service, instance.Host, instance.Port)
+	}
+	if instance.TTL <= 0 {
+		instance.TTL = 15 * time.Second
+	}
+	var lock sync.Mutex
+	var cancel context.CancelFunc
+	var done chan struct{}
+	router.OnStart(func() {
+		lock.Lock()
+		defer lock.Unlock()
+		if cancel != nil {
+			return
+		}
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		done = make(chan struct{})
+		go keepalive(ctx, done, registrar, instance, opt.Logger)
+	})
+	router.OnPreShutdown(func(ctx context.Context) {
+		lock.Lock()
+		defer lock.Unlock()
+		if cancel == nil {
+			return
+		}
+		cancel()
+		<-done
+		cancel = nil
+		err := registrar.Deregister(ctx, instance)
+		if err != nil {
+			opt.Logger.Error("deregister service error: "+err.Error(), slog.String("service", instance.Service), slog.String("id", instance.ID))
+		}
+	})
+}
+
+// keepalive register the instance and send the heartbeats until the context is canceled
+func keepalive(ctx context.Context, done chan struct{}, registrar Registrar, instance Instance, logger *slog.Logger) {
+	defer close(done)
+	ticker := time.NewTicker(instance.TTL / 3)
+	defer ticker.Stop()
+	registered := false
+	for {
+		var err error
+		if registered {
+			err = registrar.Heartbeat(ctx, instance)
+			if err != nil {
+				registered = false
+				logger.Warn("service heartbeat error: "+err.Error(), slog.String("service", instance.Service), slog.String("id", instance.ID))
+			}
+		}
+		if !registered && ctx.Err() == nil {
+			err = registrar.Register(ctx, instance)
+			if err != nil {
+				logger.Error("register service error: "+err.Error(), slog.String("service", instance.Service), slog.String("id", instance.ID))
+			} else {
+				registered = true
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// localIP the first non-loopback IPv4 address of the host
+func localIP() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "127.0.0.1"
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			return ipNet.IP.String()
+		}
+	}
+	return "127.0.0.1"
+}
+
+// call send the request with the JSON body (if not nil), and decode the JSON response into the out (if not nil)
+func call(ctx context.Context, client *http.Client, method string, url string, header http.Header, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s %s", method, url, res.Status, bytes.TrimSpace(data))
+	}
+	if out != nil {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package easierweb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Renderer render the template by name, it is used by ctx.HTML
+type Renderer interface {
+	Render(w io.Writer, name string, data any) error
+}
+
+type HTMLTemplateOptions struct {
+	// root directory of the templates
+	Dir string
+	// file system of the templates (e.g. embed.FS), default os.DirFS(Dir)
+	FS fs.FS
+	// template file extensions, default [".html"]
+	Extensions []string
+	// glob patterns (relative to the root) of the shared templates, e.g. layouts and partials
+	// they are parsed into every page, so the pages can use {{template "layout" .}} and redefine the blocks
+	Shared []string
+	Funcs  template.FuncMap
+	// template delimiters, default {{ and }}
+	Delims [2]string
+	// reparse the templates on each render, only for development
+	Reload bool
+}
+
+// HTMLTemplate the html/template renderer, each page is parsed with the shared templates
+// the page is rendered by its path relative to the root, e.g. "users/list.html"
+type HTMLTemplate struct {
+	opt   HTMLTemplateOptions
+	mu    sync.RWMutex
+	pages map[string]*template.Template
+}
+
+func NewHTMLTemplate(opts HTMLTemplateOptions) (*HTMLTemplate, error) {
+	if opts.FS == nil {
+		if opts.Dir == "" {
+			return nil, errors.New("template dir is empty")
+		}
+		opts.FS = os.DirFS(opts.Dir)
+	} else if opts.Dir != "" && opts.Dir != "." {
+		sub, err := fs.Sub(opts.FS, opts.Dir)
+		if err != nil {
+			return nil, err
+		}
+		opts.FS = sub
+	}
+	if len(opts.Extensions) == 0 {
+		opts.Extensions = []string{".html"}
+	}
+	t := &HTMLTemplate{opt: opts}
+	err := t.load()
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *HTMLTemplate) Render(w io.Writer, name string, data any) error {
+	if t.opt.Reload {
+		err := t.load()
+		if err != nil {
+			return err
+		}
+	}
+	t.mu.RLock()
+	page, ok := t.pages[name]
+	t.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("template %s not found", name)
+	}
+	return page.ExecuteTemplate(w, name, data)
+}
+
+// Names get the names of the pages
+func (t *HTMLTemplate) Names() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	names := make([]string, 0, len(t.pages))
+	for name := range t.pages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (t *HTMLTemplate) load() error {
+	shared := make(map[string]bool)
+	var sharedFiles []string
+	for _, pattern := range t.opt.Shared {
+		matches, err := fs.Glob(t.opt.FS, pattern)
+		if err != nil {
+			return err
+		}
+		for _, m := range matches {
+			if !shared[m] {
+				shared[m] = true
+				sharedFiles = append(sharedFiles, m)
+			}
+		}
+	}
+
+	base := template.New("")
+	if t.opt.Delims[0] != "" && t.opt.Delims[1] != "" {
+		base.Delims(t.opt.Delims[0], t.opt.Delims[1])
+	}
+	if t.opt.Funcs != nil {
+		base.Funcs(t.opt.Funcs)
+	}
+	for _, file := range sharedFiles {
+		err := t.parse(base.New(file), file)
+		if err != nil {
+			return err
+		}
+	}
+
+	pages := make(map[string]*template.Template)
+	err := fs.WalkDir(t.opt.FS, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || shared[p] || !t.matchExtension(p) {
+			return nil
+		}
+		page, err := base.Clone()
+		if err != nil {
+			return err
+		}
+		err = t.parse(page.New(p), p)
+		if err != nil {
+			return err
+		}
+		pages[p] = page
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.pages = pages
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *HTMLTemplate) parse(tmpl *template.Template, file string) error {
+	data, err := fs.ReadFile(t.opt.FS, file)
+	if err != nil {
+		return err
+	}
+	_, err = tmpl.Parse(string(data))
+	return err
+}
+
+func (t *HTMLTemplate) matchExtension(file string) bool {
+	ext := path.Ext(file)
+	for _, v := range t.opt.Extensions {
+		if strings.EqualFold(v, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRenderer set the renderer used by ctx.HTML
+func (r *Router) SetRenderer(renderer Renderer) *Router {
+	r.renderer = renderer
+	return r
+}
+
+// HTML render the template by the router renderer (RouterOptions.Renderer)
+func (c *Context) HTML(code int, name string, data any) {
+	if c.skipWrite() {
+		return
+	}
+	if c.router.renderer == nil {
+		panic(errors.New("renderer is empty"))
+	}
+	// render into the buffer, so that nothing is written if the template fails
+	var buf bytes.Buffer
+	err := c.router.renderer.Render(&buf, name, data)
+	if err != nil {
+		panic(err)
+	}
+	c.AddContentType("text/html; charset=utf-8")
+	c.Write(code, buf.Bytes())
+}
@@ -0,0 +1,56 @@
+package easierweb
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// render test
+
+func TestRender(t *testing.T) {
+
+	fmt.Println("\n[TestRender] start")
+
+	tmpl, err := NewHTMLTemplate(HTMLTemplateOptions{
+		FS: fstest.MapFS{
+			"layouts/base.html":  {Data: []byte(`<html><title>{{block "title" .}}default{{end}}</title><body>{{template "content" .}}</body></html>`)},
+			"partials/user.html": {Data: []byte(`{{define "user"}}<b>{{.}}</b>{{end}}`)},
+			"index.html":         {Data: []byte(`{{define "content"}}index{{end}}{{template "layouts/base.html" .}}`)},
+			"users/list.html":    {Data: []byte(`{{define "title"}}users{{end}}{{define "content"}}{{range .}}{{template "user" .}}{{end}}{{end}}{{template "layouts/base.html" .}}`)},
+		},
+		Shared: []string{"layouts/*.html", "partials/*.html"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println("[TestRender] pages ->", tmpl.Names())
+
+	router := New(RouterOptions{
+		Renderer: tmpl,
+	})
+	router.GET("/", func(ctx *Context) {
+		ctx.HTML(http.StatusOK, "index.html", nil)
+	})
+	router.GET("/users", func(ctx *Context) {
+		ctx.HTML(http.StatusOK, "users/list.html", []string{"a", "<b>"})
+	})
+
+	cases := map[string]string{
+		"/":      `<html><title>default</title><body>index</body></html>`,
+		"/users": `<html><title>users</title><body><b>a</b><b>&lt;b&gt;</b></body></html>`,
+	}
+	for path, expect := range cases {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		fmt.Println("[TestRender] result ->", path, rec.Code, rec.Body.String())
+		if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != expect {
+			t.Fatal("rendered html does not match")
+		}
+	}
+
+	fmt.Println("\n[TestRender] end")
+}
@@ -0,0 +1,227 @@
+package easierweb
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// route the registered route record
+type route struct {
+	method      string
+	path        string
+	kind        string
+	handle      any
+	easyHandle  any
+	middlewares []Handle
+	doc         APIDoc
+	name        string
+	// constraints of the path parameters, set by the inline syntax or Where
+	constraints map[string]*regexp.Regexp
+	// plugins of the easy handle, nil for the other handles
+	plugins *routePlugins
+	// the body is not read before the handles
+	streamBody bool
+	// maximum size of the body, zero means RouterOptions.MaxBodySize
+	bodyLimit int64
+	// the websocket compression of the route, nil means WebsocketOptions.Compression
+	wsCompression *WebsocketCompression
+}
+
+// kinds of the routes that are not plain http apis
+const (
+	routeKindWS     = "websocket"
+	routeKindSSE    = "sse"
+	routeKindStatic = "static"
+)
+
+var ErrRouteNotFound = errors.New("route not found")
+
+// RouteInfo the registered route, e.g. for startup logging and tests
+type RouteInfo struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// empty for http apis, "websocket", "sse" or "static"
+	Kind string `json:"kind,omitempty"`
+	// set by Name
+	Name string `json:"name,omitempty"`
+	// function name of the handle (or the easy handle)
+	Handler string `json:"handler"`
+	// function names of the router and route-level middlewares, in the execution order
+	Middlewares []string `json:"middlewares,omitempty"`
+}
+
+// APIDoc the metadata of the route, used to generate the OpenAPI document
+type APIDoc struct {
+	Summary     string
+	Description string
+	Tags        []string
+	OperationID string
+	Deprecated  bool
+}
+
+func (r *Router) addRoute(method, path, kind string, handle any, easyHandle any, middlewares []Handle) *route {
+	path, constraints := parseConstraints(path)
+	rt := &route{
+		method:      method,
+		path:        path,
+		constraints: constraints,
+		kind:        kind,
+		handle:      handle,
+		easyHandle:  easyHandle,
+		middlewares: middlewares,
+	}
+	r.routes = append(r.routes, rt)
+	r.lastRoutes = append(r.lastRoutes, rt)
+	if len(r.onRouteRegistered) > 0 {
+		info := r.routeInfo(rt)
+		for _, hook := range r.onRouteRegistered {
+			hook(info)
+		}
+	}
+	return rt
+}
+
+// Describe set the metadata of the last registered route (all methods if registered by Any)
+// e.g. router.EasyGET("/users/:id", getUser).Describe(easierweb.APIDoc{Summary: "get user", Tags: []string{"user"}})
+func (r *Router) Describe(doc APIDoc) *Router {
+	for _, rt := range r.lastRoutes {
+		rt.doc = doc
+	}
+	return r
+}
+
+// Name name the last registered route (all methods if registered by Any), the name is used by URL to generate the path
+// e.g. router.EasyGET("/users/:id", getUser).Name("user.show")
+func (r *Router) Name(name string) *Router {
+	if len(r.lastRoutes) == 0 {
+		panic(fmt.Errorf("no route to name %s", name))
+	}
+	if rt, ok := r.namedRoutes[name]; ok && rt.path != r.lastRoutes[0].path {
+		panic(fmt.Errorf("route name %s is already used by %s", name, rt.path))
+	}
+	for _, rt := range r.lastRoutes {
+		rt.name = name
+	}
+	r.namedRoutes[name] = r.lastRoutes[0]
+	return r
+}
+
+// RouteName get the name of the matched route (see Router.Name), empty if it is not named
+func (c *Context) RouteName() string {
+	return c.routeName
+}
+
+// URL generate the path of the named route, the pairs are the parameter names and values, the pairs that are not path parameters are appended as the query
+// e.g. router.URL("user.show", "id", 42, "tab", "posts") -> /users/42?tab=posts
+func (r *Router) URL(name string, pairs ...any) (string, error) {
+	rt, ok := r.namedRoutes[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrRouteNotFound, name)
+	}
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("url %s: odd number of parameters", name)
+	}
+	values := make(map[string]string, len(pairs)/2)
+	var keys []string
+	for i := 0; i < len(pairs); i += 2 {
+		key := fmt.Sprint(pairs[i])
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = fmt.Sprint(pairs[i+1])
+	}
+	used := make(map[string]bool)
+	segments := strings.Split(rt.path, "/")
+	for i, s := range segments {
+		if len(s) == 0 || (s[0] != ':' && s[0] != '*') {
+			continue
+		}
+		param := s[1:]
+		v, ok := values[param]
+		if !ok {
+			return "", fmt.Errorf("url %s: missing parameter %s", name, param)
+		}
+		used[param] = true
+		if s[0] == ':' {
+			segments[i] = url.PathEscape(v)
+			continue
+		}
+		// the catch-all parameter keeps the slashes
+		parts := strings.Split(strings.TrimPrefix(v, "/"), "/")
+		for j := range parts {
+			parts[j] = url.PathEscape(parts[j])
+		}
+		segments[i] = strings.Join(parts, "/")
+	}
+	path := strings.Join(segments, "/")
+	query := url.Values{}
+	for _, k := range keys {
+		if !used[k] {
+			query.Set(k, values[k])
+		}
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path, nil
+}
+
+// Routes get the registered routes, in the registration order
+func (r *Router) Routes() []RouteInfo {
+	routes := make([]RouteInfo, 0, len(r.routes))
+	for _, rt := range r.routes {
+		routes = append(routes, r.routeInfo(rt))
+	}
+	return routes
+}
+
+func (r *Router) routeInfo(rt *route) RouteInfo {
+	info := RouteInfo{
+		Method:  rt.method,
+		Path:    rt.path,
+		Kind:    rt.kind,
+		Name:    rt.name,
+		Handler: funcName(rt.handle),
+	}
+	if rt.easyHandle != nil {
+		info.Handler = funcName(rt.easyHandle)
+	}
+	// the static files are served without the middlewares
+	if rt.kind == routeKindStatic {
+		return info
+	}
+	for _, m := range append(append([]Handle{}, r.middlewares...), rt.middlewares...) {
+		info.Middlewares = append(info.Middlewares, funcName(m))
+	}
+	return info
+}
+
+// ServeRoutes serve the route table (json) on the path
+func (r *Router) ServeRoutes(path string, middlewares ...Handle) *Router {
+	return r.GET(path, func(ctx *Context) {
+		ctx.WriteJSON(http.StatusOK, r.Routes())
+	}, middlewares...)
+}
+
+func funcName(f any) string {
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	fn := runtime.FuncForPC(v.Pointer())
+	if fn == nil {
+		return ""
+	}
+	return fn.Name()
+}
+
+// RouteURL generate the path of the named route, see Router.URL
+func (c *Context) RouteURL(name string, pairs ...any) (string, error) {
+	return c.router.URL(name, pairs...)
+}
@@ -0,0 +1,218 @@
+package easierweb
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// route introspection test
+
+func TestRoutes(t *testing.T) {
+
+	fmt.Println("\n[TestRoutes] start")
+
+	middleware := func(ctx *Context) {
+		ctx.Next()
+	}
+	router := New(RouterOptions{
+		RootPath: "/test/routes",
+	}).Use(middleware)
+	router.EasyGET("/get/:id", routerTestEasyQueryAPI).Name("get")
+	router.GET("/plain", func(ctx *Context) {}, middleware)
+	router.SSE("/sse", func(ctx *Context) {})
+	router.Static("/static/*filepath", ".")
+
+	routes := router.Routes()
+	for _, v := range routes {
+		fmt.Println("[TestRoutes] route ->", v.Method, v.Path, v.Kind, v.Handler, v.Middlewares)
+	}
+	if len(routes) != 4 {
+		t.Fatal("number of routes does not match")
+	}
+	if routes[0].Path != "/test/routes/get/:id" || !strings.HasSuffix(routes[0].Handler, "routerTestEasyQueryAPI") || len(routes[0].Middlewares) != 1 {
+		t.Fatal("easy route does not match")
+	}
+	if len(routes[1].Middlewares) != 2 {
+		t.Fatal("route-level middlewares do not match")
+	}
+	if routes[2].Kind != routeKindSSE || routes[3].Kind != routeKindStatic || len(routes[3].Middlewares) != 0 {
+		t.Fatal("route kinds do not match")
+	}
+
+	url, err := router.URL("get", "id", 42, "tab", "a b")
+	fmt.Println("[TestRoutes] url ->", url)
+	if err != nil || url != "/test/routes/get/42?tab=a+b" {
+		t.Fatal("url does not match")
+	}
+	if _, err = router.URL("get"); err == nil {
+		t.Fatal("missing parameter is not reported")
+	}
+
+	// the name of another route panics with an error
+	func() {
+		defer func() {
+			if _, ok := recover().(error); !ok {
+				t.Fatal("duplicate route name is not rejected")
+			}
+		}()
+		router.GET("/other", func(ctx *Context) {}).Name("get")
+	}()
+
+	fmt.Println("\n[TestRoutes] end")
+}
+
+// path parameter access test
+
+func TestParam(t *testing.T) {
+
+	fmt.Println("\n[TestParam] start")
+
+	ctx, _ := NewTestContext("GET", "/users/42/posts/hello", nil, "id", "42", "slug", "hello")
+	id, err := ctx.ParamInt("id")
+	fmt.Println("[TestParam] params ->", id, ctx.Param("slug"), ctx.Param("missing"))
+	if err != nil || id != 42 || ctx.Param("slug") != "hello" || ctx.Param("missing") != "" {
+		t.Fatal("params do not match")
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = ctx.ParamInt("id")
+		_ = ctx.Param("slug")
+	})
+	fmt.Println("[TestParam] allocs ->", allocs)
+	if allocs != 0 {
+		t.Fatal("param access allocates")
+	}
+}
+
+func BenchmarkParam(b *testing.B) {
+	ctx, _ := NewTestContext("GET", "/users/42", nil, "id", "42")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = ctx.ParamInt("id")
+	}
+}
+
+// trailing slash and case-insensitive routing test
+
+func TestRouteMatching(t *testing.T) {
+
+	fmt.Println("\n[TestRouteMatching] start")
+
+	handle := func(ctx *Context) {
+		ctx.WriteString(http.StatusOK, ctx.Request.URL.Path+" "+ctx.Param("name")+ctx.Param("filepath"))
+	}
+	register := func(opts RouterOptions) *Router {
+		opts.CloseConsolePrint = true
+		router := New(opts)
+		router.GET("/users/:name", handle)
+		router.GET("/users/:name/Profile", handle)
+		router.GET("/files/*filepath", handle)
+		router.POST("/orders/", handle)
+		return router
+	}
+	defaults := register(RouterOptions{})
+	disabled := register(RouterOptions{DisableRedirectTrailingSlash: true, DisableRedirectFixedPath: true})
+	insensitive := register(RouterOptions{CaseInsensitive: true})
+
+	cases := []struct {
+		name     string
+		router   *Router
+		method   string
+		path     string
+		code     int
+		expect   string
+		location string
+	}{
+		{"exact", defaults, http.MethodGet, "/users/Bob", http.StatusOK, "/users/Bob Bob", ""},
+		{"trailing slash redirect", defaults, http.MethodGet, "/users/Bob/", http.StatusMovedPermanently, "", "/users/Bob"},
+		{"trailing slash redirect post", defaults, http.MethodPost, "/orders", http.StatusTemporaryRedirect, "", "/orders/"},
+		{"fixed path redirect", defaults, http.MethodGet, "/USERS/Bob/profile", http.StatusMovedPermanently, "", "/users/Bob/Profile"},
+		{"trailing slash disabled", disabled, http.MethodGet, "/users/Bob/", http.StatusNotFound, "", ""},
+		{"fixed path disabled", disabled, http.MethodGet, "/USERS/Bob", http.StatusNotFound, "", ""},
+		// the static segments are matched without redirecting, the parameters keep their case
+		{"case-insensitive", insensitive, http.MethodGet, "/USERS/Bob", http.StatusOK, "/users/Bob Bob", ""},
+		{"case-insensitive nested", insensitive, http.MethodGet, "/Users/Bob/PROFILE", http.StatusOK, "/users/Bob/Profile Bob", ""},
+		{"case-insensitive catch-all", insensitive, http.MethodGet, "/Files/A/b.txt", http.StatusOK, "/files/A/b.txt /A/b.txt", ""},
+		{"case-insensitive method", insensitive, http.MethodPost, "/USERS/Bob", http.StatusMethodNotAllowed, "", ""},
+		{"case-insensitive not found", insensitive, http.MethodGet, "/members/Bob", http.StatusNotFound, "", ""},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		c.router.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, nil))
+		fmt.Println("[TestRouteMatching] result ->", c.name, rec.Code, rec.Body.String(), rec.Header().Get("Location"))
+		if rec.Code != c.code || (c.expect != "" && rec.Body.String() != c.expect) || rec.Header().Get("Location") != c.location {
+			t.Fatal(c.name + ": route matching does not match")
+		}
+	}
+
+	fmt.Println("\n[TestRouteMatching] end")
+}
+
+// path parameter constraints test
+
+func TestRouteConstraints(t *testing.T) {
+
+	fmt.Println("\n[TestRouteConstraints] start")
+
+	handle := func(ctx *Context) {
+		ctx.WriteString(http.StatusOK, ctx.Param("id")+ctx.Param("slug")+ctx.Param("filepath"))
+	}
+	router := New(RouterOptions{CloseConsolePrint: true})
+	router.GET("/inline/:id|^[0-9]+$", handle)
+	router.GET("/named/:id|uuid", handle)
+	router.GET("/where/:id", handle).Where("id", "int")
+	router.GET("/regexp/:slug", handle).Where("slug", `[a-z0-9-]+`)
+	router.GET("/files/*filepath", handle).Where("filepath", `/[a-z]+\.txt`)
+	// the inline constraint of the catch-all parameter can contain /
+	router.GET(`/docs/*filepath|/[a-z]+/[a-z]+\.md`, handle)
+	router.Any("/any/:id", handle).Where("id", "uint")
+	router.Group("/group").GET("/:id", handle).Where("id", "alpha")
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		code   int
+	}{
+		{"inline", http.MethodGet, "/inline/42", http.StatusOK},
+		{"inline failed", http.MethodGet, "/inline/abc", http.StatusNotFound},
+		{"named type", http.MethodGet, "/named/123e4567-e89b-12d3-a456-426614174000", http.StatusOK},
+		{"named type failed", http.MethodGet, "/named/123", http.StatusNotFound},
+		{"where", http.MethodGet, "/where/-7", http.StatusOK},
+		{"where failed", http.MethodGet, "/where/7a", http.StatusNotFound},
+		// the regexp must match the whole value
+		{"regexp", http.MethodGet, "/regexp/hello-world", http.StatusOK},
+		{"regexp partial", http.MethodGet, "/regexp/Hello-world", http.StatusNotFound},
+		{"catch-all", http.MethodGet, "/files/a.txt", http.StatusOK},
+		{"catch-all failed", http.MethodGet, "/files/a/b.txt", http.StatusNotFound},
+		{"inline catch-all", http.MethodGet, "/docs/guide/intro.md", http.StatusOK},
+		{"inline catch-all failed", http.MethodGet, "/docs/intro.md", http.StatusNotFound},
+		{"any get", http.MethodGet, "/any/1", http.StatusOK},
+		{"any post", http.MethodPost, "/any/1", http.StatusOK},
+		{"any failed", http.MethodDelete, "/any/-1", http.StatusNotFound},
+		{"group", http.MethodGet, "/group/abc", http.StatusOK},
+		{"group failed", http.MethodGet, "/group/42", http.StatusNotFound},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, nil))
+		fmt.Println("[TestRouteConstraints] result ->", c.name, rec.Code, rec.Body.String())
+		if rec.Code != c.code {
+			t.Fatal(c.name + ": constraint does not match")
+		}
+	}
+
+	// the named parameters never contain /, the constraint is not split into the static segments
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("the constraint containing / is not rejected")
+			}
+		}()
+		router.GET(`/dates/:date|\d{4}/\d{2}`, handle)
+	}()
+
+	fmt.Println("\n[TestRouteConstraints] end")
+}
@@ -3,12 +3,22 @@ package easierweb
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"github.com/gorilla/websocket"
 	"github.com/julienschmidt/httprouter"
-	"golang.org/x/net/websocket"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/valyala/fasthttp"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
+	"os"
+	"os/signal"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
 )
 
 type RouterOptions struct {
@@ -17,22 +27,120 @@ type RouterOptions struct {
 	ErrorHandle            ErrorHandle
 	RequestHandle          RequestHandle
 	ResponseHandle         ResponseHandle
-	Logger                 *slog.Logger
-	CloseConsolePrint      bool
+	// executed around the request and response handles of the easy handles, in order, before the route-level plugins
+	RequestPlugins    []RequestPlugin
+	ResponsePlugins   []ResponsePlugin
+	Logger            *slog.Logger
+	CloseConsolePrint bool
+	ShutdownTimeout   time.Duration
+	ShutdownSignals   bool
+	Decoders          map[string]Decoder
+	// json implementation of the default request/response handles, ctx.WriteJSON, ctx.BindJSON, etc. default encoding/json
+	JSONCodec      Codec
+	Validator      Validator
+	Websocket      WebsocketOptions
+	NotFoundHandle Handle
+	// executed when the path matches but the method does not, the Allow header is set, default a 405 *Error passed to the ErrorHandle
+	MethodNotAllowedHandle Handle
+	// executed for the OPTIONS requests of the existing paths without the OPTIONS handles, the Allow header is set (it enables AutoOPTIONS)
+	OptionsHandle Handle
+	RecoveryHook  RecoveryHook
+	// renderer of ctx.HTML, e.g. NewHTMLTemplate
+	Renderer Renderer
+	// maximum size in bytes of the request body (413 if exceeded), zero means no limit, it can be overridden by the route-level BodyLimit
+	MaxBodySize int64
+	// keys of the signed and encrypted cookies, the first key is used to sign/encrypt, all keys are tried to verify/decrypt (key rotation)
+	CookieSecrets [][]byte
+	// settings of the http.Server created by Run and RunTLS, e.g. timeouts and h2c
+	Server ServerOptions
+	// path prefixes (e.g. group paths) that use protobuf as the default codec when the Content-Type or Accept header is absent
+	ProtobufPaths []string
+	// settings of RunAutoTLS, e.g. the certificate cache directory
+	AutoTLS AutoTLSOptions
+	// disable the redirect of /foo/ to /foo (or /foo to /foo/) if only the other one is registered
+	DisableRedirectTrailingSlash bool
+	// disable the redirect of the cleaned and case-corrected path, e.g. /FOO and /../foo to /foo
+	DisableRedirectFixedPath bool
+	// match the static segments of the path case-insensitively without redirecting, e.g. /Users/Bob is served by /users/:name (name is Bob)
+	CaseInsensitive bool
+	// serve the HEAD requests by the GET handles if the HEAD handles are not registered (the body is discarded by the server)
+	AutoHEAD bool
+	// answer the OPTIONS requests with 204 and the Allow header of the path if the OPTIONS handles are not registered,
+	// the router-level middlewares (e.g. CORS) are executed
+	AutoOPTIONS bool
+	// the server engine of Run, EngineNetHTTP (default) or EngineFastHTTP
+	Engine string
+	// IPs or CIDRs of the reverse proxies (e.g. "10.0.0.0/8"), ctx.ClientIP reads the X-Forwarded-For and X-Real-IP headers
+	// only if the request comes from them, otherwise the headers are ignored (they can be forged by the clients)
+	TrustedProxies []string
+	// the rules of hiding the secret headers and body fields in the logs (access log, dump and error log), see ctx.Redactor()
+	Redact RedactOptions
+	// buffer the responses, so the middlewares and hooks can modify them before they are written to the client, see ctx.BufferResponse
+	ResponseBuffer ResponseBufferOptions
+	// indent the JSON responses and include the error details and stack traces in the error responses, for all requests or the authorized requests
+	// with the query parameter, e.g. Debug: DebugOptions{Enabled: os.Getenv("APP_ENV") == "dev"}, the production mode writes the compact JSON
+	Debug DebugOptions
+	// prune the results of the default response handle to the requested fields (?fields=id,name), see ctx.SelectFields
+	Fields FieldsOptions
+	// the query parameters and the limits of ctx.BindPagination
+	Pagination PaginationOptions
 }
 
 type Router struct {
 	rootPath               string
 	multipartFormMaxMemory int64
 	router                 *httprouter.Router
-	server                 *http.Server
+	servers                []*http.Server
+	fastServers            []*fasthttp.Server
+	quicServers            []*http3.Server
+	serversLock            sync.Mutex
 	middlewares            []Handle
 	errorHandle            ErrorHandle
+	recoveryHook           RecoveryHook
+	renderer               Renderer
+	cookieSecrets          [][]byte
+	redactor               *Redactor
+	auditors               []*auditor
+	onStart                []func()
+	onPreShutdown          []func(ctx context.Context)
+	onShutdown             []func(ctx context.Context)
+	onRouteRegistered      []func(info RouteInfo)
 	requestHandle          RequestHandle
 	responseHandle         ResponseHandle
+	requestPlugins         []RequestPlugin
+	responsePlugins        []ResponsePlugin
 	logger                 *slog.Logger
 	contextPool            *sync.Pool
 	closeConsolePrint      bool
+	shutdownTimeout        time.Duration
+	shutdownSignals        bool
+	decoders               map[string]Decoder
+	jsonCodec              Codec
+	validator              Validator
+	metrics                *Metrics
+	health                 *Health
+	upgrader               *websocket.Upgrader
+	wsConns                map[*WSConn]struct{}
+	wsConnsLock            sync.Mutex
+	websocketOptions       WebsocketOptions
+	serverOptions          ServerOptions
+	autoTLSOptions         AutoTLSOptions
+	routes                 []*route
+	lastRoutes             []*route
+	namedRoutes            map[string]*route
+	caseInsensitive        bool
+	autoHEAD               bool
+	autoOPTIONS            bool
+	methodNotAllowedHandle Handle
+	optionsHandle          Handle
+	protobufPaths          []string
+	trustedProxies         []netip.Prefix
+	maxBodySize            int64
+	responseBuffer         ResponseBufferOptions
+	debug                  DebugOptions
+	fields                 FieldsOptions
+	pagination             PaginationOptions
+	engine                 string
 }
 
 func New(opts ...RouterOptions) *Router {
@@ -40,9 +148,14 @@ func New(opts ...RouterOptions) *Router {
 		multipartFormMaxMemory: 32 << 20,
 		router:                 httprouter.New(),
 		errorHandle:            defaultErrorHandle(),
+		redactor:               NewRedactor(),
 		requestHandle:          defaultRequestHandle(),
 		responseHandle:         defaultResponseHandle(),
 		logger:                 slog.Default(),
+		decoders:               defaultDecoders(),
+		jsonCodec:              stdJSONCodec{},
+		namedRoutes:            make(map[string]*route),
+		validator:              NewTagValidator(),
 		contextPool: &sync.Pool{
 			New: func() any {
 				return new(Context)
@@ -65,14 +178,121 @@ func New(opts ...RouterOptions) *Router {
 		if v.ResponseHandle != nil {
 			r.responseHandle = v.ResponseHandle
 		}
+		r.requestPlugins = append(r.requestPlugins, v.RequestPlugins...)
+		r.responsePlugins = append(r.responsePlugins, v.ResponsePlugins...)
 		if v.Logger != nil {
 			r.logger = v.Logger
 		}
+		if v.ShutdownTimeout > 0 {
+			r.shutdownTimeout = v.ShutdownTimeout
+		}
+		if v.Validator != nil {
+			r.validator = v.Validator
+		}
+		if v.JSONCodec != nil {
+			r.jsonCodec = v.JSONCodec
+			r.decoders[MediaTypeJSON] = v.JSONCodec.Unmarshal
+		}
+		for mediaType, decoder := range v.Decoders {
+			r.SetDecoder(mediaType, decoder)
+		}
+		r.websocketOptions = v.Websocket
+		r.serverOptions = v.Server
+		r.autoTLSOptions = v.AutoTLS
+		r.setFallbackHandles(v.NotFoundHandle, v.MethodNotAllowedHandle)
+		if v.OptionsHandle != nil {
+			r.optionsHandle = v.OptionsHandle
+		}
+		r.recoveryHook = v.RecoveryHook
+		r.renderer = v.Renderer
+		r.cookieSecrets = v.CookieSecrets
+		r.redactor = NewRedactor(v.Redact)
+		r.router.RedirectTrailingSlash = !v.DisableRedirectTrailingSlash
+		r.router.RedirectFixedPath = !v.DisableRedirectFixedPath
+		r.caseInsensitive = v.CaseInsensitive
+		r.autoHEAD = v.AutoHEAD
+		r.autoOPTIONS = r.autoOPTIONS || v.AutoOPTIONS
+		r.protobufPaths = v.ProtobufPaths
+		trustedProxies, err := ParseIPPrefixes(v.TrustedProxies)
+		if err != nil {
+			panic(fmt.Errorf("invalid trusted proxies: %w", err))
+		}
+		r.trustedProxies = trustedProxies
+		if v.MaxBodySize > 0 {
+			r.maxBodySize = v.MaxBodySize
+		}
 		r.closeConsolePrint = v.CloseConsolePrint
+		r.shutdownSignals = v.ShutdownSignals
+		if v.Engine != "" {
+			r.engine = v.Engine
+		}
+		r.responseBuffer = v.ResponseBuffer
+		if v.Pagination != (PaginationOptions{}) {
+			r.pagination = v.Pagination
+		}
+		if v.Debug.Query != "" && v.Debug.Authorize == nil {
+			panic(errors.New("debug query requires the authorize function"))
+		}
+		r.debug = v.Debug
+		if v.Fields.Enabled {
+			r.fields = v.Fields
+			if r.fields.Query == "" {
+				r.fields.Query = "fields"
+			}
+			if r.fields.MaxFields <= 0 {
+				r.fields.MaxFields = 100
+			}
+		}
 	}
+	if r.responseBuffer.MaxSize <= 0 {
+		r.responseBuffer.MaxSize = 1 << 20
+	}
+	if r.pagination.DefaultLimit <= 0 {
+		r.pagination.DefaultLimit = 20
+	}
+	if r.pagination.MaxLimit <= 0 {
+		r.pagination.MaxLimit = 100
+	}
+	r.pagination.DefaultLimit = min(r.pagination.DefaultLimit, r.pagination.MaxLimit)
+	if r.pagination.PageQuery == "" {
+		r.pagination.PageQuery = "page"
+	}
+	if r.pagination.LimitQuery == "" {
+		r.pagination.LimitQuery = "limit"
+	}
+	if r.pagination.CursorQuery == "" {
+		r.pagination.CursorQuery = "cursor"
+	}
+	r.router.MethodNotAllowed = http.HandlerFunc(r.methodNotAllowed)
+	if r.autoOPTIONS || r.optionsHandle != nil {
+		r.router.GlobalOPTIONS = http.HandlerFunc(r.serveOPTIONS)
+	}
+	r.upgrader = newUpgrader(r.websocketOptions)
+	r.serverOptions = defaultServerOptions(r.serverOptions)
 	return r
 }
 
+// setFallbackHandles set the handles for unmatched routes, they are executed with the router-level middlewares
+func (r *Router) setFallbackHandles(notFound, methodNotAllowed Handle) {
+	if notFound != nil {
+		r.router.NotFound = http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			r.handle(nil, notFound, res, req, nil)
+		})
+	}
+	if methodNotAllowed != nil {
+		r.methodNotAllowedHandle = methodNotAllowed
+	}
+}
+
+// notFound execute the not found handle, or the default 404 response if it is not set
+func (r *Router) notFound(res http.ResponseWriter, req *http.Request) {
+	if r.router.NotFound != nil {
+		r.router.NotFound.ServeHTTP(res, req)
+	} else {
+		http.NotFound(res, req)
+	}
+}
+
 const (
 	MethodGET     = "GET"
 	MethodHEAD    = "HEAD"
@@ -114,11 +334,20 @@ func (r *Router) EasyDELETE(path string, easyHandle any, middlewares ...Handle)
 }
 
 func (r *Router) EasyAPI(method, path string, easyHandle any, middlewares ...Handle) *Router {
-	return r.API(method, path, r.easyHandle(easyHandle), middlewares...)
+	r.lastRoutes = nil
+	plugins := &routePlugins{}
+	r.api(method, path, r.easyHandle(easyHandle, plugins), easyHandle, middlewares).plugins = plugins
+	return r
 }
 
 func (r *Router) EasyAny(path string, easyHandle any, middlewares ...Handle) *Router {
-	return r.Any(path, r.easyHandle(easyHandle), middlewares...)
+	r.lastRoutes = nil
+	plugins := &routePlugins{}
+	handle := r.easyHandle(easyHandle, plugins)
+	for _, method := range methodNames {
+		r.api(method, path, handle, easyHandle, middlewares).plugins = plugins
+	}
+	return r
 }
 
 // basic usage function
@@ -154,51 +383,94 @@ func (r *Router) DELETE(path string, handle Handle, middlewares ...Handle) *Rout
 var methodNames = []string{MethodGET, MethodHEAD, MethodOPTIONS, MethodPOST, MethodPUT, MethodPATCH, MethodDELETE}
 
 func (r *Router) Any(path string, handle Handle, middlewares ...Handle) *Router {
+	r.lastRoutes = nil
 	for _, method := range methodNames {
-		r.API(method, path, handle, middlewares...)
+		r.api(method, path, handle, nil, middlewares)
 	}
 	return r
 }
 
 func (r *Router) API(method, path string, handle Handle, middlewares ...Handle) *Router {
-	route := r.rootPath + path
-	r.router.Handle(method, route, func(res http.ResponseWriter, req *http.Request, par httprouter.Params) {
-		r.handle(route, handle, res, req, par, nil, false, middlewares...)
-	})
+	r.lastRoutes = nil
+	r.api(method, path, handle, nil, middlewares)
 	return r
 }
 
+func (r *Router) api(method, path string, handle Handle, easyHandle any, middlewares []Handle) *route {
+	rt := r.addRoute(method, r.rootPath+path, "", handle, easyHandle, middlewares)
+	r.router.Handle(method, rt.path, func(res http.ResponseWriter, req *http.Request, par httprouter.Params) {
+		if !r.matchConstraints(rt, res, req, par) {
+			return
+		}
+		r.handle(rt, handle, res, req, par, middlewares...)
+	})
+	return rt
+}
+
 func (r *Router) WS(path string, handle Handle, middlewares ...Handle) *Router {
-	route := r.rootPath + path
+	r.lastRoutes = nil
+	rt := r.addRoute(MethodGET, r.rootPath+path, routeKindWS, handle, nil, middlewares)
+	route := rt.path
 	r.router.GET(route, func(res http.ResponseWriter, req *http.Request, par httprouter.Params) {
-		websocket.Server{
-			Handler: func(ws *websocket.Conn) {
-				r.handle(route, handle, res, req, par, ws, false, middlewares...)
-			},
-			Handshake: func(config *websocket.Config, req *http.Request) error {
-				// 解决跨域
-				return nil
-			},
-		}.ServeHTTP(res, req)
+		if !r.matchConstraints(rt, res, req, par) {
+			return
+		}
+		// the middlewares are executed before the upgrade, they can abort it by writing a response, e.g. 401
+		r.handle(rt, func(ctx *Context) {
+			if ctx.Written() || ctx.IsAborted() {
+				return
+			}
+			upgrader, opt := r.upgrader, r.websocketOptions
+			if rt.wsCompression != nil {
+				u := *r.upgrader
+				u.EnableCompression = rt.wsCompression.Enabled
+				upgrader, opt.Compression = &u, *rt.wsCompression
+			}
+			conn, err := upgrader.Upgrade(ctx.ResponseWriter, ctx.Request, nil)
+			if err != nil {
+				// the upgrader has responded with an HTTP error
+				r.logger.Error(fmt.Sprintf("websocket upgrade error: %s", err), slog.String("route", route))
+				return
+			}
+			// the response is hijacked, it cannot be written by the http response methods
+			ctx.WebsocketConn = newWSConn(conn, opt, r.jsonCodec)
+			r.trackWSConn(ctx.WebsocketConn)
+			ctx.writer.status.Store(http.StatusSwitchingProtocols)
+			ctx.written = true
+			handle(ctx)
+		}, res, req, par, middlewares...)
 	})
 	return r
 }
 
 func (r *Router) SSE(path string, handle Handle, middlewares ...Handle) *Router {
-	route := r.rootPath + path
-	r.router.GET(route, func(res http.ResponseWriter, req *http.Request, par httprouter.Params) {
-		r.handle(route, handle, res, req, par, nil, true, middlewares...)
+	r.lastRoutes = nil
+	rt := r.addRoute(MethodGET, r.rootPath+path, routeKindSSE, handle, nil, middlewares)
+	r.router.GET(rt.path, func(res http.ResponseWriter, req *http.Request, par httprouter.Params) {
+		if !r.matchConstraints(rt, res, req, par) {
+			return
+		}
+		r.handle(rt, handle, res, req, par, middlewares...)
 	})
 	return r
 }
 
-func (r *Router) Static(path, dir string) *Router {
-	return r.StaticFS(path, http.Dir(dir))
+func (r *Router) Static(path, dir string, opts ...StaticOptions) *Router {
+	if len(opts) > 0 && opts[0].DenySymlinkEscape {
+		return r.StaticFS(path, symlinkSafeDir{root: dir}, opts...)
+	}
+	return r.StaticFS(path, http.Dir(dir), opts...)
 }
 
-func (r *Router) StaticFS(path string, fs http.FileSystem) *Router {
+func (r *Router) StaticFS(path string, fs http.FileSystem, opts ...StaticOptions) *Router {
 	route := r.rootPath + path
-	r.router.ServeFiles(route, fs)
+	r.lastRoutes = nil
+	r.addRoute(MethodGET, route, routeKindStatic, nil, nil, nil)
+	opt := StaticOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	r.serveStatic(route, fs, opt)
 	return r
 }
 
@@ -208,37 +480,233 @@ func (r *Router) Use(middlewares ...Handle) *Router {
 }
 
 func (r *Router) Run(addr string) error {
-	return r.Serve(&http.Server{
-		Addr: addr,
-	})
+	if r.engine == EngineFastHTTP {
+		return r.RunFast(addr)
+	}
+	return r.Serve(r.newServer(addr))
 }
 
 func (r *Router) RunTLS(addr string, certFile string, keyFile string, tlsConfig *tls.Config) error {
-	return r.ServeTLS(&http.Server{
-		Addr:      addr,
-		TLSConfig: tlsConfig,
-	}, certFile, keyFile)
+	server := r.newServer(addr)
+	server.TLSConfig = tlsConfig
+	return r.ServeTLS(server, certFile, keyFile)
+}
+
+// RunListener start the server on the listener, e.g. systemd socket activation
+func (r *Router) RunListener(l net.Listener) error {
+	server := r.newServer(l.Addr().String())
+	server.Handler = r.handler()
+	r.addServer(server)
+	r.consoleStartPrint(l.Addr().String())
+	return r.serve(func() error {
+		return server.Serve(l)
+	})
+}
+
+// RunUnix start the server on the unix domain socket, the stale socket file is removed, and the file mode is set to perm
+func (r *Router) RunUnix(path string, perm os.FileMode) error {
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		err = os.Remove(path)
+		if err != nil {
+			return err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(path, perm)
+	if err != nil {
+		_ = l.Close()
+		return err
+	}
+	return r.RunListener(l)
+}
+
+// ListenConfig an address of RunMultiConfig, TLS is enabled if CertFile and KeyFile are set (or TLSConfig has the certificates)
+type ListenConfig struct {
+	Addr      string
+	CertFile  string
+	KeyFile   string
+	TLSConfig *tls.Config
+}
+
+// RunMulti start the plaintext servers on all addresses, e.g. "0.0.0.0:8080", "[::]:8080"
+func (r *Router) RunMulti(addrs ...string) error {
+	configs := make([]ListenConfig, 0, len(addrs))
+	for _, addr := range addrs {
+		configs = append(configs, ListenConfig{Addr: addr})
+	}
+	return r.RunMultiConfig(configs...)
+}
+
+// RunMultiConfig start the servers (some TLS, some not) as one unit, Close shuts down all of them
+// returns when all servers are closed, if any of them fails (e.g. the address is in use), the others are closed
+func (r *Router) RunMultiConfig(configs ...ListenConfig) error {
+	if len(configs) == 0 {
+		return errors.New("no listen address")
+	}
+	servers := make([]*http.Server, 0, len(configs))
+	addrs := make([]string, 0, len(configs))
+	listens := make([]ListenConfig, 0, len(configs))
+	for _, c := range configs {
+		if c.CertFile != "" || c.TLSConfig != nil {
+			config, certFile, keyFile, err := r.tlsConfig(c.TLSConfig, c.CertFile, c.KeyFile)
+			if err != nil {
+				return err
+			}
+			c.TLSConfig, c.CertFile, c.KeyFile = config, certFile, keyFile
+		}
+		listens = append(listens, c)
+		server := r.newServer(c.Addr)
+		server.TLSConfig = c.TLSConfig
+		server.Handler = r.handler()
+		r.addServer(server)
+		servers = append(servers, server)
+		addrs = append(addrs, c.Addr)
+	}
+	r.consoleStartPrint(strings.Join(addrs, ", "))
+	return r.serve(func() error {
+		errs := make(chan error, len(servers))
+		for i, server := range servers {
+			server, c := server, listens[i]
+			go func() {
+				if c.CertFile != "" || c.TLSConfig != nil {
+					errs <- server.ListenAndServeTLS(c.CertFile, c.KeyFile)
+				} else {
+					errs <- server.ListenAndServe()
+				}
+			}()
+		}
+		var first error
+		for range servers {
+			err := <-errs
+			if first == nil {
+				first = err
+				if !errors.Is(err, http.ErrServerClosed) {
+					_ = r.Close()
+				}
+			}
+		}
+		return first
+	})
 }
 
 func (r *Router) Serve(server *http.Server) error {
-	r.server = server
-	r.server.Handler = r.router
-	r.consoleStartPrint(r.server.Addr)
-	return r.server.ListenAndServe()
+	server.Handler = r.handler()
+	r.addServer(server)
+	r.consoleStartPrint(server.Addr)
+	return r.serve(server.ListenAndServe)
 }
 
 func (r *Router) ServeTLS(server *http.Server, certFile string, keyFile string) error {
-	r.server = server
-	r.server.Handler = r.router
-	r.consoleStartPrint(r.server.Addr)
-	return r.server.ListenAndServeTLS(certFile, keyFile)
+	config, certFile, keyFile, err := r.tlsConfig(server.TLSConfig, certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	server.TLSConfig = config
+	server.Handler = r.handler()
+	r.addServer(server)
+	r.consoleStartPrint(server.Addr)
+	return r.serve(func() error {
+		return server.ListenAndServeTLS(certFile, keyFile)
+	})
 }
 
+// addServer track the server to be closed by Close
+func (r *Router) addServer(server *http.Server) {
+	r.serversLock.Lock()
+	defer r.serversLock.Unlock()
+	r.servers = append(r.servers, server)
+}
+
+// Close calls the OnPreShutdown hooks, waits for in-flight requests to complete, at most ShutdownTimeout (if set), then the pending audit events are delivered and the OnShutdown hooks are called
+// the websocket connections are sent the close frames (1001 going away), and closed after the WebsocketOptions.ShutdownGracePeriod
 func (r *Router) Close() error {
-	return r.server.Shutdown(context.Background())
+	ctx := context.Background()
+	if r.shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.shutdownTimeout)
+		defer cancel()
+	}
+	// deregister from the service discovery and fail the readiness first, so that the new requests are not routed to the instance
+	r.shutdownHooks(ctx, r.onPreShutdown)
+	if r.health != nil {
+		r.health.shutdown(ctx)
+	}
+	r.serversLock.Lock()
+	servers, fastServers, quicServers := r.servers, r.fastServers, r.quicServers
+	r.servers, r.fastServers, r.quicServers = nil, nil, nil
+	r.serversLock.Unlock()
+	// the HTTP/3 servers are closed immediately (the graceful shutdown is not supported by quic-go)
+	for _, server := range quicServers {
+		_ = server.Close()
+	}
+	// shut down all servers concurrently, so that they share the timeout
+	errs := make(chan error, len(servers)+len(fastServers))
+	for _, server := range servers {
+		server := server
+		go func() {
+			errs <- server.Shutdown(ctx)
+		}()
+	}
+	for _, server := range fastServers {
+		server := server
+		go func() {
+			errs <- server.ShutdownWithContext(ctx)
+		}()
+	}
+	// the hijacked websocket connections are not tracked by the servers
+	r.closeWSConns(ctx)
+	var first error
+	for i := 0; i < len(servers)+len(fastServers); i++ {
+		err := <-errs
+		if first == nil {
+			first = err
+		}
+	}
+	// the events of the completed requests are delivered
+	for _, a := range r.auditors {
+		a.close(ctx)
+	}
+	r.shutdownHooks(ctx, r.onShutdown)
+	return first
+}
+
+// serve runs the listen function, if ShutdownSignals is enabled, SIGINT/SIGTERM will close the router gracefully
+func (r *Router) serve(listen func() error) error {
+	r.startHooks()
+	if !r.shutdownSignals {
+		return listen()
+	}
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+	stop := make(chan struct{})
+	defer close(stop)
+	signaled := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		select {
+		case <-quit:
+			close(signaled)
+			r.logger.Info("received shutdown signal, server is closing")
+			done <- r.Close()
+		case <-stop:
+		}
+	}()
+	err := listen()
+	select {
+	case <-signaled:
+		// wait for the graceful shutdown to complete
+		return <-done
+	default:
+		return err
+	}
 }
 
 func (r *Router) consoleStartPrint(addr string) {
+	r.logger.Info("server started", slog.String("addr", addr))
 	if r.closeConsolePrint {
 		return
 	}
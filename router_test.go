@@ -4,7 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"golang.org/x/net/websocket"
+	"github.com/gorilla/websocket"
 	"io"
 	"net/http"
 	"testing"
@@ -31,6 +31,7 @@ func TestRouter(t *testing.T) {
 	router.HEAD("/head/:id", routerTestAPI)
 
 	router.WS("/ws", routerTestWebsocketConnect)
+	router.SSE("/sse", routerTestSSE)
 
 	router.GET("/error", routerTestErrorAPI)
 
@@ -43,6 +44,10 @@ func TestRouter(t *testing.T) {
 	router.EasyOPTIONS("/easy/options/:id", routerTestEasyQueryAPI)
 	router.EasyHEAD("/easy/head/:id", routerTestEasyQueryAPI)
 
+	// set route-level middlewares
+	router.GET("/admin/:id", routerTestAPI, routerTestAuthMiddleware)
+	router.EasyGET("/easy/admin/:id", routerTestEasyQueryAPI, routerTestAuthMiddleware)
+
 	router.EasyGET("/easy/error", routerTestErrorAPI)
 	router.EasyGET("/easy/error/return", routerTestErrorReturnAPI)
 
@@ -77,6 +82,18 @@ func routerTestMiddleware(ctx *Context) {
 	fmt.Println("[TestRouter](routerTestMiddleware) after ->", time.Now().UnixMilli())
 }
 
+// route-level middleware
+func routerTestAuthMiddleware(ctx *Context) {
+	if ctx.Header.Get("Token") != "test" {
+		fmt.Println("[TestRouter](routerTestAuthMiddleware) unauthorized ->", ctx.Route)
+		ctx.WriteString(http.StatusUnauthorized, "unauthorized")
+		ctx.Abort()
+		return
+	}
+	fmt.Println("[TestRouter](routerTestAuthMiddleware) authorized ->", ctx.Route)
+	ctx.Next()
+}
+
 func routerTestAPI(ctx *Context) {
 	if ctx.Request.Method == "GET" || ctx.Request.Method == "HEAD" || ctx.Request.Method == "OPTIONS" || ctx.Request.Method == "PUT" || ctx.Request.Method == "PATCH" || ctx.Request.Method == "DELETE" {
 		fmt.Println("[TestRouter](routerTestAPI) uri path id ->", ctx.Path.Int64("id"))
@@ -163,6 +180,15 @@ func routerTestErrorReturnAPI(ctx *Context) error {
 	return errors.New("test error return")
 }
 
+func routerTestSSE(ctx *Context) {
+	for i := 0; i < 3; i++ {
+		err := ctx.PushEvent("test", fmt.Sprintf("msg %v\nline 2", i))
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
 func routerTestWebsocketConnect(ctx *Context) {
 	msg, err := ctx.ReceiveString()
 	if err != nil {
@@ -177,22 +203,23 @@ func routerTestWebsocketConnect(ctx *Context) {
 }
 
 func routerTestWebsocketClientExecute() {
-	origin := "http://localhost/"
 	url := "ws://localhost/test/router/ws"
-	ws, err := websocket.Dial(url, "", origin)
+	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
 	if err != nil {
 		panic(err)
 	}
-	_, err = ws.Write([]byte("test msg"))
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(ws)
+	err = ws.WriteMessage(websocket.TextMessage, []byte("test msg"))
 	if err != nil {
 		panic(err)
 	}
-	var buf = make([]byte, 100)
-	n, err := ws.Read(buf)
+	_, msg, err := ws.ReadMessage()
 	if err != nil {
 		panic(err)
 	}
-	fmt.Println("[TestRouter](routerTestWebsocketClientExecute) client read websocket msg ->", string(buf[:n]))
+	fmt.Println("[TestRouter](routerTestWebsocketClientExecute) client read websocket msg ->", string(msg))
 }
 
 func routerTestHttpSendExecute() {
@@ -220,14 +247,21 @@ func routerTestHttpSendExecute() {
 	routerTestHttpClient("DELETE", "/delete/123", "")
 	routerTestHttpClient("DELETE", "/easy/delete/123", "")
 
+	routerTestHttpClient("GET", "/admin/123?int=1&int32=2&int64=3", "")
+	routerTestHttpClient("GET", "/easy/admin/123?int=1&int32=2&int64=3", "")
+	routerTestHttpClient("GET", "/admin/123?int=1&int32=2&int64=3", "", map[string]string{"Token": "test"})
+	routerTestHttpClient("GET", "/easy/admin/123?int=1&int32=2&int64=3", "", map[string]string{"Token": "test"})
+
+	routerTestHttpClient("GET", "/sse", "")
+
 	routerTestHttpClient("GET", "/error", "")
 	routerTestHttpClient("GET", "/easy/error", "")
 	routerTestHttpClient("GET", "/easy/error/return", "")
 }
 
-func routerTestHttpClient(method, uri, body string) {
+func routerTestHttpClient(method, uri, body string, header ...map[string]string) {
 	fmt.Printf("\n[TestRouter](routerTestHttpClient) request method: %s, uri: %s, body: %s \n", method, uri, body)
-	code, result, err := requestDo(method, "http://localhost/test/router"+uri, []byte(body))
+	code, result, err := requestDo(method, "http://localhost/test/router"+uri, []byte(body), header...)
 	if err != nil {
 		panic(err)
 	}
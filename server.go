@@ -0,0 +1,97 @@
+package easierweb
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ServerOptions settings of the http.Server created by Run and RunTLS
+type ServerOptions struct {
+	// maximum duration for reading the entire request (including the body), zero means no timeout
+	ReadTimeout time.Duration
+	// maximum duration for reading the request headers, default 10 seconds
+	ReadHeaderTimeout time.Duration
+	// maximum duration before timing out writes of the response, zero means no timeout (SSE and websocket are long-lived)
+	WriteTimeout time.Duration
+	// maximum time to wait for the next request when keep-alives are enabled, default 120 seconds
+	IdleTimeout time.Duration
+	// maximum size of the request headers, default http.DefaultMaxHeaderBytes (1MB)
+	MaxHeaderBytes int
+	// serve HTTP/2 without TLS (h2c), e.g. behind a reverse proxy that speaks HTTP/2 cleartext
+	H2C bool
+	// PEM file of the CA certificates that verify the client certificates (mTLS) of the TLS servers, the verified certificate can be read by ctx.ClientCert()
+	ClientCAFile string
+	// policy of the client certificates, default tls.RequireAndVerifyClientCert if ClientCAFile is set,
+	// tls.VerifyClientCertIfGiven makes them optional (e.g. some routes are public)
+	ClientAuth tls.ClientAuthType
+	// interval of checking the certificate and key files of RunTLS, RunMultiConfig and RunQUIC for changes, the changed certificate
+	// is used by the new connections without restarting (see CertReloader), zero means the certificate is loaded once
+	CertReloadInterval time.Duration
+}
+
+func defaultServerOptions(opt ServerOptions) ServerOptions {
+	if opt.ReadHeaderTimeout <= 0 {
+		opt.ReadHeaderTimeout = 10 * time.Second
+	}
+	if opt.IdleTimeout <= 0 {
+		opt.IdleTimeout = 120 * time.Second
+	}
+	return opt
+}
+
+// newServer create the http.Server with the ServerOptions
+func (r *Router) newServer(addr string) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		ReadTimeout:       r.serverOptions.ReadTimeout,
+		ReadHeaderTimeout: r.serverOptions.ReadHeaderTimeout,
+		WriteTimeout:      r.serverOptions.WriteTimeout,
+		IdleTimeout:       r.serverOptions.IdleTimeout,
+		MaxHeaderBytes:    r.serverOptions.MaxHeaderBytes,
+	}
+}
+
+// handler the router itself, or wrapped by h2c if it is enabled
+func (r *Router) handler() http.Handler {
+	if !r.serverOptions.H2C {
+		return r
+	}
+	return h2c.NewHandler(r, &http2.Server{
+		IdleTimeout: r.serverOptions.IdleTimeout,
+	})
+}
+
+// clientAuthTLSConfig apply the client certificate settings of the ServerOptions to the tls.Config (a copy is returned)
+func (r *Router) clientAuthTLSConfig(config *tls.Config) (*tls.Config, error) {
+	opt := r.serverOptions
+	if opt.ClientCAFile == "" && opt.ClientAuth == tls.NoClientCert {
+		return config, nil
+	}
+	if config == nil {
+		config = &tls.Config{}
+	} else {
+		config = config.Clone()
+	}
+	if opt.ClientCAFile != "" {
+		pem, err := os.ReadFile(opt.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificate found in " + opt.ClientCAFile)
+		}
+		config.ClientCAs = pool
+	}
+	config.ClientAuth = opt.ClientAuth
+	if config.ClientAuth == tls.NoClientCert {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
@@ -0,0 +1,394 @@
+package easierweb
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SessionStore load and save the session data by the session id, e.g. memory, redis, sql
+type SessionStore interface {
+	// Load returns nil (without error) if the session is not found
+	Load(id string) (*SessionData, error)
+	// Save the ttl is the idle timeout, the data can be removed after it
+	Save(id string, data *SessionData, ttl time.Duration) error
+	Delete(id string) error
+}
+
+type SessionData struct {
+	Values    map[string]any `json:"values"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
+
+type SessionOptions struct {
+	// name of the session cookie, default "session_id"
+	CookieName string
+	// default "/"
+	CookiePath     string
+	CookieDomain   string
+	CookieSameSite http.SameSite
+	// default in-memory store, NewCookieSessionStore() stores the data in the encrypted cookie
+	Store SessionStore
+	// the session expires if it is not accessed within the duration, default 30 minutes
+	IdleTimeout time.Duration
+	// the session expires after the duration since it was created, zero means no limit
+	AbsoluteTimeout time.Duration
+}
+
+// SessionManager load the session of each request, it is read by ctx.Session()
+type SessionManager struct {
+	opt SessionOptions
+}
+
+// Session the session of the request, Save must be called before the response is written
+type Session struct {
+	manager   *SessionManager
+	ctx       *Context
+	id        string
+	oldID     string
+	data      *SessionData
+	changed   bool
+	destroyed bool
+}
+
+func NewSessionManager(opts ...SessionOptions) *SessionManager {
+	opt := SessionOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.CookieName == "" {
+		opt.CookieName = "session_id"
+	}
+	if opt.CookiePath == "" {
+		opt.CookiePath = "/"
+	}
+	if opt.Store == nil {
+		opt.Store = NewMemorySessionStore()
+	}
+	if opt.IdleTimeout <= 0 {
+		opt.IdleTimeout = 30 * time.Minute
+	}
+	return &SessionManager{opt: opt}
+}
+
+// Middleware load the session before the handles, and refresh the idle timeout of the server-side store after them
+// the cookie of the CookieSessionStore is refreshed before the handles (the response may be written by them)
+func (m *SessionManager) Middleware() Handle {
+	return func(ctx *Context) {
+		s, err := m.load(ctx)
+		if err != nil {
+			panic(err)
+		}
+		ctx.session = s
+		_, cookieStore := m.opt.Store.(*CookieSessionStore)
+		if cookieStore && s.data != nil && time.Since(s.data.UpdatedAt) > m.opt.IdleTimeout/4 {
+			err = s.persist(true)
+			if err != nil {
+				ctx.Logger.Error("save session error: " + err.Error())
+			}
+		}
+		ctx.Next()
+		// the changes of the cookie session are only written by Save
+		if cookieStore {
+			if s.changed {
+				ctx.Logger.Warn("the cookie session is not saved, call Save before writing the response", slog.String("route", ctx.Route))
+			}
+			return
+		}
+		// the changes that are not saved explicitly are kept in the server-side store (the cookie may not be written)
+		if s.destroyed || s.data == nil {
+			return
+		}
+		// the new (or regenerated) session is not held by the client, it would be orphaned in the store without the cookie
+		if s.id == "" {
+			if s.changed {
+				ctx.Logger.Warn("the new session is not saved, call Save before writing the response", slog.String("route", ctx.Route))
+			}
+			return
+		}
+		if s.changed || time.Since(s.data.UpdatedAt) > m.opt.IdleTimeout/4 {
+			err = s.persist(false)
+			if err != nil {
+				ctx.Logger.Error("save session error: " + err.Error())
+			}
+		}
+	}
+}
+
+func (m *SessionManager) load(ctx *Context) (*Session, error) {
+	s := &Session{manager: m, ctx: ctx}
+	var data *SessionData
+	if _, ok := m.opt.Store.(*CookieSessionStore); ok {
+		raw, err := ctx.EncryptedCookie(m.opt.CookieName)
+		if err == nil {
+			data = &SessionData{}
+			if json.Unmarshal([]byte(raw), data) != nil {
+				data = nil
+			}
+		}
+	} else if id, err := ctx.Cookie(m.opt.CookieName); err == nil && id != "" {
+		data, err = m.opt.Store.Load(id)
+		if err != nil {
+			return nil, err
+		}
+		s.id = id
+	}
+	if data != nil && !m.expired(data) {
+		if data.Values == nil {
+			data.Values = make(map[string]any)
+		}
+		s.data = data
+		return s, nil
+	}
+	// the expired session is removed, a new session is created when it is written
+	if data != nil && s.id != "" {
+		err := m.opt.Store.Delete(s.id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.id = ""
+	return s, nil
+}
+
+func (m *SessionManager) expired(data *SessionData) bool {
+	now := time.Now()
+	if now.Sub(data.UpdatedAt) > m.opt.IdleTimeout {
+		return true
+	}
+	return m.opt.AbsoluteTimeout > 0 && now.Sub(data.CreatedAt) > m.opt.AbsoluteTimeout
+}
+
+// Session get the session loaded by the SessionManager middleware, returns nil if the middleware is not used
+func (c *Context) Session() *Session {
+	return c.session
+}
+
+// ID get the session id, it is empty for a new session until it is saved
+func (s *Session) ID() string {
+	return s.id
+}
+
+func (s *Session) Get(key string) any {
+	if s.data == nil {
+		return nil
+	}
+	return s.data.Values[key]
+}
+
+func (s *Session) Set(key string, value any) {
+	s.init()
+	s.data.Values[key] = value
+	s.changed = true
+}
+
+func (s *Session) Delete(key string) {
+	if s.data == nil {
+		return
+	}
+	delete(s.data.Values, key)
+	s.changed = true
+}
+
+// Clear remove all values
+func (s *Session) Clear() {
+	if s.data == nil {
+		return
+	}
+	clear(s.data.Values)
+	s.changed = true
+}
+
+// Regenerate change the session id and keep the values, it should be called on login to prevent session fixation
+func (s *Session) Regenerate() {
+	s.init()
+	if s.oldID == "" {
+		s.oldID = s.id
+	}
+	s.id = ""
+	s.changed = true
+}
+
+// Destroy remove the session and expire the cookie when it is saved, e.g. logout
+func (s *Session) Destroy() {
+	s.destroyed = true
+	s.changed = true
+}
+
+// Save save the session and write the cookie, it must be called before the response is written
+func (s *Session) Save() error {
+	return s.persist(true)
+}
+
+func (s *Session) init() {
+	if s.data == nil {
+		now := time.Now()
+		s.data = &SessionData{Values: make(map[string]any), CreatedAt: now, UpdatedAt: now}
+	}
+}
+
+func (s *Session) persist(writeCookie bool) error {
+	opt := s.manager.opt
+	_, cookieStore := opt.Store.(*CookieSessionStore)
+	if s.destroyed {
+		for _, id := range []string{s.id, s.oldID} {
+			if id != "" && !cookieStore {
+				err := opt.Store.Delete(id)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		s.id, s.oldID, s.data = "", "", nil
+		if writeCookie {
+			s.ctx.SetCookie(&http.Cookie{Name: opt.CookieName, Path: opt.CookiePath, Domain: opt.CookieDomain, MaxAge: -1, Expires: time.Unix(0, 0), HttpOnly: true, SameSite: opt.CookieSameSite})
+		}
+		s.changed = false
+		return nil
+	}
+	if s.data == nil {
+		return nil
+	}
+	s.data.UpdatedAt = time.Now()
+	cookie := &http.Cookie{Name: opt.CookieName, Path: opt.CookiePath, Domain: opt.CookieDomain, HttpOnly: true, SameSite: opt.CookieSameSite}
+
+	if cookieStore {
+		marshal, err := json.Marshal(s.data)
+		if err != nil {
+			return err
+		}
+		cookie.Value = string(marshal)
+		s.changed = false
+		if !writeCookie {
+			return nil
+		}
+		return s.ctx.SetEncryptedCookie(cookie)
+	}
+
+	isNew := s.id == ""
+	if isNew {
+		id, err := newSessionID()
+		if err != nil {
+			return err
+		}
+		s.id = id
+	}
+	if s.oldID != "" {
+		err := opt.Store.Delete(s.oldID)
+		if err != nil {
+			return err
+		}
+		s.oldID = ""
+	}
+	err := opt.Store.Save(s.id, s.data, opt.IdleTimeout)
+	if err != nil {
+		return err
+	}
+	s.changed = false
+	if writeCookie || isNew {
+		cookie.Value = s.id
+		s.ctx.SetCookie(cookie)
+	}
+	return nil
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CookieSessionStore store the session data in the encrypted cookie (RouterOptions.CookieSecrets is required)
+// the values are encoded as json, and the size of the cookie is limited (about 4KB)
+type CookieSessionStore struct{}
+
+func NewCookieSessionStore() *CookieSessionStore {
+	return &CookieSessionStore{}
+}
+
+// the data is read and written by the session manager directly
+
+func (c *CookieSessionStore) Load(id string) (*SessionData, error) {
+	return nil, errors.New("cookie session store cannot be loaded by id")
+}
+
+func (c *CookieSessionStore) Save(id string, data *SessionData, ttl time.Duration) error {
+	return errors.New("cookie session store cannot be saved by id")
+}
+
+func (c *CookieSessionStore) Delete(id string) error {
+	return nil
+}
+
+// MemorySessionStore the in-memory session store, it is not shared between instances
+type MemorySessionStore struct {
+	mu        sync.Mutex
+	sessions  map[string]memorySession
+	lastSweep time.Time
+}
+
+type memorySession struct {
+	data     SessionData
+	expireAt time.Time
+}
+
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions:  make(map[string]memorySession),
+		lastSweep: time.Now(),
+	}
+}
+
+func (m *MemorySessionStore) Load(id string) (*SessionData, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok || time.Now().After(s.expireAt) {
+		return nil, nil
+	}
+	// copy the values, so that the changes are only visible after saving
+	data := s.data
+	data.Values = make(map[string]any, len(s.data.Values))
+	for k, v := range s.data.Values {
+		data.Values[k] = v
+	}
+	return &data, nil
+}
+
+func (m *MemorySessionStore) Save(id string, data *SessionData, ttl time.Duration) error {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if now.Sub(m.lastSweep) > time.Minute {
+		m.lastSweep = now
+		for k, s := range m.sessions {
+			if now.After(s.expireAt) {
+				delete(m.sessions, k)
+			}
+		}
+	}
+	values := make(map[string]any, len(data.Values))
+	for k, v := range data.Values {
+		values[k] = v
+	}
+	saved := *data
+	saved.Values = values
+	m.sessions[id] = memorySession{data: saved, expireAt: now.Add(ttl)}
+	return nil
+}
+
+func (m *MemorySessionStore) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+	return nil
+}
@@ -0,0 +1,158 @@
+package easierweb
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// session persist test
+
+func TestSessionPersist(t *testing.T) {
+
+	fmt.Println("\n[TestSessionPersist] start")
+
+	store := NewMemorySessionStore()
+	sessions := NewSessionManager(SessionOptions{Store: store})
+	router := New(RouterOptions{CloseConsolePrint: true}).Use(sessions.Middleware())
+	router.GET("/set", func(ctx *Context) {
+		ctx.Session().Set("visits", 1)
+		ctx.WriteString(http.StatusOK, "ok")
+	})
+	router.GET("/save", func(ctx *Context) {
+		ctx.Session().Set("uid", 1)
+		err := ctx.Session().Save()
+		if err != nil {
+			panic(err)
+		}
+		ctx.WriteString(http.StatusOK, "ok")
+	})
+	router.GET("/get", func(ctx *Context) {
+		ctx.WriteString(http.StatusOK, fmt.Sprint(ctx.Session().Get("uid"), ctx.Session().Get("visits")))
+	})
+	count := func() int {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		return len(store.sessions)
+	}
+	do := func(path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// the new session without Save is not stored, the client does not get its id
+	for i := 0; i < 3; i++ {
+		rec := do("/set", nil)
+		if len(rec.Result().Cookies()) != 0 {
+			t.Fatal("the cookie of the unsaved session is written")
+		}
+	}
+	fmt.Println("[TestSessionPersist] unsaved sessions ->", count())
+	if count() != 0 {
+		t.Fatal("the unsaved new sessions are orphaned in the store")
+	}
+
+	// the saved session is stored and the cookie is written
+	rec := do("/save", nil)
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || count() != 1 {
+		t.Fatal("the saved session is not stored")
+	}
+
+	// the changes of the existing session are kept without Save
+	do("/set", cookies[0])
+	rec = do("/get", cookies[0])
+	fmt.Println("[TestSessionPersist] values ->", rec.Body.String(), count())
+	if rec.Body.String() != "1 1" || count() != 1 {
+		t.Fatal("the changes of the existing session are not kept")
+	}
+
+	fmt.Println("\n[TestSessionPersist] end")
+}
+
+// cookie session test
+
+func TestCookieSession(t *testing.T) {
+
+	fmt.Println("\n[TestCookieSession] start")
+
+	logs := &bytes.Buffer{}
+	sessions := NewSessionManager(SessionOptions{Store: NewCookieSessionStore(), IdleTimeout: 400 * time.Millisecond})
+	router := New(RouterOptions{
+		CloseConsolePrint: true,
+		CookieSecrets:     [][]byte{[]byte("0123456789abcdef0123456789abcdef")},
+		Logger:            slog.New(slog.NewTextHandler(logs, nil)),
+	}).Use(sessions.Middleware())
+	router.GET("/login", func(ctx *Context) {
+		ctx.Session().Set("uid", 1)
+		err := ctx.Session().Save()
+		if err != nil {
+			panic(err)
+		}
+		ctx.WriteString(http.StatusOK, "ok")
+	})
+	router.GET("/set", func(ctx *Context) {
+		ctx.Session().Set("visits", 1)
+		ctx.WriteString(http.StatusOK, "ok")
+	})
+	router.GET("/get", func(ctx *Context) {
+		ctx.WriteString(http.StatusOK, fmt.Sprint(ctx.Session().Get("uid"), ctx.Session().Get("visits")))
+	})
+	do := func(path string, cookie *http.Cookie) (string, *http.Cookie) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		cookies := rec.Result().Cookies()
+		if len(cookies) == 0 {
+			return rec.Body.String(), nil
+		}
+		return rec.Body.String(), cookies[len(cookies)-1]
+	}
+
+	_, login := do("/login", nil)
+	if login == nil {
+		t.Fatal("the cookie session is not written")
+	}
+
+	// the fresh session is not rewritten
+	if _, cookie := do("/get", login); cookie != nil {
+		t.Fatal("the fresh cookie session is rewritten")
+	}
+
+	// the active session is refreshed before the idle timeout
+	time.Sleep(250 * time.Millisecond)
+	body, refreshed := do("/get", login)
+	fmt.Println("[TestCookieSession] refreshed ->", body, refreshed != nil)
+	if body != "1 <nil>" || refreshed == nil {
+		t.Fatal("the active cookie session is not refreshed")
+	}
+	time.Sleep(250 * time.Millisecond)
+	if body, _ = do("/get", refreshed); body != "1 <nil>" {
+		t.Fatal("the refreshed cookie session is expired: " + body)
+	}
+	if body, _ = do("/get", login); body != "<nil> <nil>" {
+		t.Fatal("the idle cookie session is not expired: " + body)
+	}
+
+	// the unsaved changes are reported
+	do("/set", refreshed)
+	fmt.Println("[TestCookieSession] unsaved ->", strings.TrimSpace(logs.String()))
+	if !strings.Contains(logs.String(), "the cookie session is not saved") {
+		t.Fatal("the unsaved cookie session is not reported")
+	}
+
+	fmt.Println("\n[TestCookieSession] end")
+}
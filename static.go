@@ -0,0 +1,327 @@
+package easierweb
+
+import (
+	"bytes"
+	"github.com/julienschmidt/httprouter"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheControlImmutable the Cache-Control value of the fingerprinted assets, e.g. app.3f2a1c.js
+const CacheControlImmutable = "public, max-age=31536000, immutable"
+
+type StaticOptions struct {
+	// the sub directory of the file system, e.g. "dist" of the embedded files
+	Root string
+	// serve the index file for the unknown paths (single-page apps), the file is served with Cache-Control no-cache
+	SPA bool
+	// the index file of the SPA fallback, default "index.html"
+	IndexFile string
+	// the Cache-Control headers of the files, the first matched pattern is used
+	CacheControl []StaticCacheControl
+	// list the files of the directories without the index.html, they are not found by default
+	EnableListing bool
+	// the files and directories starting with "." are not found, and hidden from the listings, e.g. .git, .env
+	DenyDotfiles bool
+	// the symlinks that point outside the directory are not found, it only works with Static
+	DenySymlinkEscape bool
+	// serve the pre-compressed .br and .gz siblings of the files if the client accepts them, e.g. app.js.br
+	Precompressed bool
+}
+
+// StaticCacheControl the pattern (path.Match) is matched against the file path (without the leading slash) and the file name
+// e.g. {Pattern: "assets/*", Value: easierweb.CacheControlImmutable}, {Pattern: "*.html", Value: "no-cache"}
+type StaticCacheControl struct {
+	Pattern string
+	Value   string
+}
+
+// StaticFiles serve the files of the fs.FS, e.g. the go:embed files
+// router.StaticFiles("/*filepath", webFS, easierweb.StaticOptions{Root: "dist", SPA: true})
+func (r *Router) StaticFiles(path string, fsys fs.FS, opts ...StaticOptions) *Router {
+	opt := StaticOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Root != "" && opt.Root != "." {
+		sub, err := fs.Sub(fsys, opt.Root)
+		if err != nil {
+			panic(err)
+		}
+		fsys = sub
+		opt.Root = ""
+	}
+	return r.StaticFS(path, http.FS(fsys), opt)
+}
+
+// StaticFile serve a single file, e.g. router.StaticFile("/favicon.ico", "./assets/favicon.ico")
+func (r *Router) StaticFile(path, filePath string) *Router {
+	route := r.rootPath + path
+	r.lastRoutes = nil
+	r.addRoute(MethodGET, route, routeKindStatic, nil, nil, nil)
+	r.router.GET(route, func(res http.ResponseWriter, req *http.Request, par httprouter.Params) {
+		f, err := os.Open(filePath)
+		if err != nil {
+			r.notFound(res, req)
+			return
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		stat, err := f.Stat()
+		if err != nil || stat.IsDir() {
+			r.notFound(res, req)
+			return
+		}
+		http.ServeContent(res, req, stat.Name(), stat.ModTime(), f)
+	})
+	return r
+}
+
+// StaticData serve the bytes, e.g. router.StaticData("/robots.txt", []byte("User-agent: *"), "text/plain; charset=utf-8")
+// the content type is detected if it is empty
+func (r *Router) StaticData(path string, data []byte, contentType string) *Router {
+	route := r.rootPath + path
+	r.lastRoutes = nil
+	r.addRoute(MethodGET, route, routeKindStatic, nil, nil, nil)
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	modTime := time.Now()
+	r.router.GET(route, func(res http.ResponseWriter, req *http.Request, par httprouter.Params) {
+		res.Header().Set("Content-Type", contentType)
+		http.ServeContent(res, req, "", modTime, bytes.NewReader(data))
+	})
+	return r
+}
+
+// serveStatic like httprouter ServeFiles, with the SPA fallback, the Cache-Control headers and the access control
+// the missing files are handled by the not found handle of the router
+func (r *Router) serveStatic(route string, fsys http.FileSystem, opt StaticOptions) {
+	if len(route) < 10 || route[len(route)-10:] != "/*filepath" {
+		panic("path must end with /*filepath in path '" + route + "'")
+	}
+	if opt.Root != "" && opt.Root != "." {
+		fsys = subFileSystem{root: opt.Root, fs: fsys}
+	}
+	if opt.IndexFile == "" {
+		opt.IndexFile = "index.html"
+	}
+	if opt.DenyDotfiles {
+		fsys = dotfileHidingFileSystem{fs: fsys}
+	}
+	fileServer := http.FileServer(fsys)
+	r.router.GET(route, func(res http.ResponseWriter, req *http.Request, par httprouter.Params) {
+		name := par.ByName("filepath")
+		if opt.DenyDotfiles && hasDotSegment(name) {
+			r.notFound(res, req)
+			return
+		}
+		exists, isDir := staticStat(fsys, name)
+		if opt.SPA && (name == "/" || !exists) {
+			r.serveStaticIndex(res, req, fsys, "/"+strings.TrimPrefix(opt.IndexFile, "/"))
+			return
+		}
+		if !exists || (isDir && !opt.EnableListing && !staticFileExists(fsys, path.Join(name, "index.html"))) {
+			r.notFound(res, req)
+			return
+		}
+		if value := staticCacheControl(opt.CacheControl, name); value != "" {
+			res.Header().Set("Cache-Control", value)
+		}
+		if opt.Precompressed && !isDir && servePrecompressed(res, req, fsys, name) {
+			return
+		}
+		req.URL.Path = name
+		fileServer.ServeHTTP(res, req)
+	})
+}
+
+func staticFileExists(fsys http.FileSystem, name string) bool {
+	exists, isDir := staticStat(fsys, name)
+	return exists && !isDir
+}
+
+func staticStat(fsys http.FileSystem, name string) (exists bool, isDir bool) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return false, false
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	stat, err := f.Stat()
+	if err != nil {
+		return false, false
+	}
+	return true, stat.IsDir()
+}
+
+func (r *Router) serveStaticIndex(res http.ResponseWriter, req *http.Request, fsys http.FileSystem, name string) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		r.notFound(res, req)
+		return
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	stat, err := f.Stat()
+	if err != nil || stat.IsDir() {
+		r.notFound(res, req)
+		return
+	}
+	res.Header().Set("Cache-Control", "no-cache")
+	http.ServeContent(res, req, stat.Name(), stat.ModTime(), f)
+}
+
+var precompressedEncodings = []struct {
+	encoding  string
+	extension string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// servePrecompressed serve the compressed sibling of the file, returns false if there is no acceptable one
+func servePrecompressed(res http.ResponseWriter, req *http.Request, fsys http.FileSystem, name string) bool {
+	res.Header().Add("Vary", "Accept-Encoding")
+	acceptEncoding := req.Header.Get("Accept-Encoding")
+	for _, e := range precompressedEncodings {
+		if !acceptsEncoding(acceptEncoding, e.encoding) {
+			continue
+		}
+		f, err := fsys.Open(name + e.extension)
+		if err != nil {
+			continue
+		}
+		stat, err := f.Stat()
+		if err != nil || stat.IsDir() {
+			_ = f.Close()
+			continue
+		}
+		// the content type is detected by the extension of the original file
+		if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+			res.Header().Set("Content-Type", contentType)
+		} else {
+			res.Header().Set("Content-Type", "application/octet-stream")
+		}
+		res.Header().Set("Content-Encoding", e.encoding)
+		http.ServeContent(res, req, name, stat.ModTime(), f)
+		_ = f.Close()
+		return true
+	}
+	return false
+}
+
+// acceptsEncoding whether the Accept-Encoding header accepts the encoding with a non-zero q-value
+func acceptsEncoding(acceptEncoding, encoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(q, 64)
+			return err == nil && f > 0
+		}
+		return true
+	}
+	return false
+}
+
+func staticCacheControl(rules []StaticCacheControl, name string) string {
+	name = strings.TrimPrefix(name, "/")
+	base := path.Base(name)
+	for _, rule := range rules {
+		if ok, _ := path.Match(rule.Pattern, name); ok {
+			return rule.Value
+		}
+		if ok, _ := path.Match(rule.Pattern, base); ok {
+			return rule.Value
+		}
+	}
+	return ""
+}
+
+// subFileSystem the sub directory of the http.FileSystem
+type subFileSystem struct {
+	root string
+	fs   http.FileSystem
+}
+
+func (s subFileSystem) Open(name string) (http.File, error) {
+	// the cleaned path cannot escape the root
+	return s.fs.Open(path.Join("/", s.root, path.Clean("/"+name)))
+}
+
+func hasDotSegment(name string) bool {
+	for _, segment := range strings.Split(name, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// dotfileHidingFileSystem the dotfiles cannot be opened or listed
+type dotfileHidingFileSystem struct {
+	fs http.FileSystem
+}
+
+func (d dotfileHidingFileSystem) Open(name string) (http.File, error) {
+	if hasDotSegment(name) {
+		return nil, fs.ErrNotExist
+	}
+	f, err := d.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return dotfileHidingFile{File: f}, nil
+}
+
+type dotfileHidingFile struct {
+	http.File
+}
+
+func (d dotfileHidingFile) Readdir(n int) ([]fs.FileInfo, error) {
+	files, err := d.File.Readdir(n)
+	visible := files[:0]
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), ".") {
+			visible = append(visible, file)
+		}
+	}
+	return visible, err
+}
+
+// symlinkSafeDir the http.Dir that does not follow the symlinks outside the root directory
+type symlinkSafeDir struct {
+	root string
+}
+
+func (s symlinkSafeDir) Open(name string) (http.File, error) {
+	root, err := filepath.EvalSymlinks(s.root)
+	if err != nil {
+		return nil, err
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	real, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(path.Clean("/"+name))))
+	if err != nil {
+		return nil, err
+	}
+	if real != root && !strings.HasPrefix(real, root+string(filepath.Separator)) {
+		return nil, fs.ErrNotExist
+	}
+	return http.Dir(s.root).Open(name)
+}
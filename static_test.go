@@ -0,0 +1,54 @@
+package easierweb
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// static files test
+
+func TestStaticListing(t *testing.T) {
+
+	fmt.Println("\n[TestStaticListing] start")
+
+	dir := t.TempDir()
+	for _, name := range []string{"files/a.txt", "site/index.html"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	router := New(RouterOptions{CloseConsolePrint: true})
+	router.Static("/default/*filepath", dir)
+	router.Static("/listing/*filepath", dir, StaticOptions{EnableListing: true})
+
+	cases := []struct {
+		name string
+		path string
+		code int
+		body string
+	}{
+		{"file", "/default/files/a.txt", http.StatusOK, "files/a.txt"},
+		// the directories are not listed by default
+		{"directory", "/default/files/", http.StatusNotFound, ""},
+		{"directory index", "/default/site/", http.StatusOK, "site/index.html"},
+		{"enabled listing", "/listing/files/", http.StatusOK, "a.txt"},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.path, nil))
+		fmt.Println("[TestStaticListing] result ->", c.name, rec.Code)
+		if rec.Code != c.code || !strings.Contains(rec.Body.String(), c.body) {
+			t.Fatal(c.name + ": static response does not match")
+		}
+	}
+
+	fmt.Println("\n[TestStaticListing] end")
+}
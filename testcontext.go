@@ -0,0 +1,40 @@
+package easierweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"net/http/httptest"
+)
+
+// NewTestContext create an initialized context and the response recorder, to unit test the handles and middlewares without the router
+// the params are the path parameter pairs, and the body is sent with the application/json Content-Type if it is valid json
+// e.g. ctx, rec := easierweb.NewTestContext("GET", "/users/1", nil, "id", "1"); getUser(ctx); rec.Code
+func NewTestContext(method, path string, body []byte, params ...string) (*Context, *httptest.ResponseRecorder) {
+	return New(RouterOptions{CloseConsolePrint: true}).NewTestContext(method, path, body, params...)
+}
+
+// NewTestContext create the test context with the settings of the router, e.g. the json codec, renderer and decoders
+func (r *Router) NewTestContext(method, path string, body []byte, params ...string) (*Context, *httptest.ResponseRecorder) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if len(body) > 0 && json.Valid(body) {
+		req.Header.Set("Content-Type", MediaTypeJSON)
+	}
+	par := make(httprouter.Params, 0, len(params)/2)
+	for i := 0; i+1 < len(params); i += 2 {
+		par = append(par, httprouter.Param{Key: params[i], Value: params[i+1]})
+	}
+	rec := httptest.NewRecorder()
+	ctx := new(Context)
+	err := setContext(ctx, r, nil, rec, req, par)
+	if err != nil {
+		panic(err)
+	}
+	ctx.Route = req.URL.Path
+	return ctx, rec
+}
@@ -0,0 +1,134 @@
+package easierweb
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type UploadOptions struct {
+	// maximum number of files in the form, zero means no limit
+	MaxFiles int
+	// maximum size in bytes of each file, zero means no limit
+	MaxFileSize int64
+	// allowed mime types sniffed from the content (not the extension), e.g. "image/png", "image/*"
+	AllowedTypes []string
+}
+
+// FormFile get the first uploaded file of the form key
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	files, err := c.FormFiles(name)
+	if err != nil {
+		return nil, err
+	}
+	return files[0], nil
+}
+
+// FormFiles get all uploaded files of the form key
+func (c *Context) FormFiles(name string) ([]*multipart.FileHeader, error) {
+	if c.Request.MultipartForm == nil {
+		return nil, http.ErrNotMultipart
+	}
+	files := c.Request.MultipartForm.File[name]
+	if len(files) == 0 {
+		return nil, http.ErrMissingFile
+	}
+	return files, nil
+}
+
+// SaveUploadedFile save the uploaded file to the dst path, the parent directories are created
+func (c *Context) SaveUploadedFile(fh *multipart.FileHeader, dst string) error {
+	src, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	err = os.MkdirAll(filepath.Dir(dst), 0750)
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, src)
+	return err
+}
+
+// ValidateUploads validate all uploaded files of the form
+// returns *Error with 413 (too many files or file too large) or 415 (mime type not allowed)
+func (c *Context) ValidateUploads(opt UploadOptions) error {
+	if c.Request.MultipartForm == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(c.Request.MultipartForm.File))
+	count := 0
+	for k, files := range c.Request.MultipartForm.File {
+		keys = append(keys, k)
+		count += len(files)
+	}
+	sort.Strings(keys)
+	if opt.MaxFiles > 0 && count > opt.MaxFiles {
+		return NewError(http.StatusRequestEntityTooLarge, fmt.Sprintf("too many files, at most %d", opt.MaxFiles)).
+			WithCode("TOO_MANY_FILES").
+			WithDetails(map[string]any{"count": count, "limit": opt.MaxFiles})
+	}
+	for _, k := range keys {
+		for _, fh := range c.Request.MultipartForm.File[k] {
+			if opt.MaxFileSize > 0 && fh.Size > opt.MaxFileSize {
+				return NewError(http.StatusRequestEntityTooLarge, fmt.Sprintf("file %s is too large, at most %d bytes", fh.Filename, opt.MaxFileSize)).
+					WithCode("FILE_TOO_LARGE").
+					WithDetails(map[string]any{"field": k, "file": fh.Filename, "size": fh.Size, "limit": opt.MaxFileSize})
+			}
+			if len(opt.AllowedTypes) == 0 {
+				continue
+			}
+			fileType, err := DetectFileType(fh)
+			if err != nil {
+				return err
+			}
+			if !mimeTypeAllowed(opt.AllowedTypes, fileType) {
+				return NewError(http.StatusUnsupportedMediaType, fmt.Sprintf("file type %s of %s is not allowed", fileType, fh.Filename)).
+					WithCode("FILE_TYPE_NOT_ALLOWED").
+					WithDetails(map[string]any{"field": k, "file": fh.Filename, "type": fileType, "allowed": opt.AllowedTypes})
+			}
+		}
+	}
+	return nil
+}
+
+// DetectFileType sniff the mime type from the first 512 bytes of the file content, e.g. "image/png"
+func DetectFileType(fh *multipart.FileHeader) (string, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	fileType, _, _ := strings.Cut(http.DetectContentType(head[:n]), ";")
+	return fileType, nil
+}
+
+func mimeTypeAllowed(allowed []string, fileType string) bool {
+	for _, v := range allowed {
+		if strings.EqualFold(v, fileType) || v == "*/*" {
+			return true
+		}
+		// wildcard subtype, e.g. image/*
+		if prefix, ok := strings.CutSuffix(v, "/*"); ok && strings.HasPrefix(fileType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,256 @@
+package easierweb
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	ProxyRoundRobin = "round_robin"
+	ProxyLeastConn  = "least_conn"
+)
+
+// upstreamBalancer the round tripper that selects the upstream, tracks the failures and retries on connection failures
+type upstreamBalancer struct {
+	upstreams   []*upstream
+	transport   http.RoundTripper
+	strategy    string
+	maxFails    int
+	failTimeout time.Duration
+	retries     int
+	next        atomic.Uint64
+}
+
+type upstream struct {
+	url       *url.URL
+	active    atomic.Int64
+	mu        sync.Mutex
+	fails     int
+	downUntil time.Time
+}
+
+func newUpstreamBalancer(targets []string, opt ProxyOptions) (*upstreamBalancer, error) {
+	b := &upstreamBalancer{
+		transport:   opt.Transport,
+		strategy:    opt.Strategy,
+		maxFails:    opt.MaxFails,
+		failTimeout: opt.FailTimeout,
+		retries:     opt.Retries,
+	}
+	for _, target := range targets {
+		u, err := url.Parse(target)
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, errors.New("invalid proxy target: " + target)
+		}
+		b.upstreams = append(b.upstreams, &upstream{url: u})
+	}
+	if b.transport == nil {
+		b.transport = http.DefaultTransport
+	}
+	if b.strategy == "" {
+		b.strategy = ProxyRoundRobin
+	}
+	if b.maxFails <= 0 {
+		b.maxFails = 3
+	}
+	if b.failTimeout <= 0 {
+		b.failTimeout = 10 * time.Second
+	}
+	if b.retries == 0 {
+		b.retries = len(b.upstreams) - 1
+	}
+	return b, nil
+}
+
+func (b *upstreamBalancer) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the body is not closed by the failed attempts, so that it can be sent again if it has not been read
+	var body *retryBody
+	if req.Body != nil && req.Body != http.NoBody {
+		body = &retryBody{ReadCloser: req.Body}
+	}
+	tried := make(map[*upstream]bool, len(b.upstreams))
+	for attempt := 0; ; attempt++ {
+		u := b.pick(tried)
+		tried[u] = true
+		out := req.Clone(req.Context())
+		if body != nil {
+			out.Body = body
+		}
+		out.URL.Scheme = u.url.Scheme
+		out.URL.Host = u.url.Host
+		out.URL.Path, out.URL.RawPath = joinUpstreamPath(u.url, req.URL)
+		if u.url.RawQuery != "" {
+			if out.URL.RawQuery == "" {
+				out.URL.RawQuery = u.url.RawQuery
+			} else {
+				out.URL.RawQuery = u.url.RawQuery + "&" + out.URL.RawQuery
+			}
+		}
+
+		u.active.Add(1)
+		res, err := b.transport.RoundTrip(out)
+		if err != nil {
+			u.active.Add(-1)
+			b.fail(u)
+			if isDialError(err) && (body == nil || !body.read.Load()) && attempt < b.retries && len(tried) < len(b.upstreams) {
+				continue
+			}
+			if body != nil {
+				_ = body.ReadCloser.Close()
+			}
+			return nil, err
+		}
+		// the gateway errors of the upstream (e.g. it is overloaded) are counted as failures
+		if res.StatusCode == http.StatusBadGateway || res.StatusCode == http.StatusServiceUnavailable || res.StatusCode == http.StatusGatewayTimeout {
+			b.fail(u)
+		} else {
+			b.succeed(u)
+		}
+		done := func() {
+			u.active.Add(-1)
+		}
+		// the body of the switching protocols response (websocket) must stay writable
+		if rwc, ok := res.Body.(io.ReadWriteCloser); ok {
+			res.Body = &upstreamRWBody{ReadWriteCloser: rwc, done: done}
+		} else {
+			res.Body = &upstreamBody{ReadCloser: res.Body, done: done}
+		}
+		return res, nil
+	}
+}
+
+// pick select the upstream that is not tried, the upstreams that are down are skipped unless all of them are down
+func (b *upstreamBalancer) pick(tried map[*upstream]bool) *upstream {
+	now := time.Now()
+	candidates := make([]*upstream, 0, len(b.upstreams))
+	for _, u := range b.upstreams {
+		if !tried[u] && !u.down(now) {
+			candidates = append(candidates, u)
+		}
+	}
+	if len(candidates) == 0 {
+		for _, u := range b.upstreams {
+			if !tried[u] {
+				candidates = append(candidates, u)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = b.upstreams
+	}
+	if b.strategy == ProxyLeastConn {
+		best := candidates[0]
+		for _, u := range candidates[1:] {
+			if u.active.Load() < best.active.Load() {
+				best = u
+			}
+		}
+		return best
+	}
+	// round robin over all upstreams, so that the order is kept when some of them are skipped
+	n := uint64(len(b.upstreams))
+	start := b.next.Add(1) - 1
+	for i := uint64(0); i < n; i++ {
+		u := b.upstreams[(start+i)%n]
+		for _, c := range candidates {
+			if c == u {
+				return u
+			}
+		}
+	}
+	return candidates[0]
+}
+
+func (b *upstreamBalancer) fail(u *upstream) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.fails++
+	if u.fails >= b.maxFails {
+		u.downUntil = time.Now().Add(b.failTimeout)
+		u.fails = 0
+	}
+}
+
+func (b *upstreamBalancer) succeed(u *upstream) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.fails = 0
+}
+
+func (u *upstream) down(now time.Time) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return now.Before(u.downUntil)
+}
+
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// joinUpstreamPath join the path of the target and the request, like httputil.NewSingleHostReverseProxy
+func joinUpstreamPath(target, req *url.URL) (path, rawPath string) {
+	if target.RawPath == "" && req.RawPath == "" {
+		return singleJoiningSlash(target.Path, req.Path), ""
+	}
+	return singleJoiningSlash(target.Path, req.Path), singleJoiningSlash(target.EscapedPath(), req.EscapedPath())
+}
+
+func singleJoiningSlash(a, b string) string {
+	aSlash := strings.HasSuffix(a, "/")
+	bSlash := strings.HasPrefix(b, "/")
+	switch {
+	case aSlash && bSlash:
+		return a + b[1:]
+	case !aSlash && !bSlash:
+		return a + "/" + b
+	}
+	return a + b
+}
+
+type retryBody struct {
+	io.ReadCloser
+	read atomic.Bool
+}
+
+func (r *retryBody) Read(p []byte) (int, error) {
+	r.read.Store(true)
+	return r.ReadCloser.Read(p)
+}
+
+// Close the request body is closed by the server
+func (r *retryBody) Close() error {
+	return nil
+}
+
+type upstreamBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (u *upstreamBody) Close() error {
+	u.once.Do(u.done)
+	return u.ReadCloser.Close()
+}
+
+type upstreamRWBody struct {
+	io.ReadWriteCloser
+	once sync.Once
+	done func()
+}
+
+func (u *upstreamRWBody) Close() error {
+	u.once.Do(u.done)
+	return u.ReadWriteCloser.Close()
+}
@@ -0,0 +1,181 @@
+package easierweb
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Validator validate the request object after binding
+type Validator interface {
+	Validate(obj any) error
+}
+
+type FieldError struct {
+	Field   string `json:"field" xml:"Field" yaml:"field"`
+	Tag     string `json:"tag" xml:"Tag" yaml:"tag"`
+	Param   string `json:"param,omitempty" xml:"Param,omitempty" yaml:"param,omitempty"`
+	Message string `json:"message" xml:"Message" yaml:"message"`
+}
+
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(v))
+	for _, e := range v {
+		msgs = append(msgs, e.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// TagValidator validate struct fields by the validate tag
+// supported rules: required, omitempty, email, url, min, max, len, oneof
+// e.g. `validate:"required,email"`, `validate:"omitempty,min=1,max=10"`, `validate:"oneof=a b c"`
+type TagValidator struct {
+	TagName string
+}
+
+func NewTagValidator() *TagValidator {
+	return &TagValidator{TagName: "validate"}
+}
+
+func (t *TagValidator) Validate(obj any) error {
+	var errs ValidationErrors
+	t.validate(reflect.ValueOf(obj), "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (t *TagValidator) validate(v reflect.Value, prefix string, errs *ValidationErrors) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	vt := v.Type()
+	for i := 0; i < vt.NumField(); i++ {
+		sf := vt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		name := prefix + fieldName(sf)
+		tag := sf.Tag.Get(t.TagName)
+		if tag != "" && tag != "-" {
+			t.validateField(fv, name, tag, errs)
+		}
+		// nested struct
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			if sf.Anonymous {
+				t.validate(fv, prefix, errs)
+			} else {
+				t.validate(fv, name+".", errs)
+			}
+		}
+	}
+}
+
+func (t *TagValidator) validateField(fv reflect.Value, name, tag string, errs *ValidationErrors) {
+	rules := strings.Split(tag, ",")
+	empty := fv.IsZero()
+	for _, rule := range rules {
+		if rule == "omitempty" && empty {
+			return
+		}
+	}
+	for v := fv; v.Kind() == reflect.Ptr; v = v.Elem() {
+		if v.IsNil() {
+			break
+		}
+		fv = v.Elem()
+	}
+	for _, rule := range rules {
+		key, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		var msg string
+		switch key {
+		case "", "omitempty":
+			continue
+		case "required":
+			if empty {
+				msg = fmt.Sprintf("%s is required", name)
+			}
+		case "email":
+			if _, err := mail.ParseAddress(fv.String()); fv.Kind() != reflect.String || err != nil {
+				msg = fmt.Sprintf("%s must be a valid email address", name)
+			}
+		case "url":
+			if u, err := url.ParseRequestURI(fv.String()); fv.Kind() != reflect.String || err != nil || u.Scheme == "" || u.Host == "" {
+				msg = fmt.Sprintf("%s must be a valid url", name)
+			}
+		case "min", "max", "len":
+			n, ok := measure(fv)
+			limit, err := strconv.ParseFloat(param, 64)
+			if !ok || err != nil {
+				continue
+			}
+			if key == "min" && n < limit {
+				msg = fmt.Sprintf("%s must be at least %s", name, param)
+			} else if key == "max" && n > limit {
+				msg = fmt.Sprintf("%s must be at most %s", name, param)
+			} else if key == "len" && n != limit {
+				msg = fmt.Sprintf("%s must have a length of %s", name, param)
+			}
+		case "oneof":
+			value := fmt.Sprintf("%v", fv.Interface())
+			match := false
+			for _, option := range strings.Fields(param) {
+				if option == value {
+					match = true
+					break
+				}
+			}
+			if !match {
+				msg = fmt.Sprintf("%s must be one of [%s]", name, param)
+			}
+		default:
+			continue
+		}
+		if msg != "" {
+			*errs = append(*errs, FieldError{Field: name, Tag: key, Param: param, Message: msg})
+		}
+	}
+}
+
+// measure get the number value, or the length of string/slice/map/array
+func measure(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return float64(len([]rune(v.String()))), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	}
+	return 0, false
+}
+
+// fieldName use the json tag name first
+func fieldName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name != "" && name != "-" {
+		return name
+	}
+	return sf.Name
+}
@@ -0,0 +1,66 @@
+package easierweb
+
+import (
+	"fmt"
+	"testing"
+)
+
+// validator test
+
+func TestTagValidator(t *testing.T) {
+
+	fmt.Println("\n[TestTagValidator] start")
+
+	validator := NewTagValidator()
+
+	err := validator.Validate(&validatorTestDTO{
+		Name:  "test",
+		Email: "test@example.com",
+		Age:   18,
+		Type:  "a",
+		Tags:  []string{"a"},
+		Child: validatorTestChildDTO{Id: 1},
+	})
+	if err != nil {
+		t.Fatal("unexpected validation error:", err)
+	}
+
+	err = validator.Validate(&validatorTestDTO{
+		Email: "test",
+		Age:   200,
+		Type:  "d",
+		Tags:  []string{"a", "b", "c"},
+	})
+	if err == nil {
+		t.Fatal("validation error is expected")
+	}
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatal("validation error type does not match")
+	}
+	fmt.Println("[TestTagValidator] validation errors ->", errs)
+	expected := []string{"name", "email", "age", "type", "tags", "child.id"}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %d", len(expected), len(errs))
+	}
+	for i, field := range expected {
+		if errs[i].Field != field {
+			t.Errorf("expected field %s, got %s", field, errs[i].Field)
+		}
+	}
+
+	fmt.Println("\n[TestTagValidator] end")
+}
+
+type validatorTestDTO struct {
+	Name  string                `json:"name" validate:"required,max=10"`
+	Email string                `json:"email" validate:"omitempty,email"`
+	Age   int                   `json:"age" validate:"min=1,max=150"`
+	Type  string                `json:"type" validate:"oneof=a b c"`
+	Tags  []string              `json:"tags" validate:"max=2"`
+	Child validatorTestChildDTO `json:"child"`
+}
+
+type validatorTestChildDTO struct {
+	Id int64 `json:"id" validate:"required"`
+}
@@ -0,0 +1,453 @@
+package easierweb
+
+import (
+	"compress/flate"
+	"context"
+	"github.com/gorilla/websocket"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// websocket message types and close codes
+
+const (
+	TextMessage   = websocket.TextMessage
+	BinaryMessage = websocket.BinaryMessage
+	PingMessage   = websocket.PingMessage
+	PongMessage   = websocket.PongMessage
+)
+
+const (
+	CloseNormalClosure       = websocket.CloseNormalClosure
+	CloseGoingAway           = websocket.CloseGoingAway
+	CloseProtocolError       = websocket.CloseProtocolError
+	CloseUnsupportedData     = websocket.CloseUnsupportedData
+	CloseInvalidPayload      = websocket.CloseInvalidFramePayloadData
+	ClosePolicyViolation     = websocket.ClosePolicyViolation
+	CloseMessageTooBig       = websocket.CloseMessageTooBig
+	CloseInternalServerError = websocket.CloseInternalServerErr
+)
+
+type WebsocketOptions struct {
+	ReadBufferSize   int
+	WriteBufferSize  int
+	HandshakeTimeout time.Duration
+	// maximum size in bytes for a message read from the peer
+	ReadLimit int64
+	// deadline of each read operation, zero means no deadline
+	ReadTimeout time.Duration
+	// deadline of each write operation, zero means no deadline
+	WriteTimeout time.Duration
+	// send a ping every PingInterval, zero means no ping
+	PingInterval time.Duration
+	// close the connection (1001 going away) if no message or pong is received within the IdleTimeout, zero means no timeout
+	// the pongs are received while the handle is reading the messages
+	IdleTimeout time.Duration
+	// the buffered messages of each connection, they are written by a single goroutine, zero means the writes are synchronous
+	SendQueueSize int
+	// the policy when the send queue is full, SendQueueClose (default, close the slow client) or SendQueueDropOldest
+	SendQueuePolicy string
+	// the time of the clients to close the connections after the close frames are sent by Router.Close, default 5 seconds
+	// the remaining connections are closed after it (or the ShutdownTimeout)
+	ShutdownGracePeriod time.Duration
+	// permessage-deflate compression of the messages (negotiated with the client), it can be set per route by WSCompression
+	Compression WebsocketCompression
+	// verify the Origin header of the handshake, false responds 403, by default only the same origin is allowed (AllowedOrigins is empty)
+	CheckOrigin func(req *http.Request) bool
+	// the allowed origins of the handshake, e.g. https://example.com, "*" means all (opt out of the same-origin check), the requests without the Origin header are allowed
+	AllowedOrigins []string
+}
+
+type WebsocketCompression struct {
+	Enabled bool
+	// flate.BestSpeed (1) to flate.BestCompression (9), default 1, the lower levels use less memory and cpu
+	Level int
+	// the messages smaller than Threshold bytes are not compressed, default 512, negative means all messages are compressed
+	Threshold int
+	// maximum size in bytes of a decompressed message (1009 message too big if exceeded), default ReadLimit, zero means no limit
+	// ReadLimit limits the compressed size
+	MaxDecompressedSize int64
+}
+
+// WSConn the websocket connection, writes are safe for concurrent use
+type WSConn struct {
+	conn         *websocket.Conn
+	writeMu      sync.Mutex
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	closeOnce    sync.Once
+	closeErr     error
+	hooksMu      sync.Mutex
+	closeHooks   []func()
+	// the close hooks are called, the later hooks are called immediately
+	hooksCalled bool
+	// the unix nano time of the last message or pong
+	lastActive atomic.Int64
+	done       chan struct{}
+	queue      *sendQueue
+	// the messages smaller than the threshold are not compressed, zero means the compression is not enabled
+	compressThreshold int
+	maxMessageSize    int64
+	// the RouterOptions.JSONCodec of the router, used by Hub.BroadcastJSON
+	jsonCodec Codec
+}
+
+func newUpgrader(opt WebsocketOptions) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:    opt.ReadBufferSize,
+		WriteBufferSize:   opt.WriteBufferSize,
+		HandshakeTimeout:  opt.HandshakeTimeout,
+		EnableCompression: opt.Compression.Enabled,
+		CheckOrigin:       checkOrigin(opt),
+	}
+}
+
+func checkOrigin(opt WebsocketOptions) func(req *http.Request) bool {
+	if opt.CheckOrigin != nil {
+		return opt.CheckOrigin
+	}
+	return func(req *http.Request) bool {
+		origin := req.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		// only the same origin is allowed if the allowed origins are not set (cross-site websocket hijacking)
+		if len(opt.AllowedOrigins) == 0 {
+			u, err := url.Parse(origin)
+			return err == nil && strings.EqualFold(u.Host, req.Host)
+		}
+		for _, allowed := range opt.AllowedOrigins {
+			if allowed == "*" || strings.EqualFold(allowed, origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func newWSConn(conn *websocket.Conn, opt WebsocketOptions, jsonCodec Codec) *WSConn {
+	if opt.ReadLimit > 0 {
+		conn.SetReadLimit(opt.ReadLimit)
+	}
+	w := &WSConn{
+		conn:         conn,
+		readTimeout:  opt.ReadTimeout,
+		writeTimeout: opt.WriteTimeout,
+		done:         make(chan struct{}),
+		jsonCodec:    jsonCodec,
+	}
+	if opt.Compression.Enabled {
+		w.setCompression(opt.Compression, opt.ReadLimit)
+	}
+	w.active()
+	w.SetPongHandler(nil)
+	if opt.SendQueueSize > 0 {
+		w.queue = newSendQueue(w, opt.SendQueueSize, opt.SendQueuePolicy)
+	}
+	if opt.PingInterval > 0 || opt.IdleTimeout > 0 {
+		go w.heartbeat(opt.PingInterval, opt.IdleTimeout)
+	}
+	return w
+}
+
+func (w *WSConn) setCompression(opt WebsocketCompression, readLimit int64) {
+	if opt.Level == 0 {
+		opt.Level = flate.BestSpeed
+	}
+	_ = w.conn.SetCompressionLevel(opt.Level)
+	w.compressThreshold = opt.Threshold
+	if w.compressThreshold == 0 {
+		w.compressThreshold = 512
+	}
+	w.maxMessageSize = opt.MaxDecompressedSize
+	if w.maxMessageSize == 0 {
+		w.maxMessageSize = readLimit
+	}
+}
+
+// heartbeat send the pings and close the idle connection until the connection is closed
+func (w *WSConn) heartbeat(pingInterval, idleTimeout time.Duration) {
+	interval := pingInterval
+	if interval <= 0 || (idleTimeout > 0 && idleTimeout/2 < interval) {
+		interval = idleTimeout / 2
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+		if idleTimeout > 0 && time.Since(time.Unix(0, w.lastActive.Load())) > idleTimeout {
+			_ = w.CloseWithCode(CloseGoingAway, "idle timeout")
+			return
+		}
+		if pingInterval > 0 {
+			err := w.Ping(nil)
+			if err != nil {
+				_ = w.Close()
+				return
+			}
+		}
+	}
+}
+
+func (w *WSConn) active() {
+	w.lastActive.Store(time.Now().UnixNano())
+}
+
+// ReadMessage read a message, returns the message type (TextMessage or BinaryMessage) and data
+func (w *WSConn) ReadMessage() (int, []byte, error) {
+	if w.readTimeout > 0 {
+		err := w.conn.SetReadDeadline(time.Now().Add(w.readTimeout))
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+	messageType, data, err := w.readMessage()
+	if err == nil {
+		w.active()
+	}
+	return messageType, data, err
+}
+
+// readMessage read a message, the decompressed size is limited
+func (w *WSConn) readMessage() (int, []byte, error) {
+	if w.maxMessageSize <= 0 {
+		return w.conn.ReadMessage()
+	}
+	messageType, reader, err := w.conn.NextReader()
+	if err != nil {
+		return messageType, nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(reader, w.maxMessageSize+1))
+	if err != nil {
+		return messageType, nil, err
+	}
+	if int64(len(data)) > w.maxMessageSize {
+		_ = w.CloseWithCode(CloseMessageTooBig, "message too big")
+		return messageType, nil, websocket.ErrReadLimit
+	}
+	return messageType, data, nil
+}
+
+// WriteMessage write a message, the message type is TextMessage or BinaryMessage
+// if the send queue is enabled, the message is queued and written by the writer goroutine
+func (w *WSConn) WriteMessage(messageType int, data []byte) error {
+	if w.queue != nil {
+		return w.queue.push(messageType, data)
+	}
+	return w.writeMessage(messageType, data)
+}
+
+func (w *WSConn) writeMessage(messageType int, data []byte) error {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+	if w.writeTimeout > 0 {
+		err := w.conn.SetWriteDeadline(time.Now().Add(w.writeTimeout))
+		if err != nil {
+			return err
+		}
+	}
+	if w.compressThreshold != 0 {
+		w.conn.EnableWriteCompression(w.compressThreshold < 0 || len(data) >= w.compressThreshold)
+	}
+	return w.conn.WriteMessage(messageType, data)
+}
+
+func (w *WSConn) Ping(data []byte) error {
+	return w.conn.WriteControl(websocket.PingMessage, data, w.controlDeadline())
+}
+
+func (w *WSConn) SetPingHandler(h func(appData string) error) {
+	w.conn.SetPingHandler(h)
+}
+
+// SetPongHandler set the handler of the pongs, the pongs keep the connection active (IdleTimeout) with any handler
+func (w *WSConn) SetPongHandler(h func(appData string) error) {
+	w.conn.SetPongHandler(func(appData string) error {
+		w.active()
+		if h != nil {
+			return h(appData)
+		}
+		return nil
+	})
+}
+
+func (w *WSConn) SetReadDeadline(t time.Time) error {
+	return w.conn.SetReadDeadline(t)
+}
+
+func (w *WSConn) SetWriteDeadline(t time.Time) error {
+	return w.conn.SetWriteDeadline(t)
+}
+
+// SetReadLimit set the maximum size in bytes for a message read from the peer
+func (w *WSConn) SetReadLimit(limit int64) {
+	w.conn.SetReadLimit(limit)
+}
+
+func (w *WSConn) RemoteAddr() net.Addr {
+	return w.conn.RemoteAddr()
+}
+
+func (w *WSConn) Subprotocol() string {
+	return w.conn.Subprotocol()
+}
+
+// Conn get the underlying gorilla websocket connection
+func (w *WSConn) Conn() *websocket.Conn {
+	return w.conn
+}
+
+// CloseWithCode send a close frame with the code and reason, then close the connection
+func (w *WSConn) CloseWithCode(code int, reason string) error {
+	// the queued messages are written before the close frame
+	w.queue.close()
+	_ = w.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), w.controlDeadline())
+	return w.Close()
+}
+
+// Close close the underlying connection without sending a close frame, it can be called multiple times
+func (w *WSConn) Close() error {
+	w.queue.close()
+	w.closeOnce.Do(func() {
+		w.closeErr = w.conn.Close()
+		close(w.done)
+		w.hooksMu.Lock()
+		hooks := w.closeHooks
+		w.closeHooks = nil
+		w.hooksCalled = true
+		w.hooksMu.Unlock()
+		for _, hook := range hooks {
+			hook()
+		}
+	})
+	return w.closeErr
+}
+
+// OnClose register a function called after the connection is closed (by the server, the client or the idle timeout),
+// it is called immediately if the connection is already closed
+func (w *WSConn) OnClose(hook func()) {
+	w.hooksMu.Lock()
+	if w.hooksCalled {
+		w.hooksMu.Unlock()
+		hook()
+		return
+	}
+	w.closeHooks = append(w.closeHooks, hook)
+	w.hooksMu.Unlock()
+}
+
+// isClosed whether the connection is closed
+func (w *WSConn) isClosed() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (w *WSConn) controlDeadline() time.Time {
+	if w.writeTimeout > 0 {
+		return time.Now().Add(w.writeTimeout)
+	}
+	return time.Now().Add(10 * time.Second)
+}
+
+// shutdown write the queued messages and the close frame, the connection is closed by the client (or by the handle)
+func (w *WSConn) shutdown(code int, reason string) {
+	w.queue.close()
+	_ = w.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), w.controlDeadline())
+}
+
+// IsCloseError whether the error is a close error with one of the codes
+func IsCloseError(err error, codes ...int) bool {
+	return websocket.IsCloseError(err, codes...)
+}
+
+// WSCompression set the permessage-deflate compression of the last registered websocket route (overrides WebsocketOptions.Compression)
+// e.g. router.WS("/feed", feed).WSCompression(easierweb.WebsocketCompression{Enabled: true, Threshold: 1024})
+func (r *Router) WSCompression(opt WebsocketCompression) *Router {
+	for _, rt := range r.lastRoutes {
+		if rt.kind == routeKindWS {
+			rt.wsCompression = &opt
+		}
+	}
+	return r
+}
+
+// WSCompression see Router.WSCompression
+func (g *Group) WSCompression(opt WebsocketCompression) *Group {
+	g.router.WSCompression(opt)
+	return g
+}
+
+// WSConnections the number of the active websocket connections
+func (r *Router) WSConnections() int {
+	r.wsConnsLock.Lock()
+	defer r.wsConnsLock.Unlock()
+	return len(r.wsConns)
+}
+
+func (r *Router) trackWSConn(conn *WSConn) {
+	r.wsConnsLock.Lock()
+	if r.wsConns == nil {
+		r.wsConns = make(map[*WSConn]struct{})
+	}
+	r.wsConns[conn] = struct{}{}
+	r.wsConnsLock.Unlock()
+	conn.OnClose(func() {
+		r.wsConnsLock.Lock()
+		delete(r.wsConns, conn)
+		r.wsConnsLock.Unlock()
+	})
+}
+
+// closeWSConns send the close frames (1001 going away) to the websocket connections,
+// and close the remaining connections after the grace period (or when the ctx is done)
+func (r *Router) closeWSConns(ctx context.Context) {
+	r.wsConnsLock.Lock()
+	conns := make([]*WSConn, 0, len(r.wsConns))
+	for conn := range r.wsConns {
+		conns = append(conns, conn)
+	}
+	r.wsConnsLock.Unlock()
+	if len(conns) == 0 {
+		return
+	}
+	for _, conn := range conns {
+		// the queued messages of the slow clients should not block the others
+		go conn.shutdown(CloseGoingAway, "server shutdown")
+	}
+	grace := r.websocketOptions.ShutdownGracePeriod
+	if grace <= 0 {
+		grace = 5 * time.Second
+	}
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	// like http.Server.Shutdown, the connections are polled
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+wait:
+	for r.WSConnections() > 0 {
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			break wait
+		case <-ctx.Done():
+			break wait
+		}
+	}
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+}
@@ -0,0 +1,96 @@
+package easierweb
+
+import (
+	"fmt"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// websocket handshake test
+
+func TestWebsocketHandshakeRejected(t *testing.T) {
+
+	fmt.Println("\n[TestWebsocketHandshakeRejected] start")
+
+	var handled atomic.Bool
+	router := New()
+	// the middleware rejects the handshake by writing a response without calling Abort
+	router.WS("/ws", func(ctx *Context) {
+		handled.Store(true)
+	}, func(ctx *Context) {
+		if ctx.Request.Header.Get("Authorization") == "" {
+			ctx.WriteString(http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		ctx.Next()
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	_, res, err := websocket.DefaultDialer.Dial(url, nil)
+	fmt.Println("[TestWebsocketHandshakeRejected] dial ->", err)
+	if err == nil || res == nil || res.StatusCode != http.StatusUnauthorized {
+		t.Fatal("the handshake is not rejected")
+	}
+	if handled.Load() {
+		t.Fatal("the handle is executed after the handshake is rejected")
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"token"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+	hubTestWait(handled.Load)
+	if !handled.Load() {
+		t.Fatal("the handle is not executed")
+	}
+
+	fmt.Println("\n[TestWebsocketHandshakeRejected] end")
+}
+
+// websocket origin test
+
+func TestWebsocketOrigin(t *testing.T) {
+
+	fmt.Println("\n[TestWebsocketOrigin] start")
+
+	cases := []struct {
+		allowed []string
+		origin  string
+		ok      bool
+	}{
+		// same origin by default
+		{nil, "", true},
+		{nil, "http://{host}", true},
+		{nil, "https://evil.example.com", false},
+		{[]string{"https://example.com"}, "https://example.com", true},
+		{[]string{"https://example.com"}, "https://evil.example.com", false},
+		{[]string{"*"}, "https://evil.example.com", true},
+	}
+	for _, c := range cases {
+		router := New(RouterOptions{Websocket: WebsocketOptions{AllowedOrigins: c.allowed}})
+		router.WS("/ws", func(ctx *Context) {})
+		server := httptest.NewServer(router)
+		header := http.Header{}
+		if c.origin != "" {
+			header.Set("Origin", strings.ReplaceAll(c.origin, "{host}", strings.TrimPrefix(server.URL, "http://")))
+		}
+		conn, res, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", header)
+		fmt.Println("[TestWebsocketOrigin] result ->", c.allowed, c.origin, err)
+		if conn != nil {
+			_ = conn.Close()
+		}
+		server.Close()
+		if (err == nil) != c.ok || (!c.ok && (res == nil || res.StatusCode != http.StatusForbidden)) {
+			t.Fatal("origin check does not match")
+		}
+	}
+
+	fmt.Println("\n[TestWebsocketOrigin] end")
+}
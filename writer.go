@@ -0,0 +1,145 @@
+package easierweb
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// responseWriter the response writer of the context, it records the status code and the number of bytes written
+// it supports the type assertions of http.Flusher, http.Hijacker and http.Pusher (if the underlying writer does not support them, Flush does nothing, Hijack and Push return an error)
+type responseWriter struct {
+	http.ResponseWriter
+	// atomic, it is read by the handle while the timeout response is written by the Timeout middleware
+	status atomic.Int32
+	size   int64
+	// the response is buffered if it is set (see ctx.BufferResponse), until it is committed or streamed
+	buffer  *BufferedResponse
+	maxSize int
+	hooks   []ResponseHook
+}
+
+func (w *responseWriter) reset(res http.ResponseWriter) {
+	w.ResponseWriter = res
+	w.status.Store(0)
+	w.size = 0
+	w.buffer = nil
+	clear(w.hooks)
+	w.hooks = w.hooks[:0]
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.buffer != nil && code >= http.StatusOK {
+		if w.status.Load() == 0 {
+			w.status.Store(int32(code))
+			w.buffer.Status = code
+		}
+		return
+	}
+	// the informational responses (e.g. 103 Early Hints) are not the final status
+	if w.status.Load() == 0 && (code >= http.StatusOK || code == http.StatusSwitchingProtocols) {
+		w.status.Store(int32(code))
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(data []byte) (int, error) {
+	if w.buffer != nil {
+		if w.status.Load() == 0 {
+			w.WriteHeader(http.StatusOK)
+		}
+		if len(w.buffer.Body)+len(data) <= w.maxSize {
+			w.buffer.Body = append(w.buffer.Body, data...)
+			return len(data), nil
+		}
+		// the large response is streamed
+		err := w.stream()
+		if err != nil {
+			return 0, err
+		}
+	}
+	w.status.CompareAndSwap(0, http.StatusOK)
+	n, err := w.ResponseWriter.Write(data)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if w.buffer != nil {
+		if w.status.Load() == 0 {
+			w.WriteHeader(http.StatusOK)
+		}
+		// the flushed response is streamed
+		_ = w.stream()
+	}
+	w.status.CompareAndSwap(0, http.StatusOK)
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.buffer = nil
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("the response writer does not support hijacking")
+}
+
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap the underlying response writer, e.g. for http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Written whether the response has been written (by the ctx.Write* methods or the response writer directly)
+func (c *Context) Written() bool {
+	return c.written || c.writer.status.Load() != 0
+}
+
+// skipWrite the response can only be written once, the later writes are skipped with a warning (e.g. an error after a partial write)
+func (c *Context) skipWrite() bool {
+	if !c.Written() {
+		return false
+	}
+	c.Logger.Warn("response is already written, the write is skipped", slog.String("route", c.Route), slog.String("caller", writeCaller()))
+	return true
+}
+
+// writeCaller the function that calls the ctx.Write* method, to locate the duplicate write
+func writeCaller() string {
+	pcs := make([]uintptr, 8)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "github.com/dpwgc/easierweb.(*Context).") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// ResponseStatus get the status code written to the client, zero if nothing is written, e.g. for the access log and metrics
+func (c *Context) ResponseStatus() int {
+	return int(c.writer.status.Load())
+}
+
+// ResponseSize get the number of the body bytes written to the client (after the compression if it is used)
+func (c *Context) ResponseSize() int64 {
+	return c.writer.size
+}
@@ -0,0 +1,108 @@
+package easierweb
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// the policies when the send queue of the websocket connection is full
+const (
+	SendQueueClose      = "close"
+	SendQueueDropOldest = "drop_oldest"
+)
+
+// ErrSendQueueClosed the message is written after the connection is closed (or the slow client is closed)
+var ErrSendQueueClosed = errors.New("websocket send queue is closed")
+
+type queuedMessage struct {
+	messageType int
+	data        []byte
+}
+
+// sendQueue the buffered messages of the websocket connection, written by a single goroutine
+type sendQueue struct {
+	conn     *WSConn
+	policy   string
+	mu       sync.Mutex
+	messages chan queuedMessage
+	closed   bool
+	// closed after the writer goroutine has written (or discarded) the queued messages
+	drained chan struct{}
+}
+
+func newSendQueue(conn *WSConn, size int, policy string) *sendQueue {
+	q := &sendQueue{
+		conn:     conn,
+		policy:   policy,
+		messages: make(chan queuedMessage, size),
+		drained:  make(chan struct{}),
+	}
+	go q.write()
+	return q
+}
+
+func (q *sendQueue) push(messageType int, data []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrSendQueueClosed
+	}
+	m := queuedMessage{messageType: messageType, data: data}
+	select {
+	case q.messages <- m:
+		return nil
+	default:
+	}
+	if q.policy == SendQueueDropOldest {
+		// only the writer goroutine receives concurrently, so there is room after dropping one
+		select {
+		case <-q.messages:
+		default:
+		}
+		q.messages <- m
+		return nil
+	}
+	// the slow client is closed, it cannot be waited for here
+	go func() {
+		_ = q.conn.CloseWithCode(ClosePolicyViolation, "slow client")
+	}()
+	return ErrSendQueueClosed
+}
+
+func (q *sendQueue) write() {
+	defer close(q.drained)
+	failed := false
+	for m := range q.messages {
+		if failed {
+			continue
+		}
+		err := q.conn.writeMessage(m.messageType, m.data)
+		if err != nil {
+			// the remaining messages are discarded
+			failed = true
+			go func() {
+				_ = q.conn.Close()
+			}()
+		}
+	}
+}
+
+// close stop accepting the messages, and wait for the queued messages to be written (bounded by the write timeout, default 10 seconds)
+func (q *sendQueue) close() {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.messages)
+	}
+	q.mu.Unlock()
+	timer := time.NewTimer(time.Until(q.conn.controlDeadline()))
+	defer timer.Stop()
+	select {
+	case <-q.drained:
+	case <-timer.C:
+	}
+}